@@ -0,0 +1,230 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/hcliff-zhang/playground/database"
+	"github.com/hcliff-zhang/playground/server/serverpb"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// unchangedFields are left out of audit diffs: updated_at changes on every
+// write and prescriptions are audited as records of their own.
+var unchangedFields = map[string]bool{
+	"updated_at":    true,
+	"prescriptions": true,
+}
+
+// auditRecord collects what one Service call touched. Every Service method
+// starts one with Service.audit and defers finish with its returned error,
+// returning the error finish gives back instead.
+type auditRecord struct {
+	db        database.Store
+	ctx       context.Context
+	span      trace.Span
+	event     database.AuditEvent
+	resources []string
+	patients  map[uint]bool
+	changes   map[string]interface{}
+}
+
+// audit starts the audit record of a call to method, and the call's span,
+// which finish ends. The returned context carries the span. The record is
+// stored even if ctx is cancelled.
+func (s *Service) audit(ctx context.Context, method string) (context.Context, *auditRecord) {
+	ctx, span := startSpan(ctx, method)
+	return ctx, &auditRecord{
+		db:   s.Store,
+		ctx:  context.WithoutCancel(ctx),
+		span: span,
+		event: database.AuditEvent{
+			Actor:  actor(ctx),
+			Method: method,
+		},
+		patients: make(map[uint]bool),
+	}
+}
+
+// patient records that the call concerns the patient with the given ID.
+func (a *auditRecord) patient(id uint64) {
+	if id == 0 || a.patients[uint(id)] {
+		return
+	}
+	a.patients[uint(id)] = true
+	a.resources = append(a.resources, fmt.Sprintf("patients/%d", id))
+}
+
+// prescription records that the call concerns a prescription and the patient
+// it belongs to, if known.
+func (a *auditRecord) prescription(id, patientID uint64) {
+	if id != 0 {
+		a.resources = append(a.resources, fmt.Sprintf("prescriptions/%d", id))
+	}
+	a.patient(patientID)
+}
+
+// patientList records every patient in a result, including the prescriptions
+// returned with them.
+func (a *auditRecord) patientList(patients []*serverpb.Patient) {
+	for _, p := range patients {
+		a.patient(p.Id)
+		a.prescriptionList(p.Prescriptions)
+	}
+}
+
+// prescriptionList records every prescription in a result.
+func (a *auditRecord) prescriptionList(prescriptions []*serverpb.Prescription) {
+	for _, pr := range prescriptions {
+		a.prescription(pr.Id, pr.PatientId)
+	}
+}
+
+// change records the fields that differ between the before and after states of
+// a written record. Either may be nil for a create or a delete.
+func (a *auditRecord) change(before, after proto.Message) {
+	b, bErr := fieldValues(before)
+	c, cErr := fieldValues(after)
+	if bErr != nil || cErr != nil {
+		log.Printf("audit: cannot diff %s: %v", a.event.Method, firstError(bErr, cErr))
+		return
+	}
+
+	if a.changes == nil {
+		a.changes = make(map[string]interface{})
+	}
+	for field := range union(b, c) {
+		if unchangedFields[field] || reflect.DeepEqual(b[field], c[field]) {
+			continue
+		}
+		a.changes[field] = map[string]interface{}{"before": b[field], "after": c[field]}
+	}
+}
+
+// finish stores the record with the outcome of err, ends the call's span and
+// returns the error the call should fail with. The audit trail fails closed:
+// a successful call whose record cannot be stored fails with Internal, so no
+// response leaves without a record of it, even though a write it made stays
+// applied.
+func (a *auditRecord) finish(err error) (result error) {
+	defer func() { endSpan(a.span, result) }()
+
+	st := status.Convert(err)
+	a.event.Outcome = st.Code().String()
+	if err != nil {
+		a.event.Message = st.Message()
+	}
+	a.event.Resources = strings.Join(a.resources, " ")
+
+	ids := make([]uint, 0, len(a.patients))
+	for id := range a.patients {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, id := range ids {
+		a.event.Patients = append(a.event.Patients, database.AuditEventPatient{PatientID: id})
+	}
+
+	if len(a.changes) > 0 {
+		b, err := json.Marshal(a.changes)
+		if err != nil {
+			log.Printf("audit: cannot encode changes for %s: %v", a.event.Method, err)
+		}
+		a.event.Changes = string(b)
+	}
+
+	if auditErr := a.db.CreateAuditEvent(a.ctx, &a.event); auditErr != nil {
+		log.Printf("audit: failed to record %s by %s: %v", a.event.Method, a.event.Actor, auditErr)
+		if err == nil {
+			return status.Error(codes.Internal, "the call could not be recorded in the audit trail")
+		}
+	}
+	return err
+}
+
+// redactAuditEvent narrows ev, listed for the patient with the given ID, to
+// what concerns that patient. Events that also concern other patients, such as
+// listings, keep only the resource naming the patient and lose their changes
+// and error message, which may describe the others.
+func redactAuditEvent(ev *serverpb.AuditEvent, patientID uint64) {
+	if len(ev.PatientIds) <= 1 {
+		return
+	}
+	ev.PatientIds = []uint64{patientID}
+	own := fmt.Sprintf("patients/%d", patientID)
+	var resources []string
+	for _, r := range ev.Resources {
+		if r == own {
+			resources = append(resources, r)
+		}
+	}
+	ev.Resources = resources
+	ev.Changes = nil
+	ev.Message = ""
+}
+
+// actor identifies the caller for the audit trail by the subject of its
+// principal. Unauthenticated callers are named by network address, preferring
+// the client address forwarded by the HTTP gateway.
+func actor(ctx context.Context) string {
+	if p, ok := PrincipalFromContext(ctx); ok {
+		return p.Subject
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if fwd := md.Get("x-forwarded-for"); len(fwd) > 0 {
+			return "anonymous@" + strings.TrimSpace(strings.Split(fwd[0], ",")[0])
+		}
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return "anonymous@" + p.Addr.String()
+	}
+	return "anonymous"
+}
+
+// fieldValues returns the JSON values of the populated fields of msg keyed by
+// proto field name. A nil message has no fields.
+func fieldValues(msg proto.Message) (map[string]interface{}, error) {
+	values := make(map[string]interface{})
+	if msg == nil || reflect.ValueOf(msg).IsNil() {
+		return values, nil
+	}
+	b, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func union(a, b map[string]interface{}) map[string]bool {
+	keys := make(map[string]bool, len(a)+len(b))
+	for k := range a {
+		keys[k] = true
+	}
+	for k := range b {
+		keys[k] = true
+	}
+	return keys
+}
+
+func firstError(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
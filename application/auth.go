@@ -0,0 +1,224 @@
+package application
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	// Subject is the token's "sub" claim, which identifies the caller.
+	Subject string
+	// Issuer is the token's "iss" claim.
+	Issuer string
+	// Email is the token's "email" claim, if any.
+	Email string
+	// Claims holds every claim of the token.
+	Claims jwt.MapClaims
+	// Certificate is the verified client certificate of a caller authenticated
+	// by mutual TLS rather than a token.
+	Certificate *x509.Certificate
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying p.
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext returns the principal authenticated for the request
+// ctx belongs to, if any.
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok && p != nil
+}
+
+// Authenticator verifies a bearer token and returns the principal it names.
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (*Principal, error)
+}
+
+// publicServices are the gRPC services callable without authentication, so
+// that probes and load balancers can check health.
+var publicServices = map[string]bool{
+	healthpb.Health_ServiceDesc.ServiceName: true,
+}
+
+// isPublicMethod reports whether a gRPC method belongs to one of
+// publicServices.
+func isPublicMethod(fullMethod string) bool {
+	service, _, ok := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	return ok && publicServices[service]
+}
+
+// jwtMethods are the asymmetric signing algorithms accepted for JWTs. HMAC and
+// "none" are never accepted, as the key set only holds public keys.
+var jwtMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+// JWTAuthenticator authenticates JWTs signed by a key in a KeySet, as issued
+// by an OIDC provider.
+type JWTAuthenticator struct {
+	keys   KeySet
+	parser *jwt.Parser
+}
+
+// NewJWTAuthenticator returns an Authenticator accepting unexpired JWTs signed
+// by a key in keys. When issuer or audience are set, the token's "iss" claim
+// must equal issuer and its "aud" claim must contain audience.
+func NewJWTAuthenticator(keys KeySet, issuer, audience string) *JWTAuthenticator {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(jwtMethods),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(30 * time.Second),
+	}
+	if issuer != "" {
+		opts = append(opts, jwt.WithIssuer(issuer))
+	}
+	if audience != "" {
+		opts = append(opts, jwt.WithAudience(audience))
+	}
+	return &JWTAuthenticator{keys: keys, parser: jwt.NewParser(opts...)}
+}
+
+// Authenticate verifies token and returns the principal named by its claims.
+func (a *JWTAuthenticator) Authenticate(ctx context.Context, token string) (*Principal, error) {
+	claims := jwt.MapClaims{}
+	_, err := a.parser.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return a.keys.Key(ctx, kid)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sub, _ := claims.GetSubject()
+	if sub == "" {
+		return nil, fmt.Errorf("token has no subject")
+	}
+	iss, _ := claims.GetIssuer()
+	email, _ := claims["email"].(string)
+	return &Principal{Subject: sub, Issuer: iss, Email: email, Claims: claims}, nil
+}
+
+// AuthUnaryServerInterceptor authenticates every call with the bearer token
+// in its "authorization" metadata, which the HTTP gateway forwards from the
+// Authorization header, and stores the principal in the call's context.
+// Direct gRPC calls without a token, and REST requests to the in-process
+// gateway, may instead authenticate with a client certificate verified by
+// mutual TLS. Other calls fail with Unauthenticated,
+// except to publicServices.
+func AuthUnaryServerInterceptor(auth Authenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if isPublicMethod(info.FullMethod) {
+			return handler(ctx, req)
+		}
+		ctx, err := authenticate(ctx, auth)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// authenticate identifies the caller of the incoming call ctx belongs to, by
+// bearer token or client certificate, and returns ctx with its principal.
+func authenticate(ctx context.Context, auth Authenticator) (context.Context, error) {
+	token, err := bearerToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if token == "" {
+		if p, ok := certificatePrincipal(ctx); ok {
+			return WithPrincipal(ctx, p), nil
+		}
+		return nil, status.Error(codes.Unauthenticated, "missing bearer token")
+	}
+	p, err := auth.Authenticate(ctx, token)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid bearer token: %v", err)
+	}
+	return WithPrincipal(ctx, p), nil
+}
+
+// bearerToken extracts the token from the "authorization: Bearer <token>"
+// metadata of an incoming call. It returns "" if there is no such metadata.
+func bearerToken(ctx context.Context) (string, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return "", nil
+	}
+	scheme, token, ok := strings.Cut(values[0], " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return "", status.Error(codes.Unauthenticated, "authorization must be a bearer token")
+	}
+	return strings.TrimSpace(token), nil
+}
+
+type gatewayPeerKey struct{}
+
+// withGatewayPeer returns ctx with the HTTP client that sent r as the peer of
+// the gRPC call the in-process gateway makes for it, as a direct gRPC call from
+// that client would have. Its client certificate then identifies it like one.
+func withGatewayPeer(ctx context.Context, r *http.Request) context.Context {
+	pr := &peer.Peer{}
+	if addr, err := netip.ParseAddrPort(r.RemoteAddr); err == nil {
+		pr.Addr = net.TCPAddrFromAddrPort(addr)
+	}
+	if r.TLS != nil {
+		pr.AuthInfo = credentials.TLSInfo{
+			State:          *r.TLS,
+			CommonAuthInfo: credentials.CommonAuthInfo{SecurityLevel: credentials.PrivacyAndIntegrity},
+		}
+	}
+	return context.WithValue(peer.NewContext(ctx, pr), gatewayPeerKey{}, true)
+}
+
+// certificatePrincipal identifies the caller by the client certificate it
+// presented over mutual TLS, if one was verified. Its subject is the first URI
+// SAN, such as a SPIFFE ID, or else the common name. Calls relayed by the HTTP
+// gateway over a connection, which carry x-forwarded-for metadata, are never
+// identified this way so that anonymous HTTP requests do not take on the
+// gateway's identity. The in-process gateway's calls are, since their peer is
+// the HTTP client itself.
+func certificatePrincipal(ctx context.Context) (*Principal, bool) {
+	inProcess, _ := ctx.Value(gatewayPeerKey{}).(bool)
+	if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get("x-forwarded-for")) > 0 && !inProcess {
+		return nil, false
+	}
+	pr, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil, false
+	}
+	info, ok := pr.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.PeerCertificates) == 0 {
+		return nil, false
+	}
+
+	cert := info.State.PeerCertificates[0]
+	subject := cert.Subject.CommonName
+	if len(cert.URIs) > 0 {
+		subject = cert.URIs[0].String()
+	}
+	if subject == "" {
+		return nil, false
+	}
+	return &Principal{Subject: subject, Issuer: cert.Issuer.String(), Certificate: cert}, true
+}
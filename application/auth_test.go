@@ -0,0 +1,130 @@
+package application
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// newSigningKey returns a fresh ES256 key.
+func newSigningKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("ecdsa.GenerateKey: %v", err)
+	}
+	return key
+}
+
+// signToken returns claims signed by key as a JWT naming kid, if set.
+func signToken(t *testing.T, key *ecdsa.PrivateKey, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	s, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("SignedString: %v", err)
+	}
+	return s
+}
+
+func TestJWTAuthenticator(t *testing.T) {
+	key, other := newSigningKey(t), newSigningKey(t)
+	auth := NewJWTAuthenticator(StaticKeySet{"k1": &key.PublicKey}, "https://idp.example.com", "playground")
+
+	now := time.Now()
+	claims := func(edit func(jwt.MapClaims)) jwt.MapClaims {
+		c := jwt.MapClaims{
+			"sub":   "dr-a",
+			"iss":   "https://idp.example.com",
+			"aud":   []string{"playground", "other"},
+			"exp":   now.Add(time.Hour).Unix(),
+			"iat":   now.Unix(),
+			"email": "dr-a@example.com",
+		}
+		if edit != nil {
+			edit(c)
+		}
+		return c
+	}
+
+	hmacToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims(nil)).SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatalf("SignedString: %v", err)
+	}
+	noneToken, err := jwt.NewWithClaims(jwt.SigningMethodNone, claims(nil)).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatalf("SignedString: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		ok    bool
+	}{
+		{name: "valid", token: signToken(t, key, "k1", claims(nil)), ok: true},
+		{name: "no kid with a single key", token: signToken(t, key, "", claims(nil)), ok: true},
+		{name: "expired within leeway", token: signToken(t, key, "k1", claims(func(c jwt.MapClaims) { c["exp"] = now.Add(-10 * time.Second).Unix() })), ok: true},
+		{name: "expired", token: signToken(t, key, "k1", claims(func(c jwt.MapClaims) { c["exp"] = now.Add(-time.Minute).Unix() }))},
+		{name: "no expiry", token: signToken(t, key, "k1", claims(func(c jwt.MapClaims) { delete(c, "exp") }))},
+		{name: "issued in the future", token: signToken(t, key, "k1", claims(func(c jwt.MapClaims) { c["iat"] = now.Add(time.Hour).Unix() }))},
+		{name: "wrong audience", token: signToken(t, key, "k1", claims(func(c jwt.MapClaims) { c["aud"] = "billing" }))},
+		{name: "no audience", token: signToken(t, key, "k1", claims(func(c jwt.MapClaims) { delete(c, "aud") }))},
+		{name: "wrong issuer", token: signToken(t, key, "k1", claims(func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" }))},
+		{name: "no subject", token: signToken(t, key, "k1", claims(func(c jwt.MapClaims) { delete(c, "sub") }))},
+		{name: "unknown kid", token: signToken(t, key, "k2", claims(nil))},
+		{name: "signed by another key", token: signToken(t, other, "k1", claims(nil))},
+		{name: "HMAC signed", token: hmacToken},
+		{name: "unsigned", token: noneToken},
+		{name: "malformed", token: "not.a.jwt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := auth.Authenticate(context.Background(), tt.token)
+			if (err == nil) != tt.ok {
+				t.Fatalf("Authenticate = %v, %v; want ok %v", p, err, tt.ok)
+			}
+			if err == nil && (p.Subject != "dr-a" || p.Issuer != "https://idp.example.com" || p.Email != "dr-a@example.com") {
+				t.Errorf("Authenticate = %+v", p)
+			}
+		})
+	}
+}
+
+func TestBearerToken(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []string
+		token  string
+		code   codes.Code
+	}{
+		{name: "none"},
+		{name: "bearer", values: []string{"Bearer abc"}, token: "abc"},
+		{name: "scheme is case-insensitive", values: []string{"bearer  abc "}, token: "abc"},
+		{name: "basic", values: []string{"Basic abc"}, code: codes.Unauthenticated},
+		{name: "no token", values: []string{"Bearer "}, code: codes.Unauthenticated},
+		{name: "no scheme", values: []string{"abc"}, code: codes.Unauthenticated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.values != nil {
+				ctx = metadata.NewIncomingContext(ctx, metadata.MD{"authorization": tt.values})
+			}
+			token, err := bearerToken(ctx)
+			if token != tt.token || status.Code(err) != tt.code {
+				t.Errorf("bearerToken(%q) = %q, %v; want %q, %v", tt.values, token, err, tt.token, tt.code)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package application
+
+import (
+	"context"
+
+	"github.com/hcliff-zhang/playground/database"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// access is what the policy grants the caller of one Service call.
+type access struct {
+	// careTeam, if set, restricts the call to the patients in the care team
+	// of this member.
+	careTeam string
+}
+
+// WithPolicy enforces p on every call. Without a policy all calls are allowed,
+// which is only appropriate when authentication is disabled.
+func WithPolicy(p *Policy) Option {
+	return func(s *Service) {
+		s.policy = p
+	}
+}
+
+// authorize checks that the policy allows the caller to make the call rec is
+// recording. Denials fail with PermissionDenied and so end up in the audit
+// trail.
+func (s *Service) authorize(ctx context.Context, rec *auditRecord) (access, error) {
+	if s.policy == nil {
+		return access{}, nil
+	}
+	p, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return access{}, status.Error(codes.Unauthenticated, "authentication required")
+	}
+
+	method := rec.event.Method
+	allowed, careTeamOnly := s.policy.Allow(method, s.policy.Roles(p))
+	if !allowed {
+		return access{}, status.Errorf(codes.PermissionDenied, "%s may not call %s", p.Subject, method)
+	}
+	if careTeamOnly {
+		return access{careTeam: p.Subject}, nil
+	}
+	return access{}, nil
+}
+
+// checkPatient fails with PermissionDenied unless acc covers the patient. A
+// zero patientID, such as that of an unassigned prescription, is only covered
+// by unrestricted access.
+func (s *Service) checkPatient(ctx context.Context, acc access, patientID uint64) error {
+	return dbError(checkPatientIn(ctx, s.Store, acc, patientID), "care team member")
+}
+
+// checkPatientIn is checkPatient reading care teams from db, such as a
+// transaction. Database errors are returned as they are, tagged with their
+// resource, for the transaction to retry or dbError to translate.
+func checkPatientIn(ctx context.Context, db database.Store, acc access, patientID uint64) error {
+	if acc.careTeam == "" {
+		return nil
+	}
+	if patientID != 0 {
+		ok, err := db.IsCareTeamMember(ctx, uint(patientID), acc.careTeam)
+		if err != nil {
+			return forResource(err, "care team member")
+		}
+		if ok {
+			return nil
+		}
+	}
+	return status.Errorf(codes.PermissionDenied, "%s is not in the care team of patient %d", acc.careTeam, patientID)
+}
+
+// prescriptionPatient returns the ID of the patient pr belongs to, or 0 if it
+// is unassigned.
+func prescriptionPatient(pr *database.Prescription) uint64 {
+	if pr.PatientID == nil {
+		return 0
+	}
+	return uint64(*pr.PatientID)
+}
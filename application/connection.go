@@ -2,12 +2,16 @@ package application
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	pb "github.com/hcliff-zhang/playground/server/serverpb"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 )
 
@@ -18,14 +22,26 @@ func RegisterGRPCHandlers(grpcServer *grpc.Server, service pb.ApiServer) {
 }
 
 // RegisterHTTPGateway creates and registers a gRPC gateway handler that proxies
-// HTTP requests to the gRPC server running on the specified port.
-// It returns an HTTP handler that can be used to serve the gateway.
-func RegisterHTTPGateway(ctx context.Context, grpcPort string) (http.Handler, error) {
+// HTTP requests to the gRPC server running on the specified port, connecting
+// with creds, or in plaintext if creds is nil.
+// It returns an HTTP handler that can be used to serve the gateway, configured
+// with muxOpts. The gateway forwards each request's Authorization header as "authorization"
+// metadata, so callers authenticate to the gRPC server with the same bearer
+// token over either protocol.
+func RegisterHTTPGateway(ctx context.Context, grpcPort string, creds credentials.TransportCredentials, muxOpts ...runtime.ServeMuxOption) (http.Handler, error) {
 	// Create a new gRPC gateway multiplexer
-	mux := runtime.NewServeMux()
+	mux := runtime.NewServeMux(muxOpts...)
 
 	// Set up a connection to the gRPC server
-	opts := []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	if creds == nil {
+		creds = insecure.NewCredentials()
+	}
+	// Calls continue the trace of the HTTP request, passing it on in the
+	// traceparent metadata
+	opts := []grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+	}
 	grpcEndpoint := fmt.Sprintf("localhost%s", grpcPort)
 
 	// Register the service handler
@@ -36,3 +52,152 @@ func RegisterHTTPGateway(ctx context.Context, grpcPort string) (http.Handler, er
 
 	return mux, nil
 }
+
+// RegisterLocalGateway creates a gRPC gateway handler like RegisterHTTPGateway,
+// but one that calls service in-process instead of over a connection. Each
+// call runs through interceptors, chained in order as on the gRPC server, with
+// the HTTP client as its peer, so REST requests are logged, counted and
+// authenticated, by bearer token or client certificate, like gRPC calls.
+func RegisterLocalGateway(ctx context.Context, service pb.ApiServer, interceptors []grpc.UnaryServerInterceptor, muxOpts ...runtime.ServeMuxOption) (http.Handler, error) {
+	mux := runtime.NewServeMux(muxOpts...)
+
+	api := &localApi{server: service, interceptor: chainUnaryInterceptors(interceptors)}
+	if err := pb.RegisterApiHandlerServer(ctx, mux, api); err != nil {
+		return nil, fmt.Errorf("failed to register gateway handler: %w", err)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mux.ServeHTTP(w, r.WithContext(withGatewayPeer(r.Context(), r)))
+	}), nil
+}
+
+// chainUnaryInterceptors returns an interceptor running interceptors in order
+// around the handler, as grpc.ChainUnaryInterceptor does on a server.
+func chainUnaryInterceptors(interceptors []grpc.UnaryServerInterceptor) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		next := handler
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			interceptor, inner := interceptors[i], next
+			next = func(ctx context.Context, req interface{}) (interface{}, error) {
+				return interceptor(ctx, req, info, inner)
+			}
+		}
+		return next(ctx, req)
+	}
+}
+
+// localApi is the Api server the in-process gateway calls, which passes every
+// call through interceptor on its way to server.
+type localApi struct {
+	pb.UnimplementedApiServer
+	server      pb.ApiServer
+	interceptor grpc.UnaryServerInterceptor
+}
+
+// intercept calls method of a.server, implemented by call, through a's
+// interceptor.
+func intercept[Req, Resp any](ctx context.Context, a *localApi, method string, req Req, call func(context.Context, Req) (Resp, error)) (Resp, error) {
+	info := &grpc.UnaryServerInfo{Server: a.server, FullMethod: method}
+	resp, err := a.interceptor(ctx, req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return call(ctx, req.(Req))
+	})
+	if err != nil {
+		var none Resp
+		return none, err
+	}
+	return resp.(Resp), nil
+}
+
+func (a *localApi) CreatePatient(ctx context.Context, req *pb.CreatePatientRequest) (*pb.CreatePatientResponse, error) {
+	return intercept(ctx, a, pb.Api_CreatePatient_FullMethodName, req, a.server.CreatePatient)
+}
+
+func (a *localApi) GetPatient(ctx context.Context, req *pb.GetPatientRequest) (*pb.GetPatientResponse, error) {
+	return intercept(ctx, a, pb.Api_GetPatient_FullMethodName, req, a.server.GetPatient)
+}
+
+func (a *localApi) ListPatients(ctx context.Context, req *pb.ListPatientsRequest) (*pb.ListPatientsResponse, error) {
+	return intercept(ctx, a, pb.Api_ListPatients_FullMethodName, req, a.server.ListPatients)
+}
+
+func (a *localApi) SearchPatients(ctx context.Context, req *pb.SearchPatientsRequest) (*pb.SearchPatientsResponse, error) {
+	return intercept(ctx, a, pb.Api_SearchPatients_FullMethodName, req, a.server.SearchPatients)
+}
+
+func (a *localApi) UpdatePatient(ctx context.Context, req *pb.UpdatePatientRequest) (*pb.UpdatePatientResponse, error) {
+	return intercept(ctx, a, pb.Api_UpdatePatient_FullMethodName, req, a.server.UpdatePatient)
+}
+
+func (a *localApi) DeletePatient(ctx context.Context, req *pb.DeletePatientRequest) (*pb.DeletePatientResponse, error) {
+	return intercept(ctx, a, pb.Api_DeletePatient_FullMethodName, req, a.server.DeletePatient)
+}
+
+func (a *localApi) UndeletePatient(ctx context.Context, req *pb.UndeletePatientRequest) (*pb.UndeletePatientResponse, error) {
+	return intercept(ctx, a, pb.Api_UndeletePatient_FullMethodName, req, a.server.UndeletePatient)
+}
+
+func (a *localApi) CreatePrescription(ctx context.Context, req *pb.CreatePrescriptionRequest) (*pb.CreatePrescriptionResponse, error) {
+	return intercept(ctx, a, pb.Api_CreatePrescription_FullMethodName, req, a.server.CreatePrescription)
+}
+
+func (a *localApi) GetPrescription(ctx context.Context, req *pb.GetPrescriptionRequest) (*pb.GetPrescriptionResponse, error) {
+	return intercept(ctx, a, pb.Api_GetPrescription_FullMethodName, req, a.server.GetPrescription)
+}
+
+func (a *localApi) ListPrescriptionsForPatient(ctx context.Context, req *pb.ListPrescriptionsForPatientRequest) (*pb.ListPrescriptionsResponse, error) {
+	return intercept(ctx, a, pb.Api_ListPrescriptionsForPatient_FullMethodName, req, a.server.ListPrescriptionsForPatient)
+}
+
+func (a *localApi) ListPrescriptions(ctx context.Context, req *pb.ListPrescriptionsRequest) (*pb.ListPrescriptionsResponse, error) {
+	return intercept(ctx, a, pb.Api_ListPrescriptions_FullMethodName, req, a.server.ListPrescriptions)
+}
+
+func (a *localApi) UpdatePrescription(ctx context.Context, req *pb.UpdatePrescriptionRequest) (*pb.UpdatePrescriptionResponse, error) {
+	return intercept(ctx, a, pb.Api_UpdatePrescription_FullMethodName, req, a.server.UpdatePrescription)
+}
+
+func (a *localApi) DeletePrescription(ctx context.Context, req *pb.DeletePrescriptionRequest) (*pb.DeletePrescriptionResponse, error) {
+	return intercept(ctx, a, pb.Api_DeletePrescription_FullMethodName, req, a.server.DeletePrescription)
+}
+
+func (a *localApi) ListAuditEvents(ctx context.Context, req *pb.ListAuditEventsRequest) (*pb.ListAuditEventsResponse, error) {
+	return intercept(ctx, a, pb.Api_ListAuditEvents_FullMethodName, req, a.server.ListAuditEvents)
+}
+
+func (a *localApi) AddCareTeamMember(ctx context.Context, req *pb.AddCareTeamMemberRequest) (*pb.AddCareTeamMemberResponse, error) {
+	return intercept(ctx, a, pb.Api_AddCareTeamMember_FullMethodName, req, a.server.AddCareTeamMember)
+}
+
+func (a *localApi) RemoveCareTeamMember(ctx context.Context, req *pb.RemoveCareTeamMemberRequest) (*pb.RemoveCareTeamMemberResponse, error) {
+	return intercept(ctx, a, pb.Api_RemoveCareTeamMember_FullMethodName, req, a.server.RemoveCareTeamMember)
+}
+
+func (a *localApi) ListCareTeam(ctx context.Context, req *pb.ListCareTeamRequest) (*pb.ListCareTeamResponse, error) {
+	return intercept(ctx, a, pb.Api_ListCareTeam_FullMethodName, req, a.server.ListCareTeam)
+}
+
+// NewSinglePortServer returns an HTTP server on addr that hands gRPC requests,
+// recognised as HTTP/2 with an application/grpc content type, to grpcServer
+// and everything else to gateway. Without a TLS config it speaks both HTTP/1.1
+// and unencrypted HTTP/2, which gRPC clients use for plaintext connections.
+func NewSinglePortServer(addr string, grpcServer *grpc.Server, gateway http.Handler, tlsConfig *tls.Config) *http.Server {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+			grpcServer.ServeHTTP(w, r)
+			return
+		}
+		gateway.ServeHTTP(w, r)
+	})
+
+	var protocols http.Protocols
+	protocols.SetHTTP1(true)
+	protocols.SetHTTP2(true)
+	protocols.SetUnencryptedHTTP2(tlsConfig == nil)
+
+	return &http.Server{
+		Addr:      addr,
+		Handler:   handler,
+		TLSConfig: tlsConfig,
+		Protocols: &protocols,
+	}
+}
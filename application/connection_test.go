@@ -0,0 +1,62 @@
+package application
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+)
+
+// noTokens is an Authenticator rejecting every bearer token.
+type noTokens struct{}
+
+func (noTokens) Authenticate(context.Context, string) (*Principal, error) {
+	return nil, errors.New("no tokens are valid")
+}
+
+func TestLocalGateway(t *testing.T) {
+	var calls []string
+	record := func(name string) grpc.UnaryServerInterceptor {
+		return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			calls = append(calls, name+" "+info.FullMethod)
+			return handler(ctx, req)
+		}
+	}
+	interceptors := []grpc.UnaryServerInterceptor{record("first"), AuthUnaryServerInterceptor(noTokens{}), record("second")}
+	gateway, err := RegisterLocalGateway(context.Background(), newTestService(), interceptors)
+	if err != nil {
+		t.Fatalf("RegisterLocalGateway: %v", err)
+	}
+
+	// A verified client certificate identifies the REST caller
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "desk", OrganizationalUnit: []string{"front-desk"}}}
+	req := httptest.NewRequest(http.MethodPost, "/v1/patients", strings.NewReader(`{"patient": {"firstName": "Ann", "lastName": "Lee"}}`))
+	req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}, VerifiedChains: [][]*x509.Certificate{{cert}}}
+	rec := httptest.NewRecorder()
+	gateway.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("POST /v1/patients with a client certificate = %d %s", rec.Code, rec.Body)
+	}
+	want := []string{"first /serverpb.Api/CreatePatient", "second /serverpb.Api/CreatePatient"}
+	if len(calls) != len(want) || calls[0] != want[0] || calls[1] != want[1] {
+		t.Errorf("interceptors ran as %q, want %q", calls, want)
+	}
+
+	// Without one the caller needs a bearer token
+	calls = nil
+	rec = httptest.NewRecorder()
+	gateway.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/patients", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("GET /v1/patients without credentials = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+	if len(calls) != 1 {
+		t.Errorf("interceptors ran as %q, want only the first before authentication", calls)
+	}
+}
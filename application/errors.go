@@ -0,0 +1,213 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	gosqlite "github.com/glebarez/go-sqlite"
+	"github.com/jackc/pgx/v5/pgconn"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"gorm.io/gorm"
+)
+
+// errorDomain is reported in google.rpc.ErrorInfo details.
+const errorDomain = "playground"
+
+// Postgres SQLSTATE codes translated by dbError.
+const (
+	pgNotNullViolation    = "23502"
+	pgForeignKeyViolation = "23503"
+	pgUniqueViolation     = "23505"
+	pgCheckViolation      = "23514"
+	// pgQueryCanceled is also reported when a server-side statement_timeout
+	// cuts a statement short.
+	pgQueryCanceled = "57014"
+)
+
+// SQLite extended result codes translated by dbError.
+const (
+	sqliteCheckViolation      = 275
+	sqliteForeignKeyViolation = 787
+	sqliteNotNullViolation    = 1299
+	sqlitePrimaryKeyViolation = 1555
+	sqliteUniqueViolation     = 2067
+)
+
+// dbError translates an error returned by the database layer into a gRPC status
+// error. resource names the entity being operated on (e.g. "patient") and is
+// used as the field prefix in error details. Errors that already carry a status
+// are returned unchanged; anything unrecognised becomes Internal without leaking
+// driver messages to the caller.
+func dbError(err error, resource string) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	var re *resourceError
+	if errors.As(err, &re) {
+		resource = re.resource
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, "request canceled")
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, "request deadline exceeded")
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return withDetails(codes.NotFound, fmt.Sprintf("%s not found", resource),
+			errorInfo("NOT_FOUND", map[string]string{"resource": resource}))
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return withDetails(codes.AlreadyExists, fmt.Sprintf("%s already exists", resource),
+			errorInfo("ALREADY_EXISTS", map[string]string{"resource": resource}))
+	case errors.Is(err, gorm.ErrForeignKeyViolated):
+		return foreignKeyViolation(resource, "")
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case pgUniqueViolation:
+			return uniqueViolation(resource, violatedColumn(pgErr), pgErr.ConstraintName)
+		case pgForeignKeyViolation:
+			return foreignKeyViolation(resource, pgErr.ConstraintName)
+		case pgCheckViolation:
+			return checkViolation(resource, pgErr.ConstraintName)
+		case pgNotNullViolation:
+			return notNullViolation(resource, pgErr.ColumnName)
+		case pgQueryCanceled:
+			return status.Error(codes.DeadlineExceeded, "request deadline exceeded")
+		}
+	}
+
+	var sqliteErr *gosqlite.Error
+	if errors.As(err, &sqliteErr) {
+		switch sqliteErr.Code() {
+		case sqliteUniqueViolation, sqlitePrimaryKeyViolation:
+			column := sqliteConstraint(sqliteErr)
+			return uniqueViolation(resource, column, column)
+		case sqliteForeignKeyViolation:
+			return foreignKeyViolation(resource, "")
+		case sqliteCheckViolation:
+			return checkViolation(resource, sqliteConstraint(sqliteErr))
+		case sqliteNotNullViolation:
+			return notNullViolation(resource, sqliteConstraint(sqliteErr))
+		}
+	}
+
+	log.Printf("internal error on %s: %v", resource, err)
+	return status.Error(codes.Internal, "internal error")
+}
+
+// resourceError tags an error from a step of a transaction with the resource
+// the step concerns, which dbError then names instead of its default. It keeps
+// the database error, which decides whether the transaction is retried.
+type resourceError struct {
+	resource string
+	err      error
+}
+
+func (e *resourceError) Error() string { return e.resource + ": " + e.err.Error() }
+func (e *resourceError) Unwrap() error { return e.err }
+
+// forResource tags err, if any, as concerning resource.
+func forResource(err error, resource string) error {
+	if err == nil {
+		return nil
+	}
+	return &resourceError{resource: resource, err: err}
+}
+
+// uniqueViolation is the status of a write reusing a unique column value.
+func uniqueViolation(resource, column, constraint string) error {
+	return withDetails(codes.AlreadyExists, fmt.Sprintf("%s with the same %s already exists", resource, column),
+		errorInfo("ALREADY_EXISTS", map[string]string{"resource": resource, "constraint": constraint}),
+		badRequest(resource+"."+column, "must be unique"))
+}
+
+// foreignKeyViolation is the status of a write referencing a missing record.
+func foreignKeyViolation(resource, constraint string) error {
+	metadata := map[string]string{"resource": resource}
+	if constraint != "" {
+		metadata["constraint"] = constraint
+	}
+	return withDetails(codes.FailedPrecondition, fmt.Sprintf("%s references a record that does not exist", resource),
+		errorInfo("FOREIGN_KEY_VIOLATION", metadata))
+}
+
+// checkViolation is the status of a write failing a check constraint.
+func checkViolation(resource, constraint string) error {
+	return withDetails(codes.InvalidArgument, fmt.Sprintf("%s violates constraint %s", resource, constraint),
+		errorInfo("CHECK_VIOLATION", map[string]string{"resource": resource, "constraint": constraint}),
+		badRequest(resource, "violates constraint "+constraint))
+}
+
+// notNullViolation is the status of a write leaving a required column empty.
+func notNullViolation(resource, column string) error {
+	field := resource + "." + column
+	return withDetails(codes.InvalidArgument, fmt.Sprintf("%s is required", field),
+		errorInfo("NOT_NULL_VIOLATION", map[string]string{"resource": resource, "column": column}),
+		badRequest(field, "is required"))
+}
+
+// sqliteConstraint extracts what a SQLite constraint error names: the columns
+// of "UNIQUE constraint failed: patients.email" without their tables, or the
+// name of a check constraint.
+func sqliteConstraint(err *gosqlite.Error) string {
+	msg := err.Error()
+	if i := strings.LastIndex(msg, " constraint failed: "); i >= 0 {
+		msg = msg[i+len(" constraint failed: "):]
+	}
+	if i := strings.LastIndex(msg, " ("); i >= 0 {
+		msg = msg[:i]
+	}
+	columns := strings.Split(msg, ", ")
+	for i, column := range columns {
+		columns[i] = column[strings.LastIndex(column, ".")+1:]
+	}
+	return strings.Join(columns, ", ")
+}
+
+// violatedColumn extracts the column name from a unique violation. Postgres
+// reports it as "Key (email)=(...) already exists."; only the column list is
+// kept so that the offending value never reaches the caller.
+func violatedColumn(pgErr *pgconn.PgError) string {
+	if pgErr.ColumnName != "" {
+		return pgErr.ColumnName
+	}
+	if rest, ok := strings.CutPrefix(pgErr.Detail, "Key ("); ok {
+		if i := strings.Index(rest, ")="); i > 0 {
+			return rest[:i]
+		}
+	}
+	return pgErr.ConstraintName
+}
+
+// errorInfo builds a google.rpc.ErrorInfo detail in this service's domain.
+func errorInfo(reason string, metadata map[string]string) *errdetails.ErrorInfo {
+	return &errdetails.ErrorInfo{Reason: reason, Domain: errorDomain, Metadata: metadata}
+}
+
+// badRequest builds a google.rpc.BadRequest detail with a single field violation.
+func badRequest(field, description string) *errdetails.BadRequest {
+	return &errdetails.BadRequest{
+		FieldViolations: []*errdetails.BadRequest_FieldViolation{{Field: field, Description: description}},
+	}
+}
+
+// withDetails returns a status error carrying the given details. If the details
+// cannot be attached the bare status is returned.
+func withDetails(code codes.Code, msg string, details ...protoadapt.MessageV1) error {
+	st := status.New(code, msg)
+	if withDetails, err := st.WithDetails(details...); err == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
@@ -0,0 +1,127 @@
+package application
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	_ "github.com/glebarez/go-sqlite"
+	"github.com/jackc/pgx/v5/pgconn"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+// sqliteErrors returns the errors SQLite reports for each kind of constraint
+// violation, keyed by kind.
+func sqliteErrors(t *testing.T) map[string]error {
+	t.Helper()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	db.SetMaxOpenConns(1)
+	for _, stmt := range []string{
+		"PRAGMA foreign_keys = ON",
+		"CREATE TABLE patients (id INTEGER PRIMARY KEY, email TEXT UNIQUE, first_name TEXT NOT NULL, age INTEGER CONSTRAINT chk_age CHECK (age >= 0))",
+		"CREATE TABLE prescriptions (id INTEGER PRIMARY KEY, patient_id INTEGER REFERENCES patients (id))",
+		"INSERT INTO patients (id, email, first_name) VALUES (1, 'ann@example.com', 'Ann')",
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+
+	errs := make(map[string]error)
+	for kind, stmt := range map[string]string{
+		"unique":      "INSERT INTO patients (email, first_name) VALUES ('ann@example.com', 'Dee')",
+		"primary key": "INSERT INTO patients (id, first_name) VALUES (1, 'Dee')",
+		"foreign key": "INSERT INTO prescriptions (patient_id) VALUES (9)",
+		"check":       "INSERT INTO patients (first_name, age) VALUES ('Dee', -1)",
+		"not null":    "INSERT INTO patients (email) VALUES ('dee@example.com')",
+	} {
+		_, errs[kind] = db.Exec(stmt)
+		if errs[kind] == nil {
+			t.Fatalf("%s did not fail", stmt)
+		}
+	}
+	return errs
+}
+
+func TestDBError(t *testing.T) {
+	sqliteErrs := sqliteErrors(t)
+	denied := status.Error(codes.PermissionDenied, "denied")
+
+	tests := []struct {
+		name   string
+		err    error
+		code   codes.Code
+		reason string
+		// field is the field of a BadRequest violation, if one is expected
+		field string
+	}{
+		{name: "nil", err: nil, code: codes.OK},
+		{name: "status passes through", err: denied, code: codes.PermissionDenied},
+		{name: "canceled", err: fmt.Errorf("query: %w", context.Canceled), code: codes.Canceled},
+		{name: "deadline", err: context.DeadlineExceeded, code: codes.DeadlineExceeded},
+		{name: "not found", err: gorm.ErrRecordNotFound, code: codes.NotFound, reason: "NOT_FOUND"},
+		{name: "gorm duplicate", err: gorm.ErrDuplicatedKey, code: codes.AlreadyExists, reason: "ALREADY_EXISTS"},
+		{name: "gorm foreign key", err: gorm.ErrForeignKeyViolated, code: codes.FailedPrecondition, reason: "FOREIGN_KEY_VIOLATION"},
+
+		{name: "postgres unique", err: &pgconn.PgError{Code: pgUniqueViolation, ConstraintName: "idx_patients_email_nonempty", Detail: "Key (email)=(ann@example.com) already exists."},
+			code: codes.AlreadyExists, reason: "ALREADY_EXISTS", field: "patient.email"},
+		{name: "postgres foreign key", err: &pgconn.PgError{Code: pgForeignKeyViolation, ConstraintName: "fk_patients_prescriptions"}, code: codes.FailedPrecondition, reason: "FOREIGN_KEY_VIOLATION"},
+		{name: "postgres check", err: &pgconn.PgError{Code: pgCheckViolation, ConstraintName: "chk_quantity"}, code: codes.InvalidArgument, reason: "CHECK_VIOLATION", field: "patient"},
+		{name: "postgres not null", err: &pgconn.PgError{Code: pgNotNullViolation, ColumnName: "first_name"}, code: codes.InvalidArgument, reason: "NOT_NULL_VIOLATION", field: "patient.first_name"},
+		{name: "postgres statement timeout", err: &pgconn.PgError{Code: pgQueryCanceled}, code: codes.DeadlineExceeded},
+		{name: "postgres other", err: &pgconn.PgError{Code: "42P01", Message: "relation \"secret\" does not exist"}, code: codes.Internal},
+
+		{name: "sqlite unique", err: sqliteErrs["unique"], code: codes.AlreadyExists, reason: "ALREADY_EXISTS", field: "patient.email"},
+		{name: "sqlite primary key", err: sqliteErrs["primary key"], code: codes.AlreadyExists, reason: "ALREADY_EXISTS", field: "patient.id"},
+		{name: "sqlite foreign key", err: sqliteErrs["foreign key"], code: codes.FailedPrecondition, reason: "FOREIGN_KEY_VIOLATION"},
+		{name: "sqlite check", err: sqliteErrs["check"], code: codes.InvalidArgument, reason: "CHECK_VIOLATION", field: "patient"},
+		{name: "sqlite not null", err: sqliteErrs["not null"], code: codes.InvalidArgument, reason: "NOT_NULL_VIOLATION", field: "patient.first_name"},
+
+		{name: "unknown", err: errors.New("connection reset by 10.0.0.7"), code: codes.Internal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := dbError(tt.err, "patient")
+			st := status.Convert(err)
+			if st.Code() != tt.code {
+				t.Fatalf("dbError(%v) = %v, want %v", tt.err, err, tt.code)
+			}
+
+			var reason, field string
+			for _, d := range st.Details() {
+				switch d := d.(type) {
+				case *errdetails.ErrorInfo:
+					reason = d.Reason
+				case *errdetails.BadRequest:
+					field = d.FieldViolations[0].Field
+				}
+			}
+			if reason != tt.reason || field != tt.field {
+				t.Errorf("dbError(%v) details have reason %q and field %q, want %q and %q", tt.err, reason, field, tt.reason, tt.field)
+			}
+			// Driver messages and the values they quote stay out of responses
+			for _, leak := range []string{"ann@example.com", "secret", "10.0.0.7"} {
+				if strings.Contains(st.Message(), leak) {
+					t.Errorf("dbError(%v) message %q leaks %q", tt.err, st.Message(), leak)
+				}
+			}
+		})
+	}
+}
+
+func TestDBErrorNamesTaggedResource(t *testing.T) {
+	err := dbError(forResource(gorm.ErrRecordNotFound, "care team member"), "patient")
+	if got := status.Convert(err).Message(); got != "care team member not found" {
+		t.Errorf("dbError of a tagged error = %q, want the tagged resource named", got)
+	}
+}
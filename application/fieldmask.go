@@ -0,0 +1,114 @@
+package application
+
+import (
+	"github.com/hcliff-zhang/playground/server/serverpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// patientMaskColumns maps the updatable serverpb.Patient field paths to their
+// database columns.
+var patientMaskColumns = map[string]string{
+	"first_name": "first_name",
+	"last_name":  "last_name",
+	"gender":     "gender",
+	"email":      "email",
+	"phone":      "phone",
+	"address":    "address",
+}
+
+// prescriptionMaskColumns maps the updatable serverpb.Prescription field paths
+// to their database columns.
+var prescriptionMaskColumns = map[string]string{
+	"medication":    "medication",
+	"dosage":        "dosage",
+	"frequency":     "frequency",
+	"quantity":      "quantity",
+	"notes":         "notes",
+	"status":        "status",
+	"prescribed_at": "prescribed_at",
+	"patient_id":    "patient_id",
+}
+
+// isFullReplacement reports whether mask asks for every field to be replaced.
+func isFullReplacement(mask *fieldmaskpb.FieldMask) bool {
+	paths := mask.GetPaths()
+	return len(paths) == 0 || (len(paths) == 1 && paths[0] == "*")
+}
+
+// outputOnlyPaths are fields set by the server. They are ignored in update
+// masks so that a resource read from the API can be sent back unchanged.
+var outputOnlyPaths = map[string]bool{
+	"id":         true,
+	"created_at": true,
+	"updated_at": true,
+	"deleted_at": true,
+}
+
+// keptWhenUnset are fields that a full replacement leaves untouched when the
+// caller does not set them, because they have server-side defaults or clearing
+// them would detach the record from its patient.
+var keptWhenUnset = map[string]bool{
+	"status":        true,
+	"prescribed_at": true,
+	"patient_id":    true,
+}
+
+// maskedUpdates collects the values of msg named by mask into a column/value map
+// suitable for a gorm Updates call. A full replacement mask selects every
+// column. Output-only paths are ignored; any other path not in columns is
+// rejected with InvalidArgument.
+func maskedUpdates(msg proto.Message, mask *fieldmaskpb.FieldMask, columns map[string]string) (map[string]interface{}, error) {
+	m := msg.ProtoReflect()
+	fields := m.Descriptor().Fields()
+
+	paths := mask.GetPaths()
+	full := isFullReplacement(mask)
+	if full {
+		paths = make([]string, 0, len(columns))
+		for path := range columns {
+			paths = append(paths, path)
+		}
+	}
+
+	updates := make(map[string]interface{}, len(paths))
+	for _, path := range paths {
+		if outputOnlyPaths[path] {
+			continue
+		}
+		column, ok := columns[path]
+		if !ok {
+			return nil, status.Errorf(codes.InvalidArgument, "update_mask: field %q does not exist or cannot be updated", path)
+		}
+		fd := fields.ByName(protoreflect.Name(path))
+		if full && keptWhenUnset[path] && !m.Has(fd) {
+			continue
+		}
+		updates[column] = columnValue(m.Get(fd))
+	}
+	return updates, nil
+}
+
+// columnValue converts a proto field value to the Go value stored in its column.
+func columnValue(v protoreflect.Value) interface{} {
+	if msg, ok := v.Interface().(protoreflect.Message); ok {
+		if ts, ok := msg.Interface().(*timestamppb.Timestamp); ok {
+			return ts.AsTime()
+		}
+	}
+	return v.Interface()
+}
+
+// patientUpdates returns the column updates for a masked patient update.
+func patientUpdates(p *serverpb.Patient, mask *fieldmaskpb.FieldMask) (map[string]interface{}, error) {
+	return maskedUpdates(p, mask, patientMaskColumns)
+}
+
+// prescriptionUpdates returns the column updates for a masked prescription update.
+func prescriptionUpdates(pr *serverpb.Prescription, mask *fieldmaskpb.FieldMask) (map[string]interface{}, error) {
+	return maskedUpdates(pr, mask, prescriptionMaskColumns)
+}
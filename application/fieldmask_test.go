@@ -0,0 +1,91 @@
+package application
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hcliff-zhang/playground/server/serverpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestPatientUpdates(t *testing.T) {
+	p := &serverpb.Patient{Id: 7, FirstName: "Ann", LastName: "Lee", Email: "ann@example.com", CreatedAt: timestamppb.Now()}
+	every := map[string]interface{}{"first_name": "Ann", "last_name": "Lee", "gender": "", "email": "ann@example.com", "phone": "", "address": ""}
+
+	tests := []struct {
+		name  string
+		paths []string
+		want  map[string]interface{}
+		code  codes.Code
+	}{
+		{name: "no mask replaces every field", want: every},
+		{name: "wildcard replaces every field", paths: []string{"*"}, want: every},
+		{name: "named fields only", paths: []string{"email", "phone"}, want: map[string]interface{}{"email": "ann@example.com", "phone": ""}},
+		{name: "output-only fields are ignored", paths: []string{"id", "created_at", "updated_at", "deleted_at", "last_name"}, want: map[string]interface{}{"last_name": "Lee"}},
+		{name: "unknown field", paths: []string{"height"}, code: codes.InvalidArgument},
+		{name: "prescriptions cannot be updated", paths: []string{"prescriptions"}, code: codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mask *fieldmaskpb.FieldMask
+			if tt.paths != nil {
+				mask = &fieldmaskpb.FieldMask{Paths: tt.paths}
+			}
+			got, err := patientUpdates(p, mask)
+			if status.Code(err) != tt.code {
+				t.Fatalf("patientUpdates(%v) error = %v, want %v", tt.paths, err, tt.code)
+			}
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("patientUpdates(%v) = %v, want %v", tt.paths, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrescriptionUpdates(t *testing.T) {
+	prescribed := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	unset := &serverpb.Prescription{Medication: "Aspirin", Quantity: 30}
+	set := &serverpb.Prescription{Medication: "Aspirin", Quantity: 30, Status: "completed", PrescribedAt: timestamppb.New(prescribed), PatientId: 3}
+	base := map[string]interface{}{"medication": "Aspirin", "dosage": "", "frequency": "", "quantity": int32(30), "notes": ""}
+	with := func(extra map[string]interface{}) map[string]interface{} {
+		m := make(map[string]interface{})
+		for k, v := range base {
+			m[k] = v
+		}
+		for k, v := range extra {
+			m[k] = v
+		}
+		return m
+	}
+
+	tests := []struct {
+		name  string
+		pr    *serverpb.Prescription
+		paths []string
+		want  map[string]interface{}
+	}{
+		{name: "full replacement keeps unset defaults", pr: unset, want: base},
+		{name: "full replacement writes set defaults", pr: set, want: with(map[string]interface{}{"status": "completed", "prescribed_at": prescribed, "patient_id": uint64(3)})},
+		{name: "named unset fields are cleared", pr: unset, paths: []string{"status", "patient_id"}, want: map[string]interface{}{"status": "", "patient_id": uint64(0)}},
+		{name: "timestamps become times", pr: set, paths: []string{"prescribed_at"}, want: map[string]interface{}{"prescribed_at": prescribed}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mask *fieldmaskpb.FieldMask
+			if tt.paths != nil {
+				mask = &fieldmaskpb.FieldMask{Paths: tt.paths}
+			}
+			got, err := prescriptionUpdates(tt.pr, mask)
+			if err != nil {
+				t.Fatalf("prescriptionUpdates(%v): %v", tt.paths, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("prescriptionUpdates(%v) = %v, want %v", tt.paths, got, tt.want)
+			}
+		})
+	}
+}
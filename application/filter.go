@@ -0,0 +1,160 @@
+package application
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hcliff-zhang/playground/database"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// maxFilterLen bounds the length of a search filter expression.
+const maxFilterLen = 1024
+
+// patientFilterFields maps filter field names to database search fields.
+var patientFilterFields = map[string]string{
+	"first_name": database.PatientFieldFirstName,
+	"last_name":  database.PatientFieldLastName,
+	"name":       database.PatientFieldName,
+	"email":      database.PatientFieldEmail,
+	"phone":      database.PatientFieldPhone,
+	"medication": database.PatientFieldMedication,
+}
+
+// patientOrderFields lists the fields SearchPatients can sort by.
+var patientOrderFields = map[string]string{
+	"id":         database.PatientFieldID,
+	"first_name": database.PatientFieldFirstName,
+	"last_name":  database.PatientFieldLastName,
+	"email":      database.PatientFieldEmail,
+}
+
+// parsePatientFilter parses a SearchPatients filter expression into database
+// conditions. The grammar is a sequence of terms separated by whitespace or the
+// keyword AND, where each term is `field:value` (substring) or `field=value`
+// (exact) and value is a bare word or a double-quoted string.
+func parsePatientFilter(filter string) ([]database.PatientCondition, error) {
+	var conds []database.PatientCondition
+	rest := strings.TrimSpace(filter)
+	for rest != "" {
+		if word, after, ok := strings.Cut(rest, " "); ok && word == "AND" {
+			rest = strings.TrimSpace(after)
+			continue
+		}
+
+		i := strings.IndexAny(rest, ":= \t")
+		if i <= 0 || rest[i] == ' ' || rest[i] == '\t' {
+			return nil, fmt.Errorf("expected field:value or field=value at %q", rest)
+		}
+		name, op := rest[:i], rest[i]
+		field, ok := patientFilterFields[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown field %q", name)
+		}
+
+		value, remaining, err := parseFilterValue(rest[i+1:])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		if field == database.PatientFieldPhone {
+			value = strings.Map(keepDigits, value)
+		}
+		if value == "" {
+			return nil, fmt.Errorf("%s: value is empty", name)
+		}
+
+		conds = append(conds, database.PatientCondition{Field: field, Value: value, Exact: op == '='})
+		rest = strings.TrimSpace(remaining)
+	}
+	return conds, nil
+}
+
+// parseFilterValue reads one value from the start of s and returns it together
+// with the unparsed remainder.
+func parseFilterValue(s string) (value, rest string, err error) {
+	if !strings.HasPrefix(s, `"`) {
+		if i := strings.IndexAny(s, " \t"); i >= 0 {
+			return s[:i], s[i:], nil
+		}
+		return s, "", nil
+	}
+
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\' && i+1 < len(s):
+			i++
+			b.WriteByte(s[i])
+		case c == '"':
+			return b.String(), s[i+1:], nil
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", "", fmt.Errorf("unterminated quoted value")
+}
+
+// prescriptionOrderFields lists the fields prescription listings can sort by.
+var prescriptionOrderFields = map[string]string{
+	"id":            database.PrescriptionFieldID,
+	"medication":    database.PrescriptionFieldMedication,
+	"prescribed_at": database.PrescriptionFieldPrescribedAt,
+}
+
+// parsePatientOrder parses a SearchPatients order_by value such as
+// "last_name" or "email desc". The default is by descending ID.
+func parsePatientOrder(orderBy string) (field string, desc bool, err error) {
+	return parseOrder(orderBy, patientOrderFields, database.PatientFieldID)
+}
+
+// parsePrescriptionOrder parses a prescription listing order_by value. The
+// default is newest prescription first.
+func parsePrescriptionOrder(orderBy string) (field string, desc bool, err error) {
+	return parseOrder(orderBy, prescriptionOrderFields, database.PrescriptionFieldPrescribedAt)
+}
+
+// parseOrder parses "field" or "field asc|desc" against the sortable fields.
+// An empty value sorts by defaultField, descending.
+func parseOrder(orderBy string, fields map[string]string, defaultField string) (field string, desc bool, err error) {
+	parts := strings.Fields(orderBy)
+	if len(parts) == 0 {
+		return defaultField, true, nil
+	}
+	if len(parts) > 2 {
+		return "", false, fmt.Errorf("expected a single field optionally followed by asc or desc")
+	}
+
+	field, ok := fields[parts[0]]
+	if !ok {
+		return "", false, fmt.Errorf("cannot sort by %q", parts[0])
+	}
+	if len(parts) == 2 {
+		switch strings.ToLower(parts[1]) {
+		case "asc":
+		case "desc":
+			desc = true
+		default:
+			return "", false, fmt.Errorf("direction must be asc or desc")
+		}
+	}
+	return field, desc, nil
+}
+
+// prescriptionSearch builds the database search shared by the prescription
+// listing requests from their validated filter fields.
+func prescriptionSearch(medication, status string, after, before *timestamppb.Timestamp, orderBy string) database.PrescriptionSearch {
+	field, desc, _ := parsePrescriptionOrder(orderBy)
+	search := database.PrescriptionSearch{
+		Medication: medication,
+		Status:     status,
+		OrderBy:    field,
+		Desc:       desc,
+	}
+	if after != nil {
+		search.PrescribedAfter = after.AsTime()
+	}
+	if before != nil {
+		search.PrescribedBefore = before.AsTime()
+	}
+	return search
+}
@@ -0,0 +1,99 @@
+package application
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hcliff-zhang/playground/database"
+)
+
+func TestParsePatientFilter(t *testing.T) {
+	cond := func(field, value string, exact bool) database.PatientCondition {
+		return database.PatientCondition{Field: field, Value: value, Exact: exact}
+	}
+
+	tests := []struct {
+		filter string
+		want   []database.PatientCondition
+		err    string
+	}{
+		{filter: "", want: nil},
+		{filter: "   ", want: nil},
+		{filter: "name:ann", want: []database.PatientCondition{cond(database.PatientFieldName, "ann", false)}},
+		{filter: "email=ann@example.com", want: []database.PatientCondition{cond(database.PatientFieldEmail, "ann@example.com", true)}},
+		{filter: "last_name:lee AND first_name=Ann  medication:aspirin", want: []database.PatientCondition{
+			cond(database.PatientFieldLastName, "lee", false),
+			cond(database.PatientFieldFirstName, "Ann", true),
+			cond(database.PatientFieldMedication, "aspirin", false),
+		}},
+		{filter: `name:"van der Berg"`, want: []database.PatientCondition{cond(database.PatientFieldName, "van der Berg", false)}},
+		{filter: `name:"say \"hi\" \\ bye"`, want: []database.PatientCondition{cond(database.PatientFieldName, `say "hi" \ bye`, false)}},
+		{filter: "phone:(555) 010-0199", err: `expected field:value or field=value at "010-0199"`},
+		{filter: `phone:"(555) 010-0199"`, want: []database.PatientCondition{cond(database.PatientFieldPhone, "5550100199", false)}},
+
+		{filter: "ann", err: `expected field:value or field=value at "ann"`},
+		{filter: ":ann", err: "expected field:value"},
+		{filter: "=ann", err: "expected field:value"},
+		{filter: "height:2", err: `unknown field "height"`},
+		{filter: "Name:ann", err: `unknown field "Name"`},
+		{filter: "name:", err: "name: value is empty"},
+		{filter: `name:""`, err: "name: value is empty"},
+		{filter: "phone:abc", err: "phone: value is empty"},
+		{filter: `name:"ann`, err: "name: unterminated quoted value"},
+		{filter: `name:"ann\"`, err: "name: unterminated quoted value"},
+		{filter: "name:ann AND", err: `expected field:value or field=value at "AND"`},
+		{filter: "name:ann OR email:x", err: `expected field:value or field=value at "OR email:x"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			got, err := parsePatientFilter(tt.filter)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("parsePatientFilter(%q) = %v, %v; want error %q", tt.filter, got, err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parsePatientFilter(%q): %v", tt.filter, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parsePatientFilter(%q) = %+v, want %+v", tt.filter, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		parse   func(string) (string, bool, error)
+		orderBy string
+		field   string
+		desc    bool
+		err     bool
+	}{
+		{name: "patient default", parse: parsePatientOrder, orderBy: "", field: database.PatientFieldID, desc: true},
+		{name: "patient field", parse: parsePatientOrder, orderBy: "last_name", field: database.PatientFieldLastName},
+		{name: "patient asc", parse: parsePatientOrder, orderBy: "email asc", field: database.PatientFieldEmail},
+		{name: "patient desc", parse: parsePatientOrder, orderBy: " first_name   DESC ", field: database.PatientFieldFirstName, desc: true},
+		{name: "patient unsortable field", parse: parsePatientOrder, orderBy: "phone", err: true},
+		{name: "patient bad direction", parse: parsePatientOrder, orderBy: "email up", err: true},
+		{name: "patient two fields", parse: parsePatientOrder, orderBy: "email, id", err: true},
+		{name: "patient trailing words", parse: parsePatientOrder, orderBy: "email asc id", err: true},
+		{name: "prescription default", parse: parsePrescriptionOrder, orderBy: "", field: database.PrescriptionFieldPrescribedAt, desc: true},
+		{name: "prescription field", parse: parsePrescriptionOrder, orderBy: "medication desc", field: database.PrescriptionFieldMedication, desc: true},
+		{name: "prescription patient field", parse: parsePrescriptionOrder, orderBy: "last_name", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			field, desc, err := tt.parse(tt.orderBy)
+			if (err != nil) != tt.err {
+				t.Fatalf("parse(%q) error = %v, want error %v", tt.orderBy, err, tt.err)
+			}
+			if err == nil && (field != tt.field || desc != tt.desc) {
+				t.Errorf("parse(%q) = %s desc=%v, want %s desc=%v", tt.orderBy, field, desc, tt.field, tt.desc)
+			}
+		})
+	}
+}
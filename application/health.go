@@ -0,0 +1,130 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/hcliff-zhang/playground/database"
+	"github.com/hcliff-zhang/playground/server/serverpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// healthServices are the grpc.health.v1 service names reported: the server as
+// a whole and the Api service.
+var healthServices = []string{"", serverpb.Api_ServiceDesc.ServiceName}
+
+// Health reports whether the server is alive and ready for traffic, over the
+// standard grpc.health.v1.Health service and the /healthz and /readyz HTTP
+// endpoints. The server is ready while the database answers a ping within the
+// timeout, its connection pool has a free connection and shutdown has not
+// started. A pool of one connection is never counted as exhausted, and is not
+// pinged while it is busy.
+type Health struct {
+	db       *database.DB
+	timeout  time.Duration
+	grpc     *health.Server
+	stopping atomic.Bool
+}
+
+// NewHealth returns a Health checking db, giving up on a ping after timeout.
+func NewHealth(db *database.DB, timeout time.Duration) *Health {
+	h := &Health{db: db, timeout: timeout, grpc: health.NewServer()}
+	for _, svc := range healthServices {
+		h.grpc.SetServingStatus(svc, healthpb.HealthCheckResponse_NOT_SERVING)
+	}
+	return h
+}
+
+// RegisterGRPC registers the grpc.health.v1.Health service with s.
+func (h *Health) RegisterGRPC(s *grpc.Server) {
+	healthpb.RegisterHealthServer(s, h.grpc)
+}
+
+// Ready returns nil if the server is ready for traffic, or why it is not.
+func (h *Health) Ready(ctx context.Context) error {
+	if h.stopping.Load() {
+		return errors.New("shutting down")
+	}
+
+	stats, err := database.PoolStats(h.db)
+	if err != nil {
+		return err
+	}
+	switch {
+	case stats.MaxOpenConnections == 1:
+		// A single connection, as SQLite uses, is in use whenever a request
+		// is, which is not exhaustion, and a ping would only queue behind
+		// that request. A connection serving requests is a working one.
+		if stats.InUse > 0 {
+			return nil
+		}
+	case stats.MaxOpenConnections > 0 && stats.InUse >= stats.MaxOpenConnections:
+		return fmt.Errorf("database pool exhausted: %d of %d connections in use", stats.InUse, stats.MaxOpenConnections)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+	if err := database.PingContext(ctx, h.db); err != nil {
+		return fmt.Errorf("database ping failed: %w", err)
+	}
+	return nil
+}
+
+// Watch checks readiness every interval and updates the gRPC serving status
+// to match, until ctx is done.
+func (h *Health) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		status := healthpb.HealthCheckResponse_SERVING
+		if h.Ready(ctx) != nil {
+			status = healthpb.HealthCheckResponse_NOT_SERVING
+		}
+		for _, svc := range healthServices {
+			h.grpc.SetServingStatus(svc, status)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Shutdown permanently reports NOT_SERVING, so that load balancers stop
+// sending traffic while in-flight requests drain.
+func (h *Health) Shutdown() error {
+	h.stopping.Store(true)
+	h.grpc.Shutdown()
+	return nil
+}
+
+// Handler serves /healthz and /readyz, passing other requests to next.
+// /healthz succeeds whenever the process can serve HTTP, so that a database
+// outage makes the pod unready rather than restarting it.
+func (h *Health) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/healthz":
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			fmt.Fprintln(w, "ok")
+		case "/readyz":
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			if err := h.Ready(r.Context()); err != nil {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				fmt.Fprintf(w, "not ready: %v\n", err)
+				return
+			}
+			fmt.Fprintln(w, "ok")
+		default:
+			next.ServeHTTP(w, r)
+		}
+	})
+}
@@ -1,8 +1,14 @@
 package application
 
 import (
+	"encoding/json"
+	"strings"
+	"time"
+
 	"github.com/hcliff-zhang/playground/database"
 	"github.com/hcliff-zhang/playground/server/serverpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 // PatientToProto converts a database.Patient to a serverpb.Patient message.
@@ -19,6 +25,11 @@ func PatientToProto(p *database.Patient) *serverpb.Patient {
 		Email:     p.Email,
 		Phone:     p.Phone,
 		Address:   p.Address,
+		CreatedAt: timestampProto(p.CreatedAt),
+		UpdatedAt: timestampProto(p.UpdatedAt),
+	}
+	if p.DeletedAt.Valid {
+		protoPatient.DeletedAt = timestamppb.New(p.DeletedAt.Time)
 	}
 	
 	// Convert prescriptions if present
@@ -65,14 +76,26 @@ func PrescriptionToProto(pr *database.Prescription) *serverpb.Prescription {
 		return nil
 	}
 	
-	return &serverpb.Prescription{
-		Id:         uint64(pr.ID),
-		Medication: pr.Medication,
-		Dosage:     pr.Dosage,
-		Frequency:  pr.Frequency,
-		Quantity:   int32(pr.Quantity),
-		Notes:      pr.Notes,
+	protoPrescription := &serverpb.Prescription{
+		Id:           uint64(pr.ID),
+		Medication:   pr.Medication,
+		Dosage:       pr.Dosage,
+		Frequency:    pr.Frequency,
+		Quantity:     int32(pr.Quantity),
+		Notes:        pr.Notes,
+		Status:       pr.Status,
+		PrescribedAt: timestampProto(pr.PrescribedAt),
+		CreatedAt:    timestampProto(pr.CreatedAt),
+		UpdatedAt:    timestampProto(pr.UpdatedAt),
+	}
+	if pr.DeletedAt.Valid {
+		protoPrescription.DeletedAt = timestamppb.New(pr.DeletedAt.Time)
 	}
+	if pr.PatientID != nil {
+		protoPrescription.PatientId = uint64(*pr.PatientID)
+	}
+
+	return protoPrescription
 }
 
 // PrescriptionFromProto converts a serverpb.Prescription message to a database.Prescription.
@@ -81,14 +104,24 @@ func PrescriptionFromProto(pr *serverpb.Prescription) *database.Prescription {
 		return nil
 	}
 	
-	return &database.Prescription{
+	dbPrescription := &database.Prescription{
 		ID:         uint(pr.Id),
 		Medication: pr.Medication,
 		Dosage:     pr.Dosage,
 		Frequency:  pr.Frequency,
 		Quantity:   int(pr.Quantity),
 		Notes:      pr.Notes,
+		Status:     pr.Status,
 	}
+	if pr.PrescribedAt != nil {
+		dbPrescription.PrescribedAt = pr.PrescribedAt.AsTime()
+	}
+	if pr.PatientId != 0 {
+		patientID := uint(pr.PatientId)
+		dbPrescription.PatientID = &patientID
+	}
+
+	return dbPrescription
 }
 
 // PatientsToProto converts a slice of database.Patient to serverpb.Patient messages.
@@ -108,3 +141,54 @@ func PrescriptionsToProto(prescriptions []database.Prescription) []*serverpb.Pre
 	}
 	return result
 }
+
+// timestampProto converts t to a protobuf Timestamp, leaving zero times unset.
+func timestampProto(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+// CareTeamMemberToProto converts a database.CareTeamMember to a serverpb.CareTeamMember message.
+func CareTeamMemberToProto(m *database.CareTeamMember) *serverpb.CareTeamMember {
+	if m == nil {
+		return nil
+	}
+
+	return &serverpb.CareTeamMember{
+		PatientId: uint64(m.PatientID),
+		Member:    m.Member,
+		CreatedAt: timestampProto(m.CreatedAt),
+	}
+}
+
+// AuditEventToProto converts a database.AuditEvent to a serverpb.AuditEvent message.
+func AuditEventToProto(ev *database.AuditEvent) *serverpb.AuditEvent {
+	if ev == nil {
+		return nil
+	}
+
+	protoEvent := &serverpb.AuditEvent{
+		Id:      uint64(ev.ID),
+		Time:    timestampProto(ev.CreatedAt),
+		Actor:   ev.Actor,
+		Method:  ev.Method,
+		Outcome: ev.Outcome,
+		Message: ev.Message,
+	}
+	if ev.Resources != "" {
+		protoEvent.Resources = strings.Fields(ev.Resources)
+	}
+	for _, p := range ev.Patients {
+		protoEvent.PatientIds = append(protoEvent.PatientIds, uint64(p.PatientID))
+	}
+	if ev.Changes != "" {
+		var changes map[string]interface{}
+		if err := json.Unmarshal([]byte(ev.Changes), &changes); err == nil {
+			protoEvent.Changes, _ = structpb.NewStruct(changes)
+		}
+	}
+
+	return protoEvent
+}
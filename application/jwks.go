@@ -0,0 +1,306 @@
+package application
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// KeySet resolves the public key a JWT was signed with from the token's "kid"
+// header, which may be empty.
+type KeySet interface {
+	Key(ctx context.Context, kid string) (crypto.PublicKey, error)
+}
+
+// errUnknownKey is returned by a KeySet that has no key with the requested ID.
+var errUnknownKey = errors.New("signing key is not in the key set")
+
+// StaticKeySet is a fixed set of public keys indexed by key ID.
+type StaticKeySet map[string]crypto.PublicKey
+
+// Key returns the key with the given ID. A token without a key ID is accepted
+// when the set holds exactly one key.
+func (ks StaticKeySet) Key(_ context.Context, kid string) (crypto.PublicKey, error) {
+	if kid == "" && len(ks) == 1 {
+		for _, k := range ks {
+			return k, nil
+		}
+	}
+	if k, ok := ks[kid]; ok {
+		return k, nil
+	}
+	return nil, errUnknownKey
+}
+
+// LoadJWKSFile reads a JSON Web Key Set from a file.
+func LoadJWKSFile(path string) (StaticKeySet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseJWKS(data)
+}
+
+// ParseJWKS parses a JSON Web Key Set (RFC 7517). Only RSA and EC signing keys
+// are kept; keys marked for encryption or of other types are skipped.
+func ParseJWKS(data []byte) (StaticKeySet, error) {
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("invalid JWKS: %w", err)
+	}
+
+	keys := make(StaticKeySet)
+	for i, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWKS key %d (kid %q): %w", i, jwk.Kid, err)
+		}
+		if key != nil {
+			keys[jwk.Kid] = key
+		}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("JWKS has no signing keys")
+	}
+	return keys, nil
+}
+
+// jsonWebKey holds the JWK members needed for RSA and EC public keys.
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// publicKey decodes the key, returning nil for unsupported key types.
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("n: %w", err)
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("e: %w", err)
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("e is too large")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("x: %w", err)
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("y: %w", err)
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("point is not on the curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, nil
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	if s == "" {
+		return nil, errors.New("is missing")
+	}
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// RemoteKeySet fetches a JSON Web Key Set from a URL, such as an OIDC
+// provider's jwks_uri, and caches it. The set is refetched once it is older
+// than maxAge, or early when a token names an unknown key so that key
+// rotation is picked up, but never more than once per minRefresh.
+//
+// Fetches run in the background, one at a time, so that callers are not held
+// up by each other or by the network beyond what they need: a stale set keeps
+// being used while its replacement is fetched, and only callers without a
+// usable key wait, each for as long as its own context allows.
+type RemoteKeySet struct {
+	url        string
+	client     *http.Client
+	maxAge     time.Duration
+	minRefresh time.Duration
+
+	mu        sync.Mutex
+	keys      StaticKeySet
+	fetched   time.Time
+	attempted time.Time
+	err       error
+	inflight  *keyFetch
+}
+
+// keyFetch is a fetch of the key set in progress. done is closed once it has
+// finished, after ok records whether it succeeded.
+type keyFetch struct {
+	done chan struct{}
+	ok   bool
+}
+
+// NewRemoteKeySet returns a key set fetched from url and cached for maxAge.
+func NewRemoteKeySet(url string, maxAge time.Duration) *RemoteKeySet {
+	return &RemoteKeySet{
+		url:        url,
+		client:     &http.Client{Timeout: 10 * time.Second},
+		maxAge:     maxAge,
+		minRefresh: 30 * time.Second,
+	}
+}
+
+// Key returns the key with the given ID, fetching the key set as needed. A
+// stale set is still used if it cannot be refreshed.
+func (r *RemoteKeySet) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	r.mu.Lock()
+	keys := r.keys
+	var f *keyFetch
+	if keys == nil || time.Since(r.fetched) > r.maxAge {
+		f = r.refresh()
+	}
+	r.mu.Unlock()
+
+	if keys == nil {
+		r.wait(ctx, f)
+		r.mu.Lock()
+		keys = r.keys
+		err := r.err
+		r.mu.Unlock()
+		if keys == nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, err
+		}
+	}
+	key, err := keys.Key(ctx, kid)
+	if errors.Is(err, errUnknownKey) {
+		r.mu.Lock()
+		f := r.refresh()
+		r.mu.Unlock()
+		if r.wait(ctx, f) {
+			key, err = r.current().Key(ctx, kid)
+		}
+	}
+	return key, err
+}
+
+// refresh starts fetching the key set unless a fetch is already under way or
+// was attempted within minRefresh, and returns the fetch under way, if any.
+// r.mu must be held.
+func (r *RemoteKeySet) refresh() *keyFetch {
+	if r.inflight != nil {
+		return r.inflight
+	}
+	if time.Since(r.attempted) < r.minRefresh {
+		return nil
+	}
+	r.attempted = time.Now()
+	f := &keyFetch{done: make(chan struct{})}
+	r.inflight = f
+	go r.run(f)
+	return f
+}
+
+// run performs f. It is not bound to any caller's context, so a caller giving
+// up does not fail the fetch for the others; the client's timeout bounds it.
+func (r *RemoteKeySet) run(f *keyFetch) {
+	keys, err := r.fetch(context.Background())
+	if err != nil {
+		slog.Warn("JWKS refresh failed", slog.String("url", r.url), slog.String("error", err.Error()))
+	}
+
+	r.mu.Lock()
+	if err != nil {
+		r.err = err
+	} else {
+		r.keys, r.fetched, r.err = keys, time.Now(), nil
+	}
+	r.inflight = nil
+	f.ok = err == nil
+	r.mu.Unlock()
+	close(f.done)
+}
+
+// wait waits for f, if any, to finish or ctx to be done, and reports whether
+// f fetched a new key set.
+func (r *RemoteKeySet) wait(ctx context.Context, f *keyFetch) bool {
+	if f == nil {
+		return false
+	}
+	select {
+	case <-f.done:
+		return f.ok
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// current returns the cached key set.
+func (r *RemoteKeySet) current() StaticKeySet {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.keys
+}
+
+func (r *RemoteKeySet) fetch(ctx context.Context) (StaticKeySet, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch JWKS from %s: %s", r.url, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	return ParseJWKS(data)
+}
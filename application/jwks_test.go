@@ -0,0 +1,180 @@
+package application
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func b64(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+
+// ecJWK returns the JWK of an EC P-256 public key.
+func ecJWK(kid string, key *ecdsa.PublicKey) map[string]string {
+	return map[string]string{"kty": "EC", "kid": kid, "crv": "P-256", "x": b64(key.X.Bytes()), "y": b64(key.Y.Bytes())}
+}
+
+func jwks(keys ...map[string]string) []byte {
+	b, _ := json.Marshal(map[string]interface{}{"keys": keys})
+	return b
+}
+
+func TestParseJWKS(t *testing.T) {
+	ec := newSigningKey(t).PublicKey
+	rsaJWK := map[string]string{"kty": "RSA", "kid": "r1", "n": b64(big.NewInt(0).Lsh(big.NewInt(1), 2047).Bytes()), "e": "AQAB"}
+
+	tests := []struct {
+		name string
+		data []byte
+		kids []string
+		err  string
+	}{
+		{name: "EC and RSA", data: jwks(ecJWK("e1", &ec), rsaJWK), kids: []string{"e1", "r1"}},
+		{name: "encryption keys are skipped", data: jwks(ecJWK("e1", &ec), map[string]string{"kty": "RSA", "kid": "enc", "use": "enc", "n": "AQAB", "e": "AQAB"}), kids: []string{"e1"}},
+		{name: "other key types are skipped", data: jwks(ecJWK("e1", &ec), map[string]string{"kty": "oct", "kid": "s", "k": "c2VjcmV0"}), kids: []string{"e1"}},
+		{name: "no signing keys", data: jwks(map[string]string{"kty": "oct", "kid": "s"}), err: "no signing keys"},
+		{name: "not JSON", data: []byte("<html>"), err: "invalid JWKS"},
+		{name: "missing modulus", data: jwks(map[string]string{"kty": "RSA", "kid": "r", "e": "AQAB"}), err: "n: is missing"},
+		{name: "bad base64", data: jwks(map[string]string{"kty": "RSA", "kid": "r", "n": "***", "e": "AQAB"}), err: "n:"},
+		{name: "huge exponent", data: jwks(map[string]string{"kty": "RSA", "kid": "r", "n": "AQAB", "e": b64(big.NewInt(1 << 40).Bytes())}), err: "e is too large"},
+		{name: "unknown curve", data: jwks(map[string]string{"kty": "EC", "kid": "e", "crv": "P-192", "x": "AQ", "y": "AQ"}), err: "unsupported curve"},
+		{name: "point off the curve", data: jwks(map[string]string{"kty": "EC", "kid": "e", "crv": "P-256", "x": "AQ", "y": "AQ"}), err: "not on the curve"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := ParseJWKS(tt.data)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("ParseJWKS = %v, %v; want error %q", keys, err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseJWKS: %v", err)
+			}
+			if len(keys) != len(tt.kids) {
+				t.Errorf("ParseJWKS returned %d keys, want %v", len(keys), tt.kids)
+			}
+			for _, kid := range tt.kids {
+				if _, ok := keys[kid]; !ok {
+					t.Errorf("ParseJWKS is missing key %q", kid)
+				}
+			}
+			if k, ok := keys["r1"].(*rsa.PublicKey); ok && k.E != 65537 {
+				t.Errorf("RSA exponent = %d, want 65537", k.E)
+			}
+		})
+	}
+}
+
+// jwksServer serves a key set that tests can replace, counting fetches.
+type jwksServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	body    []byte
+	fetches int
+}
+
+func newJWKSServer(t *testing.T, body []byte) *jwksServer {
+	s := &jwksServer{body: body}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.fetches++
+		if s.body == nil {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write(s.body)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jwksServer) serve(body []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.body = body
+}
+
+func (s *jwksServer) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.fetches
+}
+
+func TestRemoteKeySetRotation(t *testing.T) {
+	old, next := newSigningKey(t), newSigningKey(t)
+	srv := newJWKSServer(t, jwks(ecJWK("old", &old.PublicKey)))
+	keys := NewRemoteKeySet(srv.URL, time.Hour)
+	keys.minRefresh = 0
+	ctx := context.Background()
+
+	if _, err := keys.Key(ctx, "old"); err != nil {
+		t.Fatalf("Key(old): %v", err)
+	}
+	if _, err := keys.Key(ctx, "old"); err != nil || srv.count() != 1 {
+		t.Errorf("Key(old) again = %v after %d fetches, want the cached set", err, srv.count())
+	}
+
+	// A token signed by a rotated-in key makes the set refetch
+	srv.serve(jwks(ecJWK("next", &next.PublicKey)))
+	if k, err := keys.Key(ctx, "next"); err != nil || !k.(*ecdsa.PublicKey).Equal(&next.PublicKey) {
+		t.Fatalf("Key(next) after rotation = %v, %v", k, err)
+	}
+	if _, err := keys.Key(ctx, "old"); !errors.Is(err, errUnknownKey) {
+		t.Errorf("Key(old) after rotation = %v, want errUnknownKey", err)
+	}
+
+	// Tokens through the authenticator follow the rotation too
+	auth := NewJWTAuthenticator(keys, "", "")
+	token := signToken(t, next, "next", map[string]interface{}{"sub": "dr-a", "exp": time.Now().Add(time.Hour).Unix()})
+	if _, err := auth.Authenticate(ctx, token); err != nil {
+		t.Errorf("Authenticate with the rotated key: %v", err)
+	}
+}
+
+func TestRemoteKeySetRefreshLimit(t *testing.T) {
+	key := newSigningKey(t)
+	srv := newJWKSServer(t, jwks(ecJWK("k1", &key.PublicKey)))
+	keys := NewRemoteKeySet(srv.URL, time.Hour)
+	ctx := context.Background()
+
+	if _, err := keys.Key(ctx, "k1"); err != nil {
+		t.Fatalf("Key(k1): %v", err)
+	}
+	// Unknown kids within minRefresh of the last fetch do not refetch
+	for i := 0; i < 3; i++ {
+		if _, err := keys.Key(ctx, "forged"); !errors.Is(err, errUnknownKey) {
+			t.Errorf("Key(forged) = %v, want errUnknownKey", err)
+		}
+	}
+	if n := srv.count(); n != 1 {
+		t.Errorf("JWKS fetched %d times, want 1", n)
+	}
+}
+
+func TestRemoteKeySetUnavailable(t *testing.T) {
+	srv := newJWKSServer(t, nil)
+	keys := NewRemoteKeySet(srv.URL, time.Hour)
+	if _, err := keys.Key(context.Background(), "k1"); err == nil || !strings.Contains(err.Error(), "503") {
+		t.Errorf("Key with the JWKS down = %v, want the fetch error", err)
+	}
+
+	// A caller giving up does not wait for the fetch
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	keys = NewRemoteKeySet(srv.URL, time.Hour)
+	if _, err := keys.Key(ctx, "k1"); err == nil {
+		t.Error("Key with a canceled context succeeded")
+	}
+}
@@ -0,0 +1,163 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// Lifecycle runs a set of servers until the context passed to Run is done or
+// one of them fails, then drains them and releases the resources they used.
+type Lifecycle struct {
+	// ShutdownTimeout bounds how long in-flight requests may take to finish
+	// once shutdown starts. Servers still busy after it are closed forcibly.
+	ShutdownTimeout time.Duration
+
+	servers []lifecycleServer
+	onStop  []lifecycleHook
+	closers []lifecycleHook
+}
+
+type lifecycleServer struct {
+	name     string
+	serve    func() error
+	shutdown func(ctx context.Context) error
+}
+
+type lifecycleHook struct {
+	name string
+	fn   func() error
+}
+
+// AddGRPCServer serves s on lis. On shutdown it stops accepting connections
+// and waits for in-flight calls with GracefulStop.
+func (l *Lifecycle) AddGRPCServer(name string, s *grpc.Server, lis net.Listener) {
+	l.servers = append(l.servers, lifecycleServer{
+		name:  name,
+		serve: func() error { return s.Serve(lis) },
+		shutdown: func(ctx context.Context) error {
+			done := make(chan struct{})
+			go func() {
+				s.GracefulStop()
+				close(done)
+			}()
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				s.Stop()
+				return ctx.Err()
+			}
+		},
+	})
+}
+
+// AddHTTPServer serves s on lis, over TLS if s has a TLS config. On shutdown
+// it stops accepting connections and waits for in-flight requests with
+// Shutdown.
+func (l *Lifecycle) AddHTTPServer(name string, s *http.Server, lis net.Listener) {
+	l.servers = append(l.servers, lifecycleServer{
+		name: name,
+		serve: func() error {
+			var err error
+			if s.TLSConfig != nil {
+				err = s.ServeTLS(lis, "", "")
+			} else {
+				err = s.Serve(lis)
+			}
+			if errors.Is(err, http.ErrServerClosed) {
+				return nil
+			}
+			return err
+		},
+		shutdown: func(ctx context.Context) error {
+			if err := s.Shutdown(ctx); err != nil {
+				s.Close()
+				return err
+			}
+			return nil
+		},
+	})
+}
+
+// OnStop registers fn to run as soon as shutdown starts, before the servers
+// are drained.
+func (l *Lifecycle) OnStop(name string, fn func() error) {
+	l.onStop = append(l.onStop, lifecycleHook{name: name, fn: fn})
+}
+
+// OnClose registers fn to run once every server has stopped. Close hooks run
+// in reverse order of registration.
+func (l *Lifecycle) OnClose(name string, fn func() error) {
+	l.closers = append(l.closers, lifecycleHook{name: name, fn: fn})
+}
+
+// Run serves every server until ctx is done or a server fails, then shuts
+// them all down. It returns nil after a clean shutdown following ctx, and
+// otherwise the errors that caused or occurred during the shutdown.
+func (l *Lifecycle) Run(ctx context.Context) error {
+	failed := make(chan error, len(l.servers))
+	var wg sync.WaitGroup
+	for _, srv := range l.servers {
+		wg.Add(1)
+		go func(srv lifecycleServer) {
+			defer wg.Done()
+			if err := srv.serve(); err != nil {
+				failed <- fmt.Errorf("%s: %w", srv.name, err)
+			}
+		}(srv)
+	}
+
+	var errs []error
+	select {
+	case <-ctx.Done():
+		log.Printf("Shutting down")
+	case err := <-failed:
+		log.Printf("Shutting down after failure: %v", err)
+		errs = append(errs, err)
+	}
+
+	for _, hook := range l.onStop {
+		if err := hook.fn(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", hook.name, err))
+		}
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), l.ShutdownTimeout)
+	defer cancel()
+	var mu sync.Mutex
+	var stopping sync.WaitGroup
+	for _, srv := range l.servers {
+		stopping.Add(1)
+		go func(srv lifecycleServer) {
+			defer stopping.Done()
+			if err := srv.shutdown(shutdownCtx); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("%s: shutdown: %w", srv.name, err))
+				mu.Unlock()
+			}
+		}(srv)
+	}
+	stopping.Wait()
+	wg.Wait()
+
+	// Servers that failed while shutting down
+	close(failed)
+	for err := range failed {
+		errs = append(errs, err)
+	}
+
+	for i := len(l.closers) - 1; i >= 0; i-- {
+		if err := l.closers[i].fn(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", l.closers[i].name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,242 @@
+package application
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// requestIDHeader carries a request's ID, as an HTTP header and as gRPC
+// metadata.
+const requestIDHeader = "x-request-id"
+
+// redacted replaces PHI in logs.
+const redacted = "REDACTED"
+
+// phiFields are the log attributes and request fields that may hold protected
+// health information and are never logged. filter is a patient search, which
+// names the patients searched for.
+var phiFields = map[string]bool{
+	"first_name": true,
+	"last_name":  true,
+	"name":       true,
+	"email":      true,
+	"phone":      true,
+	"address":    true,
+	"notes":      true,
+	"filter":     true,
+}
+
+// NewLogger returns a logger writing JSON lines to w, at level and above.
+// Attributes named in phiFields are redacted, and records logged with a
+// context carry its request ID and trace ID.
+func NewLogger(w io.Writer, level slog.Leveler) *slog.Logger {
+	h := slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level: level,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if phiFields[a.Key] {
+				return slog.String(a.Key, redacted)
+			}
+			return a
+		},
+	})
+	return slog.New(contextHandler{h})
+}
+
+// contextHandler adds the request and trace IDs of a record's context.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestIDFromContext(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(slog.String("trace_id", sc.TraceID().String()), slog.String("span_id", sc.SpanID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+type requestIDKey struct{}
+
+// RequestIDFromContext returns the ID of the request ctx belongs to, if any.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// requestID returns the caller's request ID if it is usable, or else a new
+// one.
+func requestID(given string) string {
+	if given != "" && len(given) <= 128 {
+		printable := true
+		for _, c := range given {
+			if c < 0x21 || c > 0x7e {
+				printable = false
+				break
+			}
+		}
+		if printable {
+			return given
+		}
+	}
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// LoggingUnaryServerInterceptor logs every gRPC call to slog.Default, with its
+// method, status code, duration and request ID, taken from the call's
+// x-request-id metadata or else generated, and returned in the x-request-id
+// response header. Request messages are logged at debug level with PHI
+// redacted. Error messages are not logged for InvalidArgument, as they may
+// echo the request. Health checks are not logged.
+func LoggingUnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if isPublicMethod(info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		var given string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(requestIDHeader); len(values) > 0 {
+				given = values[0]
+			}
+		}
+		id := requestID(given)
+		ctx = context.WithValue(ctx, requestIDKey{}, id)
+		grpc.SetHeader(ctx, metadata.Pairs(requestIDHeader, id))
+
+		start := time.Now()
+		resp, err := handler(ctx, req)
+
+		st := status.Convert(err)
+		attrs := []slog.Attr{
+			slog.String("method", info.FullMethod),
+			slog.String("code", st.Code().String()),
+			slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+		}
+		if p, ok := PrincipalFromContext(ctx); ok {
+			attrs = append(attrs, slog.String("subject", p.Subject))
+		}
+		if err != nil && st.Code() != codes.InvalidArgument {
+			attrs = append(attrs, slog.String("error", st.Message()))
+		}
+		logger := slog.Default()
+		if msg, ok := req.(proto.Message); ok && logger.Enabled(ctx, slog.LevelDebug) {
+			attrs = append(attrs, slog.Any("request", redactMessage(msg)))
+		}
+		logger.LogAttrs(ctx, grpcLogLevel(st.Code()), "gRPC call", attrs...)
+		return resp, err
+	}
+}
+
+// grpcLogLevel is the level calls finishing with code are logged at: server
+// faults are errors and everything else is routine.
+func grpcLogLevel(code codes.Code) slog.Level {
+	switch code {
+	case codes.Unknown, codes.Internal, codes.DataLoss, codes.Unavailable, codes.DeadlineExceeded:
+		return slog.LevelError
+	}
+	return slog.LevelInfo
+}
+
+// LogHTTP logs every request to h to slog.Default, with its method, path,
+// status, duration and request ID, taken from the X-Request-Id header or else
+// generated, and returned in the X-Request-Id response header. Query strings,
+// which may hold search terms, are not logged. Probes and metric scrapes are
+// not logged.
+func LogHTTP(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/healthz", "/readyz", "/metrics":
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		id := requestID(r.Header.Get(requestIDHeader))
+		ctx := context.WithValue(r.Context(), requestIDKey{}, id)
+		w.Header().Set(requestIDHeader, id)
+
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		h.ServeHTTP(rec, r.WithContext(ctx))
+
+		level := slog.LevelInfo
+		if rec.status >= 500 {
+			level = slog.LevelError
+		}
+		slog.Default().LogAttrs(ctx, level, "HTTP request",
+			slog.String("http_method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", rec.status),
+			slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+		)
+	})
+}
+
+// GatewayRequestID passes the request ID assigned by LogHTTP on to the gRPC
+// server as x-request-id metadata, so that both log the same ID.
+func GatewayRequestID() runtime.ServeMuxOption {
+	return runtime.WithMetadata(func(ctx context.Context, r *http.Request) metadata.MD {
+		if id := RequestIDFromContext(ctx); id != "" {
+			return metadata.Pairs(requestIDHeader, id)
+		}
+		return nil
+	})
+}
+
+// redactMessage returns msg as a JSON object with the fields named in
+// phiFields redacted, at any depth.
+func redactMessage(msg proto.Message) interface{} {
+	b, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(msg)
+	if err != nil {
+		return nil
+	}
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil
+	}
+	return redactValue(v)
+}
+
+func redactValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, field := range v {
+			if phiFields[k] {
+				v[k] = redacted
+			} else {
+				v[k] = redactValue(field)
+			}
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = redactValue(v[i])
+		}
+	}
+	return v
+}
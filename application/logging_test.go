@@ -0,0 +1,129 @@
+package application
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hcliff-zhang/playground/server/serverpb"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// phi are the values the tests' patient data is made of, none of which may
+// reach a log.
+var phi = []string{"Zelda", "Quixote", "zq@example.com", "5550100199", "Secret Lane", "take with cheese"}
+
+func testPatient() *serverpb.Patient {
+	return &serverpb.Patient{
+		Id: 7, FirstName: "Zelda", LastName: "Quixote", Gender: "female", Email: "zq@example.com", Phone: "5550100199", Address: "1 Secret Lane",
+		Prescriptions: []*serverpb.Prescription{{Id: 3, Medication: "aspirin", Notes: "take with cheese"}},
+	}
+}
+
+func TestRedactMessage(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  proto.Message
+		want string
+	}{
+		{name: "nested", msg: &serverpb.CreatePatientRequest{Patient: testPatient()},
+			want: `{"patient":{"address":"REDACTED","email":"REDACTED","first_name":"REDACTED","gender":"female","id":"7","last_name":"REDACTED","phone":"REDACTED",` +
+				`"prescriptions":[{"id":"3","medication":"aspirin","notes":"REDACTED"}]}}`},
+		{name: "repeated", msg: &serverpb.ListPatientsResponse{Patients: []*serverpb.Patient{{Id: 1, Email: "zq@example.com"}, {Id: 2, LastName: "Quixote"}}, Total: 2},
+			want: `{"patients":[{"email":"REDACTED","id":"1"},{"id":"2","last_name":"REDACTED"}],"total":2}`},
+		{name: "search filter", msg: &serverpb.SearchPatientsRequest{Filter: "last_name:Quixote", OrderBy: "email"},
+			want: `{"filter":"REDACTED","order_by":"email"}`},
+		{name: "no PHI", msg: &serverpb.GetPatientRequest{Id: 7}, want: `{"id":"7"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(redactMessage(tt.msg))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var gotV, wantV interface{}
+			json.Unmarshal(got, &gotV)
+			json.Unmarshal([]byte(tt.want), &wantV)
+			if !reflect.DeepEqual(gotV, wantV) {
+				t.Errorf("redactMessage = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewLoggerRedacts(t *testing.T) {
+	var buf bytes.Buffer
+	log := NewLogger(&buf, slog.LevelInfo)
+
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "req-1")
+	ctx = trace.ContextWithSpanContext(ctx, trace.NewSpanContext(trace.SpanContextConfig{TraceID: trace.TraceID{1}, SpanID: trace.SpanID{2}}))
+	log.InfoContext(ctx, "patient created",
+		slog.String("email", "zq@example.com"),
+		slog.Group("patient", slog.String("last_name", "Quixote"), slog.Int("id", 7)),
+		slog.Any("phone", []string{"5550100199"}),
+	)
+	log.With(slog.String("first_name", "Zelda")).Debug("below the level", slog.String("address", "1 Secret Lane"))
+
+	out := buf.String()
+	for _, v := range phi {
+		if strings.Contains(out, v) {
+			t.Errorf("log leaks %q: %s", v, out)
+		}
+	}
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("log is not one JSON line: %v: %s", err, out)
+	}
+	want := map[string]interface{}{
+		"email":      "REDACTED",
+		"phone":      "REDACTED",
+		"patient":    map[string]interface{}{"last_name": "REDACTED", "id": float64(7)},
+		"request_id": "req-1",
+		"trace_id":   trace.TraceID{1}.String(),
+		"span_id":    trace.SpanID{2}.String(),
+	}
+	for k, v := range want {
+		if !reflect.DeepEqual(record[k], v) {
+			t.Errorf("log %s = %v, want %v", k, record[k], v)
+		}
+	}
+}
+
+func TestLoggingInterceptorRedacts(t *testing.T) {
+	var buf bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(NewLogger(&buf, slog.LevelDebug))
+
+	interceptor := LoggingUnaryServerInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: serverpb.Api_CreatePatient_FullMethodName}
+	for _, err := range []error{
+		nil,
+		// InvalidArgument messages may echo the request
+		status.Error(codes.InvalidArgument, "patient.email: zq@example.com is not an email address"),
+	} {
+		interceptor(context.Background(), &serverpb.CreatePatientRequest{Patient: testPatient()}, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, err
+		})
+	}
+
+	out := buf.String()
+	for _, v := range phi {
+		if strings.Contains(out, v) {
+			t.Errorf("log leaks %q: %s", v, out)
+		}
+	}
+	if n := strings.Count(out, `"msg":"gRPC call"`); n != 2 {
+		t.Errorf("logged %d calls, want 2: %s", n, out)
+	}
+	if !strings.Contains(out, `"medication":"aspirin"`) || !strings.Contains(out, `"code":"InvalidArgument"`) {
+		t.Errorf("log lacks the redacted request or the status code: %s", out)
+	}
+}
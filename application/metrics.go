@@ -0,0 +1,203 @@
+package application
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/hcliff-zhang/playground/database"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// Metrics collects Prometheus metrics for gRPC calls, gateway requests, the
+// database connection pool and database queries, and serves them on
+// /metrics.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	grpcHandled  *prometheus.CounterVec
+	grpcDuration *prometheus.HistogramVec
+	httpHandled  *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+	queries      *prometheus.HistogramVec
+	queryErrors  *prometheus.CounterVec
+}
+
+// NewMetrics returns Metrics reporting on db, whose queries are timed from
+// now on.
+func NewMetrics(db *database.DB) (*Metrics, error) {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		grpcHandled: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "grpc_server_handled_total",
+			Help: "gRPC calls completed, by method and status code.",
+		}, []string{"grpc_service", "grpc_method", "grpc_code"}),
+		grpcDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "grpc_server_handling_seconds",
+			Help:    "Time taken to handle gRPC calls, by method.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"grpc_service", "grpc_method"}),
+		httpHandled: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_gateway_requests_total",
+			Help: "HTTP gateway requests completed, by method, route pattern and status code.",
+		}, []string{"method", "pattern", "code"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_gateway_request_duration_seconds",
+			Help:    "Time taken to handle HTTP gateway requests, by method and route pattern.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "pattern"}),
+		queries: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "gorm_query_duration_seconds",
+			Help:    "Time taken by database statements, by operation and table.",
+			Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+		}, []string{"operation", "table"}),
+		queryErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "gorm_query_errors_total",
+			Help: "Database statements that failed, by operation and table.",
+		}, []string{"operation", "table"}),
+	}
+
+	err := database.ObserveQueries(db, func(operation, table string, elapsed time.Duration, err error) {
+		m.queries.WithLabelValues(operation, table).Observe(elapsed.Seconds())
+		if err != nil {
+			m.queryErrors.WithLabelValues(operation, table).Inc()
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		newPoolCollector(db),
+		m.grpcHandled, m.grpcDuration,
+		m.httpHandled, m.httpDuration,
+		m.queries, m.queryErrors,
+	)
+	return m, nil
+}
+
+// UnaryServerInterceptor counts and times every gRPC call by method and
+// status code.
+func (m *Metrics) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+
+		service, method, _ := strings.Cut(strings.TrimPrefix(info.FullMethod, "/"), "/")
+		m.grpcHandled.WithLabelValues(service, method, status.Code(err).String()).Inc()
+		m.grpcDuration.WithLabelValues(service, method).Observe(time.Since(start).Seconds())
+		return resp, err
+	}
+}
+
+// GatewayMiddleware counts and times every HTTP gateway request by method,
+// route pattern, such as /v1/patients/{id=*}, and status code. Requests that
+// match no route are not counted.
+func (m *Metrics) GatewayMiddleware() runtime.ServeMuxOption {
+	return runtime.WithMiddlewares(func(next runtime.HandlerFunc) runtime.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+			start := time.Now()
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next(rec, r, pathParams)
+
+			var pattern string
+			if p, ok := runtime.HTTPPattern(r.Context()); ok {
+				pattern = p.String()
+			}
+			m.httpHandled.WithLabelValues(r.Method, pattern, strconv.Itoa(rec.status)).Inc()
+			m.httpDuration.WithLabelValues(r.Method, pattern).Observe(time.Since(start).Seconds())
+		}
+	})
+}
+
+// Handler serves the metrics on /metrics, passing other requests to next.
+func (m *Metrics) Handler(next http.Handler) http.Handler {
+	metrics := promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/metrics" {
+			metrics.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// statusRecorder remembers the status code written to a response.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// poolCollector reports the connection pool statistics of a database.
+type poolCollector struct {
+	db *database.DB
+
+	maxOpen       *prometheus.Desc
+	open          *prometheus.Desc
+	inUse         *prometheus.Desc
+	idle          *prometheus.Desc
+	waitCount     *prometheus.Desc
+	waitDuration  *prometheus.Desc
+	maxIdleClosed *prometheus.Desc
+	maxIdleTime   *prometheus.Desc
+	maxLifetime   *prometheus.Desc
+}
+
+func newPoolCollector(db *database.DB) *poolCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc("db_pool_"+name, help, nil, nil)
+	}
+	return &poolCollector{
+		db:            db,
+		maxOpen:       desc("max_open_connections", "Maximum number of open connections to the database."),
+		open:          desc("open_connections", "Established connections, both in use and idle."),
+		inUse:         desc("in_use_connections", "Connections currently in use."),
+		idle:          desc("idle_connections", "Idle connections."),
+		waitCount:     desc("wait_count_total", "Connections waited for because the pool was exhausted."),
+		waitDuration:  desc("wait_duration_seconds_total", "Time spent waiting for a connection."),
+		maxIdleClosed: desc("max_idle_closed_total", "Connections closed because of the idle connection limit."),
+		maxIdleTime:   desc("max_idle_time_closed_total", "Connections closed because of the idle time limit."),
+		maxLifetime:   desc("max_lifetime_closed_total", "Connections closed because of the connection lifetime limit."),
+	}
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{c.maxOpen, c.open, c.inUse, c.idle, c.waitCount, c.waitDuration, c.maxIdleClosed, c.maxIdleTime, c.maxLifetime} {
+		ch <- d
+	}
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stats, err := database.PoolStats(c.db)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.open, err)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.maxOpen, prometheus.GaugeValue, float64(stats.MaxOpenConnections))
+	ch <- prometheus.MustNewConstMetric(c.open, prometheus.GaugeValue, float64(stats.OpenConnections))
+	ch <- prometheus.MustNewConstMetric(c.inUse, prometheus.GaugeValue, float64(stats.InUse))
+	ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(stats.Idle))
+	ch <- prometheus.MustNewConstMetric(c.waitCount, prometheus.CounterValue, float64(stats.WaitCount))
+	ch <- prometheus.MustNewConstMetric(c.waitDuration, prometheus.CounterValue, stats.WaitDuration.Seconds())
+	ch <- prometheus.MustNewConstMetric(c.maxIdleClosed, prometheus.CounterValue, float64(stats.MaxIdleClosed))
+	ch <- prometheus.MustNewConstMetric(c.maxIdleTime, prometheus.CounterValue, float64(stats.MaxIdleTimeClosed))
+	ch <- prometheus.MustNewConstMetric(c.maxLifetime, prometheus.CounterValue, float64(stats.MaxLifetimeClosed))
+}
@@ -0,0 +1,91 @@
+package application
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/hcliff-zhang/playground/server/serverpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestPageTokens(t *testing.T) {
+	tokens := newPageTokens([]byte("key"))
+	cur := pageCursor{LastID: 42, LastKey: "Lee", Query: "ListPatientsRequest:abc"}
+	token := tokens.encode(cur)
+
+	got, err := tokens.decode(token, cur.Query)
+	if err != nil || got != cur {
+		t.Fatalf("decode(encode(%+v)) = %+v, %v", cur, got, err)
+	}
+
+	payload, mac, _ := strings.Cut(token, ".")
+	forged := base64.RawURLEncoding.EncodeToString([]byte(`{"i":1,"k":"Lee","q":"ListPatientsRequest:abc"}`))
+	tests := []struct {
+		name  string
+		token string
+		query string
+	}{
+		{name: "another listing", token: token, query: "ListPatientsRequest:xyz"},
+		{name: "another request type", token: token, query: "SearchPatientsRequest:abc"},
+		{name: "edited cursor", token: forged + "." + mac, query: cur.Query},
+		{name: "edited signature", token: payload + "." + mac[:len(mac)-2] + "AA", query: cur.Query},
+		{name: "no signature", token: payload, query: cur.Query},
+		{name: "empty signature", token: payload + ".", query: cur.Query},
+		{name: "not base64", token: "!!!." + mac, query: cur.Query},
+		{name: "not JSON", token: signedToken(tokens, "not json"), query: cur.Query},
+		{name: "signed with another key", token: newPageTokens([]byte("other")).encode(cur), query: cur.Query},
+		{name: "empty", token: "", query: cur.Query},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tokens.decode(tt.token, tt.query)
+			if status.Code(err) != codes.InvalidArgument {
+				t.Errorf("decode(%q) = %v, want InvalidArgument", tt.token, err)
+			}
+		})
+	}
+}
+
+// signedToken returns payload signed by t as a token, whatever it contains.
+func signedToken(t *pageTokens, payload string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + base64.RawURLEncoding.EncodeToString(t.sign([]byte(payload)))
+}
+
+func TestPageTokensRandomKey(t *testing.T) {
+	cur := pageCursor{LastID: 1, Query: "q"}
+	if _, err := newPageTokens(nil).decode(newPageTokens(nil).encode(cur), "q"); err == nil {
+		t.Error("a token from one process decoded in another without a shared key")
+	}
+}
+
+func TestRequestQuery(t *testing.T) {
+	base := &serverpb.ListPatientsRequest{IncludeDeleted: true}
+	tests := []struct {
+		name string
+		req  proto.Message
+		same bool
+	}{
+		{name: "same request", req: &serverpb.ListPatientsRequest{IncludeDeleted: true}, same: true},
+		{name: "page fields differ", req: &serverpb.ListPatientsRequest{IncludeDeleted: true, PageSize: 5, PageToken: "t", Limit: 3, Offset: 9}, same: true},
+		{name: "filter differs", req: &serverpb.ListPatientsRequest{}},
+		{name: "request type differs", req: &serverpb.ListAuditEventsRequest{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if same := requestQuery(tt.req) == requestQuery(base); same != tt.same {
+				t.Errorf("requestQuery(%v) == requestQuery(%v) is %v, want %v", tt.req, base, same, tt.same)
+			}
+		})
+	}
+}
+
+func TestPageSize(t *testing.T) {
+	for requested, want := range map[int32]int{-1: defaultPageSize, 0: defaultPageSize, 1: 1, 120: 120, maxPageSize: maxPageSize, maxPageSize + 1: maxPageSize} {
+		if got := pageSize(requested); got != want {
+			t.Errorf("pageSize(%d) = %d, want %d", requested, got, want)
+		}
+	}
+}
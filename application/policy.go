@@ -0,0 +1,143 @@
+package application
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hcliff-zhang/playground/server/serverpb"
+	"gopkg.in/yaml.v3"
+)
+
+// anyRole in a policy allows every authenticated caller.
+const anyRole = "*"
+
+//go:embed policy.yaml
+var defaultPolicy []byte
+
+// Policy decides which roles may call each Api method, and which roles are
+// restricted to the patients in their care team.
+type Policy struct {
+	rolesClaim    string
+	methods       map[string]map[string]bool
+	careTeamRoles map[string]bool
+}
+
+// policyFile is the YAML form of a Policy; see policy.yaml.
+type policyFile struct {
+	RolesClaim    string              `yaml:"roles_claim"`
+	CareTeamRoles []string            `yaml:"care_team_roles"`
+	Methods       map[string][]string `yaml:"methods"`
+}
+
+// DefaultPolicy returns the policy built into the binary.
+func DefaultPolicy() *Policy {
+	p, err := ParsePolicy(defaultPolicy)
+	if err != nil {
+		panic(fmt.Sprintf("invalid default policy: %v", err))
+	}
+	return p
+}
+
+// LoadPolicyFile reads a policy from a YAML file.
+func LoadPolicyFile(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePolicy(data)
+}
+
+// ParsePolicy parses a YAML policy. Methods are named as in the Api service,
+// e.g. GetPatient, and unknown method names are rejected.
+func ParsePolicy(data []byte) (*Policy, error) {
+	var f policyFile
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&f); err != nil {
+		return nil, fmt.Errorf("invalid policy: %w", err)
+	}
+	if f.RolesClaim == "" {
+		return nil, fmt.Errorf("invalid policy: roles_claim is required")
+	}
+
+	known := make(map[string]bool)
+	for _, m := range serverpb.Api_ServiceDesc.Methods {
+		known[m.MethodName] = true
+	}
+
+	p := &Policy{
+		rolesClaim:    f.RolesClaim,
+		methods:       make(map[string]map[string]bool),
+		careTeamRoles: make(map[string]bool),
+	}
+	for _, role := range f.CareTeamRoles {
+		p.careTeamRoles[role] = true
+	}
+	for method, roles := range f.Methods {
+		if !known[method] {
+			return nil, fmt.Errorf("invalid policy: unknown method %q", method)
+		}
+		allowed := make(map[string]bool)
+		for _, role := range roles {
+			allowed[role] = true
+		}
+		p.methods[fmt.Sprintf("/%s/%s", serverpb.Api_ServiceDesc.ServiceName, method)] = allowed
+	}
+	return p, nil
+}
+
+// Roles returns the roles the policy's roles claim grants principal. Callers
+// authenticated by client certificate hold the certificate's organizational
+// units as roles.
+func (p *Policy) Roles(principal *Principal) []string {
+	if principal.Certificate != nil {
+		return principal.Certificate.Subject.OrganizationalUnit
+	}
+	var v interface{} = map[string]interface{}(principal.Claims)
+	for _, name := range strings.Split(p.rolesClaim, ".") {
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		v = obj[name]
+	}
+
+	switch v := v.(type) {
+	case string:
+		return strings.Fields(v)
+	case []interface{}:
+		roles := make([]string, 0, len(v))
+		for _, r := range v {
+			if s, ok := r.(string); ok {
+				roles = append(roles, s)
+			}
+		}
+		return roles
+	}
+	return nil
+}
+
+// Allow reports whether a caller with roles may call the full gRPC method, and
+// if so whether only for patients in their care team. A caller is restricted
+// to their care team when every role that allows the method is a care team
+// role.
+func (p *Policy) Allow(method string, roles []string) (allowed, careTeamOnly bool) {
+	allow := p.methods[method]
+	if allow[anyRole] {
+		return true, false
+	}
+	careTeamOnly = true
+	for _, role := range roles {
+		if !allow[role] {
+			continue
+		}
+		allowed = true
+		if !p.careTeamRoles[role] {
+			careTeamOnly = false
+		}
+	}
+	return allowed, allowed && careTeamOnly
+}
@@ -0,0 +1,129 @@
+package application
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/hcliff-zhang/playground/server/serverpb"
+)
+
+func TestParsePolicy(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		err  string
+	}{
+		{name: "valid", yaml: "roles_claim: roles\nmethods:\n  GetPatient: [clinician]\n"},
+		{name: "no methods", yaml: "roles_claim: roles\n"},
+		{name: "unknown method", yaml: "roles_claim: roles\nmethods:\n  GetPatients: [clinician]\n", err: `unknown method "GetPatients"`},
+		{name: "full method name", yaml: "roles_claim: roles\nmethods:\n  /serverpb.Api/GetPatient: [clinician]\n", err: "unknown method"},
+		{name: "unknown field", yaml: "roles_claim: roles\ncare_team_role: [clinician]\n", err: "field care_team_role not found"},
+		{name: "no roles claim", yaml: "methods:\n  GetPatient: [clinician]\n", err: "roles_claim is required"},
+		{name: "roles not a list", yaml: "roles_claim: roles\nmethods:\n  GetPatient: clinician\n", err: "invalid policy"},
+		{name: "empty", yaml: "", err: "invalid policy"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParsePolicy([]byte(tt.yaml))
+			if tt.err == "" && err != nil {
+				t.Fatalf("ParsePolicy: %v", err)
+			}
+			if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+				t.Fatalf("ParsePolicy = %v, want error %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestPolicyRoles(t *testing.T) {
+	nested, err := ParsePolicy([]byte("roles_claim: realm_access.roles\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	flat := DefaultPolicy()
+
+	tests := []struct {
+		name      string
+		policy    *Policy
+		principal *Principal
+		want      []string
+	}{
+		{name: "list", policy: flat, principal: &Principal{Claims: jwt.MapClaims{"roles": []interface{}{"clinician", "auditor"}}}, want: []string{"clinician", "auditor"}},
+		{name: "string", policy: flat, principal: &Principal{Claims: jwt.MapClaims{"roles": " clinician  auditor"}}, want: []string{"clinician", "auditor"}},
+		{name: "non-string entries", policy: flat, principal: &Principal{Claims: jwt.MapClaims{"roles": []interface{}{"clinician", 7, nil}}}, want: []string{"clinician"}},
+		{name: "wrong type", policy: flat, principal: &Principal{Claims: jwt.MapClaims{"roles": true}}},
+		{name: "missing", policy: flat, principal: &Principal{Claims: jwt.MapClaims{}}},
+		{name: "nested", policy: nested, principal: &Principal{Claims: jwt.MapClaims{"realm_access": map[string]interface{}{"roles": []interface{}{"pharmacist"}}}}, want: []string{"pharmacist"}},
+		{name: "nested claim not an object", policy: nested, principal: &Principal{Claims: jwt.MapClaims{"realm_access": "pharmacist"}}},
+		{name: "top-level claim under a nested policy", policy: nested, principal: &Principal{Claims: jwt.MapClaims{"roles": []interface{}{"pharmacist"}}}},
+		{name: "certificate", policy: flat, principal: &Principal{
+			Claims:      jwt.MapClaims{"roles": []interface{}{"auditor"}},
+			Certificate: &x509.Certificate{Subject: pkix.Name{CommonName: "pharmacy-1", OrganizationalUnit: []string{"pharmacist"}}},
+		}, want: []string{"pharmacist"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.policy.Roles(tt.principal)
+			if len(got) != 0 || len(tt.want) != 0 {
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("Roles = %q, want %q", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestPolicyAllow(t *testing.T) {
+	p, err := ParsePolicy([]byte(`
+roles_claim: roles
+care_team_roles: [clinician, nurse]
+methods:
+  GetPatient: [clinician, nurse, front-desk]
+  ListAuditEvents: [auditor]
+  ListCareTeam: ["*"]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		method       string
+		roles        []string
+		allowed      bool
+		careTeamOnly bool
+	}{
+		{name: "unrestricted role", method: "/serverpb.Api/GetPatient", roles: []string{"front-desk"}, allowed: true},
+		{name: "care team role", method: "/serverpb.Api/GetPatient", roles: []string{"clinician"}, allowed: true, careTeamOnly: true},
+		{name: "only care team roles", method: "/serverpb.Api/GetPatient", roles: []string{"clinician", "nurse"}, allowed: true, careTeamOnly: true},
+		{name: "care team and unrestricted roles", method: "/serverpb.Api/GetPatient", roles: []string{"clinician", "front-desk"}, allowed: true},
+		{name: "irrelevant role does not lift the restriction", method: "/serverpb.Api/GetPatient", roles: []string{"clinician", "auditor"}, allowed: true, careTeamOnly: true},
+		{name: "role not allowed", method: "/serverpb.Api/ListAuditEvents", roles: []string{"clinician"}},
+		{name: "no roles", method: "/serverpb.Api/GetPatient"},
+		{name: "anyone", method: "/serverpb.Api/ListCareTeam", allowed: true},
+		{name: "anyone with a care team role", method: "/serverpb.Api/ListCareTeam", roles: []string{"clinician"}, allowed: true},
+		{name: "unlisted method", method: "/serverpb.Api/DeletePatient", roles: []string{"front-desk"}},
+		{name: "unknown method", method: "/other.Api/GetPatient", roles: []string{"front-desk"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allowed, careTeamOnly := p.Allow(tt.method, tt.roles)
+			if allowed != tt.allowed || careTeamOnly != tt.careTeamOnly {
+				t.Errorf("Allow(%s, %q) = %v, %v; want %v, %v", tt.method, tt.roles, allowed, careTeamOnly, tt.allowed, tt.careTeamOnly)
+			}
+		})
+	}
+}
+
+func TestDefaultPolicyCoversEveryMethod(t *testing.T) {
+	p := DefaultPolicy()
+	for _, m := range serverpb.Api_ServiceDesc.Methods {
+		if len(p.methods["/serverpb.Api/"+m.MethodName]) == 0 {
+			t.Errorf("default policy denies %s to everyone", m.MethodName)
+		}
+	}
+}
@@ -2,102 +2,796 @@ package application
 
 import (
 	"context"
+	"errors"
 
 	"github.com/hcliff-zhang/playground/database"
 	"github.com/hcliff-zhang/playground/server/serverpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"gorm.io/gorm"
 )
 
-// Service wraps a database handle and provides methods to read and write data.
+// Service wraps a store and provides methods to read and write data.
 type Service struct {
 	serverpb.UnimplementedApiServer
-	DB *database.DB
+	Store database.Store
+
+	pageTokenKey []byte
+	pageTokens   *pageTokens
+	policy       *Policy
+}
+
+// Option configures optional Service behaviour.
+type Option func(*Service)
+
+// WithPageTokenKey sets the secret used to sign page tokens. Every replica
+// serving the same clients must use the same key; without one a random key is
+// generated and tokens are only valid against the process that issued them.
+func WithPageTokenKey(key []byte) Option {
+	return func(s *Service) {
+		s.pageTokenKey = key
+	}
+}
+
+// NewService returns a Service backed by store, which is usually a
+// *database.DB and in tests a *database.MemoryStore.
+func NewService(store database.Store, opts ...Option) *Service {
+	s := &Service{Store: store}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.pageTokens = newPageTokens(s.pageTokenKey)
+	return s
 }
 
-func NewService(db *database.DB) *Service {
-	return &Service{DB: db}
+// store returns the store to read from, seeing soft-deleted rows when
+// includeDeleted is set and listing only the patients acc covers.
+func (s *Service) store(acc access, includeDeleted bool) database.Store {
+	db := s.Store.ForCareTeam(acc.careTeam)
+	if includeDeleted {
+		return db.WithDeleted()
+	}
+	return db
 }
 
 // --- Patient methods ---
 
 // CreatePatient creates a new patient in the database.
-func (s *Service) CreatePatient(ctx context.Context, req *serverpb.CreatePatientRequest) (*serverpb.CreatePatientResponse, error) {
-	// Convert proto to database model
-	dbPatient := PatientFromProto(req.Patient)
-	
-	// Save to database
-	if err := s.DB.CreatePatient(dbPatient); err != nil {
+func (s *Service) CreatePatient(ctx context.Context, req *serverpb.CreatePatientRequest) (resp *serverpb.CreatePatientResponse, err error) {
+	ctx, rec := s.audit(ctx, serverpb.Api_CreatePatient_FullMethodName)
+	defer func() { err = rec.finish(err) }()
+	acc, err := s.authorize(ctx, rec)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateCreatePatientRequest(req); err != nil {
 		return nil, err
 	}
+
+	// Save to database
+	var dbPatient *database.Patient
+	err = s.Store.Transaction(ctx, func(tx database.Store) error {
+		dbPatient = PatientFromProto(req.Patient)
+		if err := tx.CreatePatient(ctx, dbPatient); err != nil {
+			return err
+		}
+		// Restricted callers could not otherwise see the patient they created
+		if acc.careTeam == "" {
+			return nil
+		}
+		_, err := tx.AddCareTeamMember(ctx, dbPatient.ID, acc.careTeam)
+		return forResource(err, "care team member")
+	})
+	if err != nil {
+		return nil, dbError(err, "patient")
+	}
 	
 	// Convert back to proto
-	return &serverpb.CreatePatientResponse{
+	resp = &serverpb.CreatePatientResponse{
 		Patient: PatientToProto(dbPatient),
-	}, nil
+	}
+	rec.patientList([]*serverpb.Patient{resp.Patient})
+	rec.change(nil, resp.Patient)
+	return resp, nil
 }
 
 // GetPatient fetches a patient by ID with preloaded prescriptions.
-func (s *Service) GetPatient(ctx context.Context, req *serverpb.GetPatientRequest) (*serverpb.GetPatientResponse, error) {
-	dbPatient, err := s.DB.GetPatientByID(uint(req.Id))
+func (s *Service) GetPatient(ctx context.Context, req *serverpb.GetPatientRequest) (resp *serverpb.GetPatientResponse, err error) {
+	ctx, rec := s.audit(ctx, serverpb.Api_GetPatient_FullMethodName)
+	defer func() { err = rec.finish(err) }()
+	rec.patient(req.Id)
+	acc, err := s.authorize(ctx, rec)
 	if err != nil {
 		return nil, err
 	}
+	if err := validateGetPatientRequest(req); err != nil {
+		return nil, err
+	}
+	if err := s.checkPatient(ctx, acc, req.Id); err != nil {
+		return nil, err
+	}
+
+	dbPatient, err := s.store(acc, req.IncludeDeleted).GetPatientByID(ctx, uint(req.Id))
+	if err != nil {
+		return nil, dbError(err, "patient")
+	}
 	
-	return &serverpb.GetPatientResponse{
+	resp = &serverpb.GetPatientResponse{
 		Patient: PatientToProto(dbPatient),
-	}, nil
+	}
+	rec.prescriptionList(resp.Patient.Prescriptions)
+	return resp, nil
 }
 
-// ListPatients returns a paginated list of patients.
-func (s *Service) ListPatients(ctx context.Context, req *serverpb.ListPatientsRequest) (*serverpb.ListPatientsResponse, error) {
-	dbPatients, err := s.DB.ListPatients(int(req.Limit), int(req.Offset))
+// ListPatients returns a page of patients, newest first, along with the total
+// number of patients. Pages are addressed by signed keyset tokens.
+func (s *Service) ListPatients(ctx context.Context, req *serverpb.ListPatientsRequest) (resp *serverpb.ListPatientsResponse, err error) {
+	ctx, rec := s.audit(ctx, serverpb.Api_ListPatients_FullMethodName)
+	defer func() { err = rec.finish(err) }()
+	acc, err := s.authorize(ctx, rec)
 	if err != nil {
 		return nil, err
 	}
-	
-	return &serverpb.ListPatientsResponse{
-		Patients: PatientsToProto(dbPatients),
-		Total:    int32(len(dbPatients)),
-	}, nil
+	if err := validateListPatientsRequest(req); err != nil {
+		return nil, err
+	}
+
+	size := req.PageSize
+	if size == 0 {
+		size = req.Limit
+	}
+	limit := pageSize(size)
+
+	query := requestQuery(req)
+	var cur pageCursor
+	offset := int(req.Offset)
+	if req.PageToken != "" {
+		var err error
+		if cur, err = s.pageTokens.decode(req.PageToken, query); err != nil {
+			return nil, err
+		}
+		offset = 0
+	}
+
+	// Fetch one extra row to learn whether another page follows, and count
+	// the same rows
+	var dbPatients []database.Patient
+	var total int64
+	err = s.store(acc, req.IncludeDeleted).ReadTransaction(ctx, func(tx database.Store) error {
+		var err error
+		if dbPatients, err = tx.ListPatientsAfter(ctx, cur.LastID, offset, limit+1); err != nil {
+			return err
+		}
+		if req.EstimateTotal {
+			total, err = tx.EstimatePatientCount(ctx)
+		} else {
+			total, err = tx.CountPatients(ctx)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, dbError(err, "patient")
+	}
+
+	resp = &serverpb.ListPatientsResponse{}
+	if len(dbPatients) > limit {
+		dbPatients = dbPatients[:limit]
+		resp.NextPageToken = s.pageTokens.encode(pageCursor{
+			LastID: dbPatients[limit-1].ID,
+			Query:  query,
+		})
+	}
+	resp.Patients = PatientsToProto(dbPatients)
+	rec.patientList(resp.Patients)
+	resp.Total = clampInt32(total)
+	resp.TotalEstimated = req.EstimateTotal
+
+	return resp, nil
+}
+
+// SearchPatients returns a page of patients matching a filter expression.
+func (s *Service) SearchPatients(ctx context.Context, req *serverpb.SearchPatientsRequest) (resp *serverpb.SearchPatientsResponse, err error) {
+	ctx, rec := s.audit(ctx, serverpb.Api_SearchPatients_FullMethodName)
+	defer func() { err = rec.finish(err) }()
+	acc, err := s.authorize(ctx, rec)
+	if err != nil {
+		return nil, err
+	}
+	search, err := validateSearchPatientsRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	search.Limit = pageSize(req.PageSize) + 1
+
+	query := requestQuery(req)
+	if req.PageToken != "" {
+		cur, err := s.pageTokens.decode(req.PageToken, query)
+		if err != nil {
+			return nil, err
+		}
+		search.After = &database.SearchCursor{ID: cur.LastID, Key: cur.LastKey}
+	}
+
+	var dbPatients []database.Patient
+	var total int64
+	err = s.store(acc, req.IncludeDeleted).ReadTransaction(ctx, func(tx database.Store) error {
+		var err error
+		if dbPatients, err = tx.SearchPatients(ctx, search); err != nil {
+			return err
+		}
+		total, err = tx.CountPatientSearch(ctx, search)
+		return err
+	})
+	if err != nil {
+		return nil, dbError(err, "patient")
+	}
+
+	resp = &serverpb.SearchPatientsResponse{}
+	if limit := search.Limit - 1; len(dbPatients) > limit {
+		dbPatients = dbPatients[:limit]
+		last := &dbPatients[limit-1]
+		resp.NextPageToken = s.pageTokens.encode(pageCursor{
+			LastID:  last.ID,
+			LastKey: database.PatientSortKey(last, search.OrderBy),
+			Query:   query,
+		})
+	}
+	resp.Patients = PatientsToProto(dbPatients)
+	rec.patientList(resp.Patients)
+	resp.Total = clampInt32(total)
+
+	return resp, nil
+}
+
+// UpdatePatient updates an existing patient. Only the fields named in
+// update_mask are changed; an empty mask or "*" replaces every field.
+// Prescriptions are managed through their own RPCs and are ignored here.
+func (s *Service) UpdatePatient(ctx context.Context, req *serverpb.UpdatePatientRequest) (resp *serverpb.UpdatePatientResponse, err error) {
+	ctx, rec := s.audit(ctx, serverpb.Api_UpdatePatient_FullMethodName)
+	defer func() { err = rec.finish(err) }()
+	rec.patient(req.GetPatient().GetId())
+	acc, err := s.authorize(ctx, rec)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateUpdatePatientRequest(req); err != nil {
+		return nil, err
+	}
+	if err := s.checkPatient(ctx, acc, req.Patient.Id); err != nil {
+		return nil, err
+	}
+
+	id := uint(req.Patient.Id)
+
+	fields, err := patientUpdates(req.Patient, req.UpdateMask)
+	if err != nil {
+		return nil, err
+	}
+
+	var before, updated *database.Patient
+	err = s.Store.Transaction(ctx, func(tx database.Store) error {
+		var err error
+		if before, err = tx.GetPatientByID(ctx, id); err != nil {
+			return err
+		}
+		if len(fields) > 0 {
+			if err := tx.UpdatePatientFields(ctx, id, fields); err != nil {
+				return err
+			}
+		}
+		updated, err = tx.GetPatientByID(ctx, id)
+		return err
+	})
+	if err != nil {
+		return nil, dbError(err, "patient")
+	}
+
+	resp = &serverpb.UpdatePatientResponse{
+		Patient: PatientToProto(updated),
+	}
+	rec.change(PatientToProto(before), resp.Patient)
+	return resp, nil
+}
+
+// DeletePatient deletes a patient by ID.
+func (s *Service) DeletePatient(ctx context.Context, req *serverpb.DeletePatientRequest) (resp *serverpb.DeletePatientResponse, err error) {
+	ctx, rec := s.audit(ctx, serverpb.Api_DeletePatient_FullMethodName)
+	defer func() { err = rec.finish(err) }()
+	rec.patient(req.Id)
+	acc, err := s.authorize(ctx, rec)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateDeletePatientRequest(req); err != nil {
+		return nil, err
+	}
+	if err := s.checkPatient(ctx, acc, req.Id); err != nil {
+		return nil, err
+	}
+
+	var before *database.Patient
+	err = s.Store.Transaction(ctx, func(tx database.Store) error {
+		var err error
+		if before, err = tx.GetPatientByID(ctx, uint(req.Id)); err != nil {
+			return err
+		}
+		return tx.DeletePatient(ctx, uint(req.Id))
+	})
+	if err != nil {
+		return nil, dbError(err, "patient")
+	}
+
+	rec.change(PatientToProto(before), nil)
+	return &serverpb.DeletePatientResponse{}, nil
+}
+
+// UndeletePatient restores a deleted patient.
+func (s *Service) UndeletePatient(ctx context.Context, req *serverpb.UndeletePatientRequest) (resp *serverpb.UndeletePatientResponse, err error) {
+	ctx, rec := s.audit(ctx, serverpb.Api_UndeletePatient_FullMethodName)
+	defer func() { err = rec.finish(err) }()
+	rec.patient(req.Id)
+	acc, err := s.authorize(ctx, rec)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateUndeletePatientRequest(req); err != nil {
+		return nil, err
+	}
+	if err := s.checkPatient(ctx, acc, req.Id); err != nil {
+		return nil, err
+	}
+
+	id := uint(req.Id)
+	var before, dbPatient *database.Patient
+	err = s.Store.Transaction(ctx, func(tx database.Store) error {
+		var err error
+		if before, err = tx.WithDeleted().GetPatientByID(ctx, id); err != nil {
+			return err
+		}
+		if err := tx.UndeletePatient(ctx, id); err != nil {
+			// Distinguish a patient that was never deleted from one that does not exist
+			if errors.Is(err, gorm.ErrRecordNotFound) && !before.DeletedAt.Valid {
+				return status.Error(codes.FailedPrecondition, "patient is not deleted")
+			}
+			return err
+		}
+		dbPatient, err = tx.GetPatientByID(ctx, id)
+		return err
+	})
+	if err != nil {
+		return nil, dbError(err, "patient")
+	}
+
+	resp = &serverpb.UndeletePatientResponse{
+		Patient: PatientToProto(dbPatient),
+	}
+	rec.change(PatientToProto(before), resp.Patient)
+	return resp, nil
 }
 
 // --- Prescription methods ---
 
 // CreatePrescription creates a prescription associated with a patient.
-func (s *Service) CreatePrescription(ctx context.Context, req *serverpb.CreatePrescriptionRequest) (*serverpb.CreatePrescriptionResponse, error) {
+func (s *Service) CreatePrescription(ctx context.Context, req *serverpb.CreatePrescriptionRequest) (resp *serverpb.CreatePrescriptionResponse, err error) {
+	ctx, rec := s.audit(ctx, serverpb.Api_CreatePrescription_FullMethodName)
+	defer func() { err = rec.finish(err) }()
+	rec.patient(req.PatientId)
+	acc, err := s.authorize(ctx, rec)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateCreatePrescriptionRequest(req); err != nil {
+		return nil, err
+	}
+	if err := s.checkPatient(ctx, acc, req.PatientId); err != nil {
+		return nil, err
+	}
+
 	// Convert proto to database model
 	dbPrescription := PrescriptionFromProto(req.Prescription)
 	
 	// Save to database
-	if err := s.DB.CreatePrescriptionForPatient(uint(req.PatientId), dbPrescription); err != nil {
-		return nil, err
+	if err := s.Store.CreatePrescriptionForPatient(ctx, uint(req.PatientId), dbPrescription); err != nil {
+		return nil, dbError(err, "patient")
 	}
 	
 	// Convert back to proto
-	return &serverpb.CreatePrescriptionResponse{
+	resp = &serverpb.CreatePrescriptionResponse{
 		Prescription: PrescriptionToProto(dbPrescription),
-	}, nil
+	}
+	rec.prescription(resp.Prescription.Id, resp.Prescription.PatientId)
+	rec.change(nil, resp.Prescription)
+	return resp, nil
 }
 
 // GetPrescription fetches a prescription by ID.
-func (s *Service) GetPrescription(ctx context.Context, req *serverpb.GetPrescriptionRequest) (*serverpb.GetPrescriptionResponse, error) {
-	dbPrescription, err := s.DB.GetPrescriptionByID(uint(req.Id))
+func (s *Service) GetPrescription(ctx context.Context, req *serverpb.GetPrescriptionRequest) (resp *serverpb.GetPrescriptionResponse, err error) {
+	ctx, rec := s.audit(ctx, serverpb.Api_GetPrescription_FullMethodName)
+	defer func() { err = rec.finish(err) }()
+	rec.prescription(req.Id, 0)
+	acc, err := s.authorize(ctx, rec)
 	if err != nil {
 		return nil, err
 	}
+	if err := validateGetPrescriptionRequest(req); err != nil {
+		return nil, err
+	}
+
+	dbPrescription, err := s.store(acc, req.IncludeDeleted).GetPrescriptionByID(ctx, uint(req.Id))
+	if err != nil {
+		return nil, dbError(err, "prescription")
+	}
 	
-	return &serverpb.GetPrescriptionResponse{
+	resp = &serverpb.GetPrescriptionResponse{
 		Prescription: PrescriptionToProto(dbPrescription),
-	}, nil
+	}
+	rec.patient(resp.Prescription.PatientId)
+	if err := s.checkPatient(ctx, acc, resp.Prescription.PatientId); err != nil {
+		return nil, err
+	}
+	return resp, nil
 }
 
-// ListPrescriptionsForPatient returns all prescriptions for a patient.
-func (s *Service) ListPrescriptionsForPatient(ctx context.Context, req *serverpb.ListPrescriptionsForPatientRequest) (*serverpb.ListPrescriptionsResponse, error) {
-	dbPrescriptions, err := s.DB.ListPrescriptionsForPatientAssoc(uint(req.PatientId))
+// ListPrescriptionsForPatient returns a filtered page of a patient's prescriptions.
+func (s *Service) ListPrescriptionsForPatient(ctx context.Context, req *serverpb.ListPrescriptionsForPatientRequest) (resp *serverpb.ListPrescriptionsResponse, err error) {
+	ctx, rec := s.audit(ctx, serverpb.Api_ListPrescriptionsForPatient_FullMethodName)
+	defer func() { err = rec.finish(err) }()
+	rec.patient(req.PatientId)
+	acc, err := s.authorize(ctx, rec)
 	if err != nil {
 		return nil, err
 	}
-	
-	return &serverpb.ListPrescriptionsResponse{
-		Prescriptions: PrescriptionsToProto(dbPrescriptions),
-	}, nil
+	if err := validateListPrescriptionsForPatientRequest(req); err != nil {
+		return nil, err
+	}
+	if err := s.checkPatient(ctx, acc, req.PatientId); err != nil {
+		return nil, err
+	}
+
+	search := prescriptionSearch(req.Medication, req.Status, req.PrescribedAfter, req.PrescribedBefore, req.OrderBy)
+	search.PatientID = uint(req.PatientId)
+	return s.listPrescriptions(ctx, s.store(acc, req.IncludeDeleted), rec, search, req, req.PageToken, req.PageSize)
+}
+
+// ListPrescriptions returns a filtered page of prescriptions across all patients.
+func (s *Service) ListPrescriptions(ctx context.Context, req *serverpb.ListPrescriptionsRequest) (resp *serverpb.ListPrescriptionsResponse, err error) {
+	ctx, rec := s.audit(ctx, serverpb.Api_ListPrescriptions_FullMethodName)
+	defer func() { err = rec.finish(err) }()
+	acc, err := s.authorize(ctx, rec)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateListPrescriptionsRequest(req); err != nil {
+		return nil, err
+	}
+
+	search := prescriptionSearch(req.Medication, req.Status, req.PrescribedAfter, req.PrescribedBefore, req.OrderBy)
+	return s.listPrescriptions(ctx, s.store(acc, req.IncludeDeleted), rec, search, req, req.PageToken, req.PageSize)
+}
+
+// listPrescriptions runs a prescription search on db under ctx one page at a
+// time and records the results in rec. A search for one patient fails with
+// NotFound unless the patient exists. req is the originating request, to which
+// page tokens are bound.
+func (s *Service) listPrescriptions(ctx context.Context, db database.Store, rec *auditRecord, search database.PrescriptionSearch, req proto.Message, pageToken string, size int32) (*serverpb.ListPrescriptionsResponse, error) {
+	limit := pageSize(size)
+	search.Limit = limit + 1
+
+	query := requestQuery(req)
+	if pageToken != "" {
+		cur, err := s.pageTokens.decode(pageToken, query)
+		if err != nil {
+			return nil, err
+		}
+		search.After = &database.SearchCursor{ID: cur.LastID, Key: cur.LastKey}
+	}
+
+	var dbPrescriptions []database.Prescription
+	var total int64
+	err := db.ReadTransaction(ctx, func(tx database.Store) error {
+		if search.PatientID != 0 {
+			if err := tx.CheckPatientExists(ctx, search.PatientID); err != nil {
+				return forResource(err, "patient")
+			}
+		}
+		var err error
+		if dbPrescriptions, err = tx.SearchPrescriptions(ctx, search); err != nil {
+			return err
+		}
+		total, err = tx.CountPrescriptionSearch(ctx, search)
+		return err
+	})
+	if err != nil {
+		return nil, dbError(err, "prescription")
+	}
+
+	resp := &serverpb.ListPrescriptionsResponse{}
+	if len(dbPrescriptions) > limit {
+		dbPrescriptions = dbPrescriptions[:limit]
+		last := &dbPrescriptions[limit-1]
+		resp.NextPageToken = s.pageTokens.encode(pageCursor{
+			LastID:  last.ID,
+			LastKey: database.PrescriptionSortKey(last, search.OrderBy),
+			Query:   query,
+		})
+	}
+	resp.Prescriptions = PrescriptionsToProto(dbPrescriptions)
+	rec.prescriptionList(resp.Prescriptions)
+	resp.Total = clampInt32(total)
+
+	return resp, nil
+}
+
+// UpdatePrescription updates an existing prescription. Only the fields named
+// in update_mask are changed; an empty mask or "*" replaces every field.
+func (s *Service) UpdatePrescription(ctx context.Context, req *serverpb.UpdatePrescriptionRequest) (resp *serverpb.UpdatePrescriptionResponse, err error) {
+	ctx, rec := s.audit(ctx, serverpb.Api_UpdatePrescription_FullMethodName)
+	defer func() { err = rec.finish(err) }()
+	rec.prescription(req.GetPrescription().GetId(), 0)
+	acc, err := s.authorize(ctx, rec)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateUpdatePrescriptionRequest(req); err != nil {
+		return nil, err
+	}
+
+	id := uint(req.Prescription.Id)
+
+	fields, err := prescriptionUpdates(req.Prescription, req.UpdateMask)
+	if err != nil {
+		return nil, err
+	}
+
+	target, moving := fields["patient_id"].(uint64)
+	if moving {
+		rec.patient(target)
+	}
+
+	var before, updated *database.Prescription
+	err = s.Store.Transaction(ctx, func(tx database.Store) error {
+		var err error
+		if before, err = tx.GetPrescriptionByID(ctx, id); err != nil {
+			return err
+		}
+		if err := checkPatientIn(ctx, tx, acc, prescriptionPatient(before)); err != nil {
+			return err
+		}
+
+		// Moving a prescription requires the new patient to exist and not be deleted
+		if moving {
+			if err := checkPatientIn(ctx, tx, acc, target); err != nil {
+				return err
+			}
+			if err := tx.CheckPatientExists(ctx, uint(target)); err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					var v violations
+					v.add("prescription.patient_id", "patient %d does not exist", target)
+					return v.err()
+				}
+				return forResource(err, "patient")
+			}
+		}
+		if len(fields) > 0 {
+			if err := tx.UpdatePrescriptionFields(ctx, id, fields); err != nil {
+				return err
+			}
+		}
+		updated, err = tx.GetPrescriptionByID(ctx, id)
+		return err
+	})
+	if before != nil {
+		rec.patient(prescriptionPatient(before))
+	}
+	if err != nil {
+		return nil, dbError(err, "prescription")
+	}
+	beforeProto := PrescriptionToProto(before)
+
+	resp = &serverpb.UpdatePrescriptionResponse{
+		Prescription: PrescriptionToProto(updated),
+	}
+	rec.patient(resp.Prescription.PatientId)
+	rec.change(beforeProto, resp.Prescription)
+	return resp, nil
+}
+
+// DeletePrescription deletes a prescription by ID.
+func (s *Service) DeletePrescription(ctx context.Context, req *serverpb.DeletePrescriptionRequest) (resp *serverpb.DeletePrescriptionResponse, err error) {
+	ctx, rec := s.audit(ctx, serverpb.Api_DeletePrescription_FullMethodName)
+	defer func() { err = rec.finish(err) }()
+	rec.prescription(req.Id, 0)
+	acc, err := s.authorize(ctx, rec)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateDeletePrescriptionRequest(req); err != nil {
+		return nil, err
+	}
+
+	var before *database.Prescription
+	err = s.Store.Transaction(ctx, func(tx database.Store) error {
+		var err error
+		if before, err = tx.GetPrescriptionByID(ctx, uint(req.Id)); err != nil {
+			return err
+		}
+		if err := checkPatientIn(ctx, tx, acc, prescriptionPatient(before)); err != nil {
+			return err
+		}
+		return tx.DeletePrescription(ctx, uint(req.Id))
+	})
+	if before != nil {
+		rec.patient(prescriptionPatient(before))
+	}
+	if err != nil {
+		return nil, dbError(err, "prescription")
+	}
+
+	rec.change(PrescriptionToProto(before), nil)
+	return &serverpb.DeletePrescriptionResponse{}, nil
+}
+
+// ListAuditEvents pages through the audit trail, newest first. Events listed
+// for one patient are narrowed to that patient. Reading the trail is itself
+// audited, against every patient the returned events concern.
+func (s *Service) ListAuditEvents(ctx context.Context, req *serverpb.ListAuditEventsRequest) (resp *serverpb.ListAuditEventsResponse, err error) {
+	ctx, rec := s.audit(ctx, serverpb.Api_ListAuditEvents_FullMethodName)
+	defer func() { err = rec.finish(err) }()
+	acc, err := s.authorize(ctx, rec)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateListAuditEventsRequest(req); err != nil {
+		return nil, err
+	}
+	if acc.careTeam != "" && req.PatientId == 0 {
+		return nil, status.Error(codes.PermissionDenied, "patient_id is required to list audit events of a care team")
+	}
+	if err := s.checkPatient(ctx, acc, req.PatientId); err != nil {
+		return nil, err
+	}
+
+	limit := pageSize(req.PageSize)
+	search := database.AuditSearch{
+		PatientID: uint(req.PatientId),
+		Actor:     req.Actor,
+		Limit:     limit + 1,
+	}
+	if req.StartTime != nil {
+		search.Since = req.StartTime.AsTime()
+	}
+	if req.EndTime != nil {
+		search.Until = req.EndTime.AsTime()
+	}
+
+	query := requestQuery(req)
+	if req.PageToken != "" {
+		cur, err := s.pageTokens.decode(req.PageToken, query)
+		if err != nil {
+			return nil, err
+		}
+		search.After = &database.SearchCursor{ID: cur.LastID}
+	}
+
+	dbEvents, err := s.Store.ListAuditEvents(ctx, search)
+	if err != nil {
+		return nil, dbError(err, "audit event")
+	}
+
+	resp = &serverpb.ListAuditEventsResponse{}
+	if len(dbEvents) > limit {
+		dbEvents = dbEvents[:limit]
+		resp.NextPageToken = s.pageTokens.encode(pageCursor{
+			LastID: dbEvents[limit-1].ID,
+			Query:  query,
+		})
+	}
+	for i := range dbEvents {
+		ev := AuditEventToProto(&dbEvents[i])
+		if req.PatientId != 0 {
+			redactAuditEvent(ev, req.PatientId)
+		}
+		for _, id := range ev.PatientIds {
+			rec.patient(id)
+		}
+		resp.Events = append(resp.Events, ev)
+	}
+
+	return resp, nil
+}
+
+// --- Care team methods ---
+
+// AddCareTeamMember adds a caller to a patient's care team, giving them access
+// to the patient under care-team-scoped roles. Callers restricted to their care
+// team may only add themselves, so they cannot hand out access they hold.
+func (s *Service) AddCareTeamMember(ctx context.Context, req *serverpb.AddCareTeamMemberRequest) (resp *serverpb.AddCareTeamMemberResponse, err error) {
+	ctx, rec := s.audit(ctx, serverpb.Api_AddCareTeamMember_FullMethodName)
+	defer func() { err = rec.finish(err) }()
+	rec.patient(req.PatientId)
+	acc, err := s.authorize(ctx, rec)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateAddCareTeamMemberRequest(req); err != nil {
+		return nil, err
+	}
+	if acc.careTeam != "" && req.Member != acc.careTeam {
+		return nil, status.Errorf(codes.PermissionDenied, "%s may only add themselves to a care team", acc.careTeam)
+	}
+	if err := s.checkPatient(ctx, acc, req.PatientId); err != nil {
+		return nil, err
+	}
+
+	member, err := s.Store.AddCareTeamMember(ctx, uint(req.PatientId), req.Member)
+	if err != nil {
+		return nil, dbError(err, "patient")
+	}
+
+	resp = &serverpb.AddCareTeamMemberResponse{
+		Member: CareTeamMemberToProto(member),
+	}
+	rec.change(nil, resp.Member)
+	return resp, nil
+}
+
+// RemoveCareTeamMember removes a caller from a patient's care team.
+func (s *Service) RemoveCareTeamMember(ctx context.Context, req *serverpb.RemoveCareTeamMemberRequest) (resp *serverpb.RemoveCareTeamMemberResponse, err error) {
+	ctx, rec := s.audit(ctx, serverpb.Api_RemoveCareTeamMember_FullMethodName)
+	defer func() { err = rec.finish(err) }()
+	rec.patient(req.PatientId)
+	acc, err := s.authorize(ctx, rec)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateRemoveCareTeamMemberRequest(req); err != nil {
+		return nil, err
+	}
+	if err := s.checkPatient(ctx, acc, req.PatientId); err != nil {
+		return nil, err
+	}
+
+	if err := s.Store.RemoveCareTeamMember(ctx, uint(req.PatientId), req.Member); err != nil {
+		return nil, dbError(err, "care team member")
+	}
+
+	rec.change(&serverpb.CareTeamMember{PatientId: req.PatientId, Member: req.Member}, nil)
+	return &serverpb.RemoveCareTeamMemberResponse{}, nil
+}
+
+// ListCareTeam returns a patient's care team.
+func (s *Service) ListCareTeam(ctx context.Context, req *serverpb.ListCareTeamRequest) (resp *serverpb.ListCareTeamResponse, err error) {
+	ctx, rec := s.audit(ctx, serverpb.Api_ListCareTeam_FullMethodName)
+	defer func() { err = rec.finish(err) }()
+	rec.patient(req.PatientId)
+	acc, err := s.authorize(ctx, rec)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateListCareTeamRequest(req); err != nil {
+		return nil, err
+	}
+	if err := s.checkPatient(ctx, acc, req.PatientId); err != nil {
+		return nil, err
+	}
+
+	var members []database.CareTeamMember
+	err = s.Store.ReadTransaction(ctx, func(tx database.Store) error {
+		if err := tx.CheckPatientExists(ctx, uint(req.PatientId)); err != nil {
+			return forResource(err, "patient")
+		}
+		var err error
+		members, err = tx.ListCareTeam(ctx, uint(req.PatientId))
+		return err
+	})
+	if err != nil {
+		return nil, dbError(err, "care team member")
+	}
+
+	resp = &serverpb.ListCareTeamResponse{}
+	for i := range members {
+		resp.Members = append(resp.Members, CareTeamMemberToProto(&members[i]))
+	}
+	return resp, nil
 }
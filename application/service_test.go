@@ -0,0 +1,326 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/hcliff-zhang/playground/database"
+	"github.com/hcliff-zhang/playground/server/serverpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// newTestService returns a Service on an empty MemoryStore enforcing the
+// default policy.
+func newTestService() *Service {
+	return NewService(database.NewMemoryStore(), WithPolicy(DefaultPolicy()))
+}
+
+// as returns a context authenticated as subject holding roles, with claims
+// shaped as if decoded from a token.
+func as(subject string, roles ...string) context.Context {
+	claimed := make([]interface{}, len(roles))
+	for i, r := range roles {
+		claimed[i] = r
+	}
+	claims := jwt.MapClaims{"sub": subject, "roles": claimed}
+	return WithPrincipal(context.Background(), &Principal{Subject: subject, Claims: claims})
+}
+
+func wantCode(t *testing.T, what string, err error, code codes.Code) {
+	t.Helper()
+	if got := status.Code(err); got != code {
+		t.Errorf("%s = %v, want %v", what, err, code)
+	}
+}
+
+func createPatient(t *testing.T, ctx context.Context, s *Service, p *serverpb.Patient) *serverpb.Patient {
+	t.Helper()
+	resp, err := s.CreatePatient(ctx, &serverpb.CreatePatientRequest{Patient: p})
+	if err != nil {
+		t.Fatalf("CreatePatient(%s %s): %v", p.FirstName, p.LastName, err)
+	}
+	return resp.Patient
+}
+
+func TestServiceRequiresAuthentication(t *testing.T) {
+	s := newTestService()
+	_, err := s.ListPatients(context.Background(), &serverpb.ListPatientsRequest{})
+	wantCode(t, "ListPatients without a principal", err, codes.Unauthenticated)
+	_, err = s.DeletePatient(as("dr-a", "clinician"), &serverpb.DeletePatientRequest{Id: 1})
+	wantCode(t, "DeletePatient as a clinician", err, codes.PermissionDenied)
+}
+
+func TestServiceCreatePatient(t *testing.T) {
+	s := newTestService()
+	ctx := as("desk", "front-desk")
+
+	p := createPatient(t, ctx, s, &serverpb.Patient{FirstName: "Ann", LastName: "Lee", Email: "ann@example.com"})
+	got, err := s.GetPatient(ctx, &serverpb.GetPatientRequest{Id: p.Id})
+	if err != nil {
+		t.Fatalf("GetPatient: %v", err)
+	}
+	if got.Patient.FirstName != "Ann" || got.Patient.Email != "ann@example.com" {
+		t.Errorf("GetPatient = %v", got.Patient)
+	}
+
+	// Email is optional, and only given emails must be unique
+	createPatient(t, ctx, s, &serverpb.Patient{FirstName: "Bob", LastName: "Ray"})
+	createPatient(t, ctx, s, &serverpb.Patient{FirstName: "Cy", LastName: "Fox"})
+	_, err = s.CreatePatient(ctx, &serverpb.CreatePatientRequest{Patient: &serverpb.Patient{FirstName: "Dee", LastName: "Lee", Email: "ann@example.com"}})
+	wantCode(t, "CreatePatient with a taken email", err, codes.AlreadyExists)
+
+	_, err = s.CreatePatient(ctx, &serverpb.CreatePatientRequest{Patient: &serverpb.Patient{LastName: "Lee"}})
+	wantCode(t, "CreatePatient without a first name", err, codes.InvalidArgument)
+	_, err = s.GetPatient(ctx, &serverpb.GetPatientRequest{Id: 999})
+	wantCode(t, "GetPatient(missing)", err, codes.NotFound)
+}
+
+func TestServiceListPatientsPages(t *testing.T) {
+	s := newTestService()
+	ctx := as("desk", "front-desk")
+	for _, name := range []string{"A", "B", "C", "D", "E"} {
+		createPatient(t, ctx, s, &serverpb.Patient{FirstName: name, LastName: name})
+	}
+
+	var names []string
+	req := &serverpb.ListPatientsRequest{PageSize: 2}
+	for pages := 0; ; pages++ {
+		if pages == 3 {
+			t.Fatal("ListPatients did not stop after 3 pages")
+		}
+		resp, err := s.ListPatients(ctx, req)
+		if err != nil {
+			t.Fatalf("ListPatients: %v", err)
+		}
+		if resp.Total != 5 {
+			t.Errorf("ListPatients total = %d, want 5", resp.Total)
+		}
+		for _, p := range resp.Patients {
+			names = append(names, p.FirstName)
+		}
+		if resp.NextPageToken == "" {
+			break
+		}
+		req.PageToken = resp.NextPageToken
+	}
+	if got := len(names); got != 5 || names[0] != "E" || names[4] != "A" {
+		t.Errorf("ListPatients returned %v, want E to A", names)
+	}
+
+	// A token only continues the listing it came from
+	first, err := s.ListPatients(ctx, &serverpb.ListPatientsRequest{PageSize: 2})
+	if err != nil {
+		t.Fatalf("ListPatients: %v", err)
+	}
+	_, err = s.ListPatients(ctx, &serverpb.ListPatientsRequest{PageSize: 2, PageToken: first.NextPageToken, IncludeDeleted: true})
+	wantCode(t, "ListPatients with another listing's token", err, codes.InvalidArgument)
+	_, err = s.ListPatients(ctx, &serverpb.ListPatientsRequest{PageSize: 3, PageToken: first.NextPageToken})
+	if err != nil {
+		t.Errorf("ListPatients with a new page size: %v", err)
+	}
+}
+
+func TestServiceSearchPatients(t *testing.T) {
+	s := newTestService()
+	ctx := as("desk", "front-desk")
+	createPatient(t, ctx, s, &serverpb.Patient{FirstName: "Ann", LastName: "Lee"})
+	createPatient(t, ctx, s, &serverpb.Patient{FirstName: "Bob", LastName: "Annson"})
+	createPatient(t, ctx, s, &serverpb.Patient{FirstName: "Cy", LastName: "Fox"})
+
+	resp, err := s.SearchPatients(ctx, &serverpb.SearchPatientsRequest{Filter: "name:ann", OrderBy: "last_name desc"})
+	if err != nil {
+		t.Fatalf("SearchPatients: %v", err)
+	}
+	if len(resp.Patients) != 2 || resp.Patients[0].LastName != "Lee" || resp.Total != 2 {
+		t.Errorf("SearchPatients = %v, total %d; want Lee then Annson", resp.Patients, resp.Total)
+	}
+
+	_, err = s.SearchPatients(ctx, &serverpb.SearchPatientsRequest{Filter: "height:2"})
+	wantCode(t, "SearchPatients with an unknown field", err, codes.InvalidArgument)
+	_, err = s.SearchPatients(ctx, &serverpb.SearchPatientsRequest{OrderBy: "phone"})
+	wantCode(t, "SearchPatients ordered by phone", err, codes.InvalidArgument)
+}
+
+func TestServiceDeleteAndUndeletePatient(t *testing.T) {
+	s := newTestService()
+	ctx := as("desk", "front-desk")
+	p := createPatient(t, ctx, s, &serverpb.Patient{FirstName: "Ann", LastName: "Lee"})
+
+	if _, err := s.DeletePatient(ctx, &serverpb.DeletePatientRequest{Id: p.Id}); err != nil {
+		t.Fatalf("DeletePatient: %v", err)
+	}
+	_, err := s.GetPatient(ctx, &serverpb.GetPatientRequest{Id: p.Id})
+	wantCode(t, "GetPatient(deleted)", err, codes.NotFound)
+	got, err := s.GetPatient(ctx, &serverpb.GetPatientRequest{Id: p.Id, IncludeDeleted: true})
+	if err != nil || got.Patient.DeletedAt == nil {
+		t.Errorf("GetPatient(deleted, include_deleted) = %v, %v", got, err)
+	}
+	list, _ := s.ListPatients(ctx, &serverpb.ListPatientsRequest{})
+	if len(list.GetPatients()) != 0 {
+		t.Errorf("ListPatients returned %d deleted patients", len(list.GetPatients()))
+	}
+
+	if _, err := s.UndeletePatient(ctx, &serverpb.UndeletePatientRequest{Id: p.Id}); err != nil {
+		t.Fatalf("UndeletePatient: %v", err)
+	}
+	_, err = s.UndeletePatient(ctx, &serverpb.UndeletePatientRequest{Id: p.Id})
+	wantCode(t, "UndeletePatient(not deleted)", err, codes.FailedPrecondition)
+	if _, err := s.GetPatient(ctx, &serverpb.GetPatientRequest{Id: p.Id}); err != nil {
+		t.Errorf("GetPatient(undeleted): %v", err)
+	}
+}
+
+func TestServiceCareTeamScoping(t *testing.T) {
+	s := newTestService()
+	drA, drB := as("dr-a", "clinician"), as("dr-b", "clinician")
+
+	// Clinicians join the care team of the patients they create
+	mine := createPatient(t, drA, s, &serverpb.Patient{FirstName: "Ann", LastName: "Lee"})
+	theirs := createPatient(t, drB, s, &serverpb.Patient{FirstName: "Bob", LastName: "Ray"})
+
+	list, err := s.ListPatients(drA, &serverpb.ListPatientsRequest{})
+	if err != nil {
+		t.Fatalf("ListPatients: %v", err)
+	}
+	if len(list.Patients) != 1 || list.Patients[0].Id != mine.Id || list.Total != 1 {
+		t.Errorf("dr-a lists %v, total %d; want only patient %d", list.Patients, list.Total, mine.Id)
+	}
+	_, err = s.GetPatient(drA, &serverpb.GetPatientRequest{Id: theirs.Id})
+	wantCode(t, "GetPatient outside the care team", err, codes.PermissionDenied)
+	_, err = s.CreatePrescription(drA, &serverpb.CreatePrescriptionRequest{PatientId: theirs.Id, Prescription: &serverpb.Prescription{Medication: "Aspirin", Quantity: 30}})
+	wantCode(t, "CreatePrescription outside the care team", err, codes.PermissionDenied)
+
+	// Clinicians cannot share their patients, only the front desk can
+	_, err = s.AddCareTeamMember(drB, &serverpb.AddCareTeamMemberRequest{PatientId: theirs.Id, Member: "dr-a"})
+	wantCode(t, "AddCareTeamMember of a third party by a clinician", err, codes.PermissionDenied)
+	if _, err := s.GetPatient(drA, &serverpb.GetPatientRequest{Id: theirs.Id}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("GetPatient after a denied AddCareTeamMember = %v, want PermissionDenied", err)
+	}
+	if _, err := s.AddCareTeamMember(as("desk", "front-desk"), &serverpb.AddCareTeamMemberRequest{PatientId: theirs.Id, Member: "dr-a"}); err != nil {
+		t.Fatalf("AddCareTeamMember: %v", err)
+	}
+	if _, err := s.GetPatient(drA, &serverpb.GetPatientRequest{Id: theirs.Id}); err != nil {
+		t.Errorf("GetPatient after joining the care team: %v", err)
+	}
+}
+
+func TestServiceCareTeamMembersAddOnlyThemselves(t *testing.T) {
+	// Even a policy letting clinicians manage care teams keeps them from
+	// adding anyone but themselves
+	policy, err := ParsePolicy([]byte(`
+roles_claim: roles
+care_team_roles: [clinician]
+methods:
+  CreatePatient: [clinician]
+  GetPatient: [clinician]
+  AddCareTeamMember: [clinician]
+`))
+	if err != nil {
+		t.Fatalf("ParsePolicy: %v", err)
+	}
+	s := NewService(database.NewMemoryStore(), WithPolicy(policy))
+	drA := as("dr-a", "clinician")
+	p := createPatient(t, drA, s, &serverpb.Patient{FirstName: "Ann", LastName: "Lee"})
+
+	_, err = s.AddCareTeamMember(drA, &serverpb.AddCareTeamMemberRequest{PatientId: p.Id, Member: "dr-b"})
+	wantCode(t, "AddCareTeamMember of a third party by a clinician", err, codes.PermissionDenied)
+	_, err = s.GetPatient(as("dr-b", "clinician"), &serverpb.GetPatientRequest{Id: p.Id})
+	wantCode(t, "GetPatient by the clinician who was not added", err, codes.PermissionDenied)
+	_, err = s.AddCareTeamMember(as("dr-b", "clinician"), &serverpb.AddCareTeamMemberRequest{PatientId: p.Id, Member: "dr-b"})
+	wantCode(t, "AddCareTeamMember of themselves outside the care team", err, codes.PermissionDenied)
+}
+
+func TestServicePrescriptions(t *testing.T) {
+	s := newTestService()
+	ctx := as("dr-a", "clinician")
+	p := createPatient(t, ctx, s, &serverpb.Patient{FirstName: "Ann", LastName: "Lee"})
+
+	created, err := s.CreatePrescription(ctx, &serverpb.CreatePrescriptionRequest{PatientId: p.Id, Prescription: &serverpb.Prescription{Medication: "Aspirin", Quantity: 30}})
+	if err != nil {
+		t.Fatalf("CreatePrescription: %v", err)
+	}
+	if created.Prescription.PatientId != p.Id || created.Prescription.Status != database.PrescriptionActive {
+		t.Errorf("CreatePrescription = %v", created.Prescription)
+	}
+	_, err = s.CreatePrescription(ctx, &serverpb.CreatePrescriptionRequest{PatientId: p.Id, Prescription: &serverpb.Prescription{}})
+	wantCode(t, "CreatePrescription without a medication", err, codes.InvalidArgument)
+
+	list, err := s.ListPrescriptionsForPatient(ctx, &serverpb.ListPrescriptionsForPatientRequest{PatientId: p.Id})
+	if err != nil || len(list.Prescriptions) != 1 {
+		t.Errorf("ListPrescriptionsForPatient = %v, %v; want 1 prescription", list, err)
+	}
+}
+
+func TestServiceAuditTrail(t *testing.T) {
+	s := newTestService()
+	p := createPatient(t, as("desk", "front-desk"), s, &serverpb.Patient{FirstName: "Ann", LastName: "Lee"})
+	_, err := s.GetPatient(as("dr-a", "clinician"), &serverpb.GetPatientRequest{Id: p.Id})
+	wantCode(t, "GetPatient outside the care team", err, codes.PermissionDenied)
+
+	resp, err := s.ListAuditEvents(as("auditor", "auditor"), &serverpb.ListAuditEventsRequest{PatientId: p.Id})
+	if err != nil {
+		t.Fatalf("ListAuditEvents: %v", err)
+	}
+	actors := map[string]bool{}
+	for _, ev := range resp.Events {
+		actors[ev.Actor] = true
+	}
+	if !actors["desk"] || !actors["dr-a"] {
+		t.Errorf("audit trail of patient %d has actors %v, want desk and the denied dr-a", p.Id, actors)
+	}
+}
+
+func TestServiceAuditEventsForPatient(t *testing.T) {
+	s := newTestService()
+	desk := as("desk", "front-desk")
+	ann := createPatient(t, desk, s, &serverpb.Patient{FirstName: "Ann", LastName: "Lee"})
+	bob := createPatient(t, desk, s, &serverpb.Patient{FirstName: "Bob", LastName: "Ray"})
+	if _, err := s.ListPatients(desk, &serverpb.ListPatientsRequest{}); err != nil {
+		t.Fatalf("ListPatients: %v", err)
+	}
+
+	resp, err := s.ListAuditEvents(as("auditor", "auditor"), &serverpb.ListAuditEventsRequest{PatientId: ann.Id})
+	if err != nil {
+		t.Fatalf("ListAuditEvents: %v", err)
+	}
+	var listed bool
+	for _, ev := range resp.Events {
+		if len(ev.PatientIds) != 1 || ev.PatientIds[0] != ann.Id {
+			t.Errorf("event %d of %s concerns patients %v, want only %d", ev.Id, ev.Method, ev.PatientIds, ann.Id)
+		}
+		for _, r := range ev.Resources {
+			if r == fmt.Sprintf("patients/%d", bob.Id) {
+				t.Errorf("event %d of %s names %s", ev.Id, ev.Method, r)
+			}
+		}
+		listed = listed || ev.Method == serverpb.Api_ListPatients_FullMethodName
+	}
+	if !listed {
+		t.Errorf("audit trail of patient %d misses the listing of both patients", ann.Id)
+	}
+}
+
+// failingAuditStore is a store that cannot record audit events.
+type failingAuditStore struct {
+	database.Store
+}
+
+func (failingAuditStore) CreateAuditEvent(context.Context, *database.AuditEvent) error {
+	return errors.New("audit table unavailable")
+}
+
+func TestServiceFailsClosedWithoutAuditTrail(t *testing.T) {
+	s := NewService(failingAuditStore{database.NewMemoryStore()}, WithPolicy(DefaultPolicy()))
+	ctx := as("desk", "front-desk")
+
+	_, err := s.CreatePatient(ctx, &serverpb.CreatePatientRequest{Patient: &serverpb.Patient{FirstName: "Ann", LastName: "Lee"}})
+	wantCode(t, "CreatePatient without an audit trail", err, codes.Internal)
+	_, err = s.GetPatient(ctx, &serverpb.GetPatientRequest{Id: 1})
+	wantCode(t, "GetPatient without an audit trail", err, codes.Internal)
+	_, err = s.GetPatient(ctx, &serverpb.GetPatientRequest{Id: 2})
+	wantCode(t, "GetPatient(missing) without an audit trail", err, codes.NotFound)
+}
@@ -0,0 +1,198 @@
+package application
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// reloadInterval is how often TLS files are checked for changes.
+const reloadInterval = 5 * time.Second
+
+// ServerTLS configures TLS on a listener from PEM files.
+type ServerTLS struct {
+	CertFile string
+	KeyFile  string
+	// ClientCAFile, if set, enables mutual TLS: clients are verified against
+	// the CAs in this file.
+	ClientCAFile string
+	// ClientCertOptional accepts clients without a certificate when mutual TLS
+	// is enabled. Certificates that are presented must still verify.
+	ClientCertOptional bool
+}
+
+// ClientTLS configures TLS on an outgoing connection from PEM files.
+type ClientTLS struct {
+	// CAFile verifies the server; the system roots are used if it is empty.
+	CAFile string
+	// CertFile and KeyFile, if set, are presented for mutual TLS.
+	CertFile string
+	KeyFile  string
+	// ServerName overrides the name the server certificate is checked against.
+	ServerName string
+}
+
+// Config returns a TLS config serving the certificate and, with mutual TLS,
+// verifying clients against the client CAs. Both are reloaded when their files
+// change, so certificates can be rotated without a restart.
+func (c ServerTLS) Config() (*tls.Config, error) {
+	if c.CertFile == "" || c.KeyFile == "" {
+		return nil, errors.New("TLS needs both a certificate and a key file")
+	}
+	cert, err := newKeyPairReloader(c.CertFile, c.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	base := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: []string{"h2", "http/1.1"},
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return cert.get()
+		},
+	}
+	if c.ClientCAFile == "" {
+		return base, nil
+	}
+
+	cas, err := newCertPoolReloader(c.ClientCAFile)
+	if err != nil {
+		return nil, err
+	}
+	clientAuth := tls.RequireAndVerifyClientCert
+	if c.ClientCertOptional {
+		clientAuth = tls.VerifyClientCertIfGiven
+	}
+	base.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		pool, err := cas.get()
+		if err != nil {
+			return nil, err
+		}
+		cfg := base.Clone()
+		cfg.GetConfigForClient = nil
+		cfg.ClientAuth = clientAuth
+		cfg.ClientCAs = pool
+		return cfg, nil
+	}
+	return base, nil
+}
+
+// Config returns a TLS config for dialling a server. The client certificate,
+// if any, is reloaded when its files change.
+func (c ClientTLS) Config() (*tls.Config, error) {
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: c.ServerName,
+	}
+	if c.CAFile != "" {
+		pool, err := loadCertPool(c.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.RootCAs = pool
+	}
+	if c.CertFile != "" || c.KeyFile != "" {
+		cert, err := newKeyPairReloader(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return cert.get()
+		}
+	}
+	return cfg, nil
+}
+
+// fileReloader holds a value loaded from files and reloads it when any of the
+// files' size or modification time changes. Changes are noticed at most
+// reloadInterval late. If a reload fails, for example because only one of a
+// certificate and its key has been replaced yet, the previous value is kept.
+type fileReloader[T any] struct {
+	files []string
+	load  func() (T, error)
+
+	mu      sync.Mutex
+	value   T
+	stamp   string
+	checked time.Time
+}
+
+func newFileReloader[T any](load func() (T, error), files ...string) (*fileReloader[T], error) {
+	r := &fileReloader[T]{files: files, load: load}
+	stamp, err := r.fileStamp()
+	if err != nil {
+		return nil, err
+	}
+	if r.value, err = load(); err != nil {
+		return nil, err
+	}
+	r.stamp, r.checked = stamp, time.Now()
+	return r, nil
+}
+
+func (r *fileReloader[T]) get() (T, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if time.Since(r.checked) < reloadInterval {
+		return r.value, nil
+	}
+	r.checked = time.Now()
+	stamp, err := r.fileStamp()
+	if err != nil || stamp == r.stamp {
+		return r.value, nil
+	}
+	value, err := r.load()
+	if err != nil {
+		log.Printf("tls: keeping previous %s: %v", strings.Join(r.files, ", "), err)
+		return r.value, nil
+	}
+	log.Printf("tls: reloaded %s", strings.Join(r.files, ", "))
+	r.value, r.stamp = value, stamp
+	return r.value, nil
+}
+
+// fileStamp summarizes the size and modification time of the files.
+func (r *fileReloader[T]) fileStamp() (string, error) {
+	var b strings.Builder
+	for _, f := range r.files {
+		fi, err := os.Stat(f)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "%d:%d;", fi.Size(), fi.ModTime().UnixNano())
+	}
+	return b.String(), nil
+}
+
+func newKeyPairReloader(certFile, keyFile string) (*fileReloader[*tls.Certificate], error) {
+	return newFileReloader(func() (*tls.Certificate, error) {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		return &cert, err
+	}, certFile, keyFile)
+}
+
+func newCertPoolReloader(caFile string) (*fileReloader[*x509.CertPool], error) {
+	return newFileReloader(func() (*x509.CertPool, error) {
+		return loadCertPool(caFile)
+	}, caFile)
+}
+
+// loadCertPool reads the PEM certificates in a file into a pool.
+func loadCertPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("%s contains no PEM certificates", path)
+	}
+	return pool, nil
+}
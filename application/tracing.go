@@ -0,0 +1,156 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/stats"
+	"google.golang.org/grpc/status"
+)
+
+var tracer = otel.Tracer("github.com/hcliff-zhang/playground/application")
+
+// Tracing configures OpenTelemetry tracing.
+type Tracing struct {
+	// Exporter is "otlp" to send spans to a collector over OTLP/gRPC, "file"
+	// to append them to File as JSON, or "none" or "" to record no spans.
+	// Trace context is propagated either way.
+	Exporter string
+	// Endpoint is the collector's host:port, such as localhost:4317.
+	Endpoint string
+	// Insecure sends spans to the collector without TLS.
+	Insecure bool
+	// File receives spans from the file exporter.
+	File string
+	// SampleRatio is the fraction of new traces recorded. Traces started by a
+	// caller follow the caller's sampling decision.
+	SampleRatio float64
+	// ServiceName identifies this server in traces.
+	ServiceName string
+}
+
+// Start installs the global tracer provider and the W3C trace context and
+// baggage propagators. The returned function flushes outstanding spans and
+// stops the exporter.
+func (t Tracing) Start(ctx context.Context) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	var closeFile func() error
+	switch t.Exporter {
+	case "", "none":
+		return func(context.Context) error { return nil }, nil
+	case "otlp":
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(t.Endpoint)}
+		if t.Insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		otlp, err := otlptracegrpc.New(ctx, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+		}
+		exporter = otlp
+	case "file":
+		f, err := os.OpenFile(t.File, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+		if err != nil {
+			return nil, err
+		}
+		stdout, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		exporter, closeFile = stdout, f.Close
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", t.Exporter)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(semconv.ServiceName(t.ServiceName)))
+	if err != nil {
+		return nil, err
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(t.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closeFile != nil {
+			err = errors.Join(err, closeFile())
+		}
+		return err
+	}, nil
+}
+
+// TraceGRPCServer is a server option recording a span for every incoming
+// gRPC call, continuing the trace of the caller. Health checks are not
+// traced.
+func TraceGRPCServer() grpc.ServerOption {
+	return grpc.StatsHandler(otelgrpc.NewServerHandler(otelgrpc.WithFilter(func(info *stats.RPCTagInfo) bool {
+		return !isPublicMethod(info.FullMethodName)
+	})))
+}
+
+// TraceHTTP records a span for every request to h, continuing the trace of
+// the caller. Probes and metric scrapes are not traced.
+func TraceHTTP(h http.Handler) http.Handler {
+	return otelhttp.NewHandler(h, "HTTP", otelhttp.WithFilter(func(r *http.Request) bool {
+		switch r.URL.Path {
+		case "/healthz", "/readyz", "/metrics":
+			return false
+		}
+		return true
+	}))
+}
+
+// TraceGatewayRoutes names the span of each gateway request after its route
+// pattern, such as "GET /v1/patients/{id=*}".
+func TraceGatewayRoutes() runtime.ServeMuxOption {
+	return runtime.WithMiddlewares(func(next runtime.HandlerFunc) runtime.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+			if p, ok := runtime.HTTPPattern(r.Context()); ok {
+				span := trace.SpanFromContext(r.Context())
+				span.SetName(r.Method + " " + p.String())
+				span.SetAttributes(semconv.HTTPRoute(p.String()))
+			}
+			next(w, r, pathParams)
+		}
+	})
+}
+
+// startSpan starts the span of a Service method, given by its full gRPC
+// name, naming it like "Service.GetPatient".
+func startSpan(ctx context.Context, method string) (context.Context, trace.Span) {
+	name := method[strings.LastIndex(method, "/")+1:]
+	return tracer.Start(ctx, "Service."+name, trace.WithAttributes(attribute.String("rpc.method", method)))
+}
+
+// endSpan ends the span of a Service method that returned err.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, status.Convert(err).Message())
+	}
+	span.End()
+}
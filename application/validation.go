@@ -0,0 +1,426 @@
+package application
+
+import (
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hcliff-zhang/playground/database"
+	"github.com/hcliff-zhang/playground/server/serverpb"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Maximum field lengths, matching the size tags in database/model.go.
+const (
+	maxNameLen       = 100
+	maxGenderLen     = 20
+	maxEmailLen      = 200
+	maxPhoneLen      = 50
+	maxAddressLen    = 500
+	maxMedicationLen = 255
+	maxDosageLen     = 100
+	maxFrequencyLen  = 100
+	maxMemberLen     = 255
+)
+
+// validGenders enumerates the accepted values of Patient.gender. An empty
+// gender is allowed and means it was not recorded.
+var validGenders = map[string]bool{
+	"male":    true,
+	"female":  true,
+	"other":   true,
+	"unknown": true,
+}
+
+// validPrescriptionStatuses enumerates the accepted values of
+// Prescription.status.
+var validPrescriptionStatuses = map[string]bool{
+	database.PrescriptionActive:    true,
+	database.PrescriptionCompleted: true,
+	database.PrescriptionCancelled: true,
+}
+
+// phonePattern accepts digits with an optional leading "+" and common
+// separators. The digit count is checked separately.
+var phonePattern = regexp.MustCompile(`^\+?[0-9 ().-]+$`)
+
+// violations accumulates the field violations found while validating a request.
+type violations []*errdetails.BadRequest_FieldViolation
+
+// add records a violation for field.
+func (v *violations) add(field, format string, args ...interface{}) {
+	*v = append(*v, &errdetails.BadRequest_FieldViolation{
+		Field:       field,
+		Description: fmt.Sprintf(format, args...),
+	})
+}
+
+// err returns nil when no violations were recorded, otherwise an InvalidArgument
+// status carrying every violation in a google.rpc.BadRequest detail.
+func (v violations) err() error {
+	if len(v) == 0 {
+		return nil
+	}
+
+	msgs := make([]string, len(v))
+	for i, fv := range v {
+		msgs[i] = fv.Field + ": " + fv.Description
+	}
+
+	st := status.New(codes.InvalidArgument, "invalid request: "+strings.Join(msgs, "; "))
+	if withDetails, err := st.WithDetails(&errdetails.BadRequest{FieldViolations: v}); err == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
+
+// requireID records a violation when id is not set.
+func (v *violations) requireID(field string, id uint64) {
+	if id == 0 {
+		v.add(field, "is required")
+	}
+}
+
+// maxLen records a violation when value is longer than max characters.
+func (v *violations) maxLen(field, value string, max int) {
+	if utf8.RuneCountInString(value) > max {
+		v.add(field, "must be at most %d characters", max)
+	}
+}
+
+// required records a violation when value is empty or only whitespace, and
+// otherwise checks its length.
+func (v *violations) required(field, value string, max int) {
+	if strings.TrimSpace(value) == "" {
+		v.add(field, "is required")
+		return
+	}
+	v.maxLen(field, value, max)
+}
+
+// maskPaths returns the set of paths named by a partial update mask, or nil for
+// a full replacement in which every field is validated.
+func maskPaths(mask *fieldmaskpb.FieldMask) map[string]bool {
+	if isFullReplacement(mask) {
+		return nil
+	}
+	paths := make(map[string]bool, len(mask.GetPaths()))
+	for _, p := range mask.GetPaths() {
+		paths[p] = true
+	}
+	return paths
+}
+
+// checkMask records a violation for every path that is not updatable.
+func (v *violations) checkMask(mask *fieldmaskpb.FieldMask, columns map[string]string) {
+	if isFullReplacement(mask) {
+		return
+	}
+	for _, p := range mask.GetPaths() {
+		if _, ok := columns[p]; !ok && !outputOnlyPaths[p] {
+			v.add("update_mask", "field %q does not exist or cannot be updated", p)
+		}
+	}
+}
+
+// validatePatient checks the fields of p. When only is non-nil just the named
+// fields are checked, as for a masked update.
+func (v *violations) validatePatient(prefix string, p *serverpb.Patient, only map[string]bool) {
+	check := func(path string) bool { return only == nil || only[path] }
+
+	if check("first_name") {
+		v.required(prefix+".first_name", p.FirstName, maxNameLen)
+	}
+	if check("last_name") {
+		v.required(prefix+".last_name", p.LastName, maxNameLen)
+	}
+	if check("gender") && p.Gender != "" {
+		if !validGenders[p.Gender] {
+			v.add(prefix+".gender", "must be one of male, female, other or unknown")
+		}
+		v.maxLen(prefix+".gender", p.Gender, maxGenderLen)
+	}
+	if check("email") && p.Email != "" {
+		if addr, err := mail.ParseAddress(p.Email); err != nil || addr.Address != p.Email {
+			v.add(prefix+".email", "must be a valid email address")
+		}
+		v.maxLen(prefix+".email", p.Email, maxEmailLen)
+	}
+	if check("phone") && p.Phone != "" {
+		digits := len(strings.Map(keepDigits, p.Phone))
+		if !phonePattern.MatchString(p.Phone) || digits < 7 || digits > 15 {
+			v.add(prefix+".phone", "must be a phone number of 7 to 15 digits")
+		}
+		v.maxLen(prefix+".phone", p.Phone, maxPhoneLen)
+	}
+	if check("address") {
+		v.maxLen(prefix+".address", p.Address, maxAddressLen)
+	}
+}
+
+// validatePrescription checks the fields of pr. When only is non-nil just the
+// named fields are checked, as for a masked update.
+func (v *violations) validatePrescription(prefix string, pr *serverpb.Prescription, only map[string]bool) {
+	check := func(path string) bool { return only == nil || only[path] }
+
+	if check("medication") {
+		v.required(prefix+".medication", pr.Medication, maxMedicationLen)
+	}
+	if check("dosage") {
+		v.maxLen(prefix+".dosage", pr.Dosage, maxDosageLen)
+	}
+	if check("frequency") {
+		v.maxLen(prefix+".frequency", pr.Frequency, maxFrequencyLen)
+	}
+	if check("quantity") && pr.Quantity <= 0 {
+		v.add(prefix+".quantity", "must be greater than zero")
+	}
+	// Empty values take defaults on create, so they are only an error when a
+	// masked update names them explicitly
+	if check("status") {
+		if pr.Status == "" && only != nil {
+			v.add(prefix+".status", "is required")
+		} else if pr.Status != "" && !validPrescriptionStatuses[pr.Status] {
+			v.add(prefix+".status", "must be one of active, completed or cancelled")
+		}
+	}
+	if check("patient_id") && only != nil && pr.PatientId == 0 {
+		v.add(prefix+".patient_id", "is required; a prescription cannot be detached from its patient")
+	}
+	if check("prescribed_at") {
+		if pr.PrescribedAt == nil && only != nil {
+			v.add(prefix+".prescribed_at", "is required")
+		} else if pr.PrescribedAt != nil && pr.PrescribedAt.CheckValid() != nil {
+			v.add(prefix+".prescribed_at", "must be a valid timestamp")
+		}
+	}
+}
+
+// prescriptionListFilter checks the filter, ordering and paging fields shared
+// by the prescription listing requests.
+func (v *violations) prescriptionListFilter(status string, after, before *timestamppb.Timestamp, orderBy string, pageSize int32) {
+	if status != "" && !validPrescriptionStatuses[status] {
+		v.add("status", "must be one of active, completed or cancelled")
+	}
+	if after != nil && after.CheckValid() != nil {
+		v.add("prescribed_after", "must be a valid timestamp")
+	}
+	if before != nil && before.CheckValid() != nil {
+		v.add("prescribed_before", "must be a valid timestamp")
+	}
+	if after != nil && before != nil && !after.AsTime().Before(before.AsTime()) {
+		v.add("prescribed_before", "must be after prescribed_after")
+	}
+	if _, _, err := parsePrescriptionOrder(orderBy); err != nil {
+		v.add("order_by", "%v", err)
+	}
+	if pageSize < 0 {
+		v.add("page_size", "must not be negative")
+	}
+}
+
+// keepDigits is a strings.Map function dropping every non-digit rune.
+func keepDigits(r rune) rune {
+	if r >= '0' && r <= '9' {
+		return r
+	}
+	return -1
+}
+
+// Each validateXxxRequest function below checks one serverpb request message
+// and returns an InvalidArgument status listing every violation found, or nil.
+
+// --- Patient requests ---
+
+func validateCreatePatientRequest(req *serverpb.CreatePatientRequest) error {
+	var v violations
+	if req.Patient == nil {
+		v.add("patient", "is required")
+		return v.err()
+	}
+	if req.Patient.Id != 0 {
+		v.add("patient.id", "must not be set; it is assigned by the server")
+	}
+	v.validatePatient("patient", req.Patient, nil)
+	for i, pr := range req.Patient.Prescriptions {
+		prefix := fmt.Sprintf("patient.prescriptions[%d]", i)
+		if pr.Id != 0 {
+			v.add(prefix+".id", "must not be set; it is assigned by the server")
+		}
+		if pr.PatientId != 0 {
+			v.add(prefix+".patient_id", "must not be set; it is the patient being created")
+		}
+		v.validatePrescription(prefix, pr, nil)
+	}
+	return v.err()
+}
+
+func validateGetPatientRequest(req *serverpb.GetPatientRequest) error {
+	var v violations
+	v.requireID("id", req.Id)
+	return v.err()
+}
+
+func validateListPatientsRequest(req *serverpb.ListPatientsRequest) error {
+	var v violations
+	if req.Limit < 0 {
+		v.add("limit", "must not be negative")
+	}
+	if req.Offset < 0 {
+		v.add("offset", "must not be negative")
+	}
+	if req.PageSize < 0 {
+		v.add("page_size", "must not be negative")
+	}
+	return v.err()
+}
+
+// validateSearchPatientsRequest also returns the search the request's filter
+// and order_by describe, leaving its paging to the caller.
+func validateSearchPatientsRequest(req *serverpb.SearchPatientsRequest) (database.PatientSearch, error) {
+	var v violations
+	var search database.PatientSearch
+	if len(req.Filter) > maxFilterLen {
+		v.add("filter", "must be at most %d characters", maxFilterLen)
+	} else if conds, err := parsePatientFilter(req.Filter); err != nil {
+		v.add("filter", "%v", err)
+	} else {
+		search.Conditions = conds
+	}
+	if field, desc, err := parsePatientOrder(req.OrderBy); err != nil {
+		v.add("order_by", "%v", err)
+	} else {
+		search.OrderBy, search.Desc = field, desc
+	}
+	if req.PageSize < 0 {
+		v.add("page_size", "must not be negative")
+	}
+	return search, v.err()
+}
+
+func validateUpdatePatientRequest(req *serverpb.UpdatePatientRequest) error {
+	var v violations
+	if req.Patient == nil {
+		v.add("patient", "is required")
+		return v.err()
+	}
+	v.requireID("patient.id", req.Patient.Id)
+	v.checkMask(req.UpdateMask, patientMaskColumns)
+	v.validatePatient("patient", req.Patient, maskPaths(req.UpdateMask))
+	return v.err()
+}
+
+func validateDeletePatientRequest(req *serverpb.DeletePatientRequest) error {
+	var v violations
+	v.requireID("id", req.Id)
+	return v.err()
+}
+
+func validateUndeletePatientRequest(req *serverpb.UndeletePatientRequest) error {
+	var v violations
+	v.requireID("id", req.Id)
+	return v.err()
+}
+
+// --- Prescription requests ---
+
+func validateCreatePrescriptionRequest(req *serverpb.CreatePrescriptionRequest) error {
+	var v violations
+	v.requireID("patient_id", req.PatientId)
+	if req.Prescription == nil {
+		v.add("prescription", "is required")
+		return v.err()
+	}
+	if req.Prescription.Id != 0 {
+		v.add("prescription.id", "must not be set; it is assigned by the server")
+	}
+	if req.Prescription.PatientId != 0 && req.Prescription.PatientId != req.PatientId {
+		v.add("prescription.patient_id", "must be unset or equal to patient_id")
+	}
+	v.validatePrescription("prescription", req.Prescription, nil)
+	return v.err()
+}
+
+func validateGetPrescriptionRequest(req *serverpb.GetPrescriptionRequest) error {
+	var v violations
+	v.requireID("id", req.Id)
+	return v.err()
+}
+
+func validateListPrescriptionsForPatientRequest(req *serverpb.ListPrescriptionsForPatientRequest) error {
+	var v violations
+	v.requireID("patient_id", req.PatientId)
+	v.prescriptionListFilter(req.Status, req.PrescribedAfter, req.PrescribedBefore, req.OrderBy, req.PageSize)
+	return v.err()
+}
+
+func validateListPrescriptionsRequest(req *serverpb.ListPrescriptionsRequest) error {
+	var v violations
+	v.prescriptionListFilter(req.Status, req.PrescribedAfter, req.PrescribedBefore, req.OrderBy, req.PageSize)
+	return v.err()
+}
+
+func validateUpdatePrescriptionRequest(req *serverpb.UpdatePrescriptionRequest) error {
+	var v violations
+	if req.Prescription == nil {
+		v.add("prescription", "is required")
+		return v.err()
+	}
+	v.requireID("prescription.id", req.Prescription.Id)
+	v.checkMask(req.UpdateMask, prescriptionMaskColumns)
+	v.validatePrescription("prescription", req.Prescription, maskPaths(req.UpdateMask))
+	return v.err()
+}
+
+func validateDeletePrescriptionRequest(req *serverpb.DeletePrescriptionRequest) error {
+	var v violations
+	v.requireID("id", req.Id)
+	return v.err()
+}
+
+// --- Audit requests ---
+
+func validateListAuditEventsRequest(req *serverpb.ListAuditEventsRequest) error {
+	var v violations
+	if req.StartTime != nil && req.StartTime.CheckValid() != nil {
+		v.add("start_time", "must be a valid timestamp")
+	}
+	if req.EndTime != nil && req.EndTime.CheckValid() != nil {
+		v.add("end_time", "must be a valid timestamp")
+	}
+	if req.StartTime != nil && req.EndTime != nil && !req.StartTime.AsTime().Before(req.EndTime.AsTime()) {
+		v.add("end_time", "must be after start_time")
+	}
+	if req.PageSize < 0 {
+		v.add("page_size", "must not be negative")
+	}
+	return v.err()
+}
+
+// --- Care team requests ---
+
+func validateAddCareTeamMemberRequest(req *serverpb.AddCareTeamMemberRequest) error {
+	var v violations
+	v.requireID("patient_id", req.PatientId)
+	v.required("member", req.Member, maxMemberLen)
+	return v.err()
+}
+
+func validateRemoveCareTeamMemberRequest(req *serverpb.RemoveCareTeamMemberRequest) error {
+	var v violations
+	v.requireID("patient_id", req.PatientId)
+	v.required("member", req.Member, maxMemberLen)
+	return v.err()
+}
+
+func validateListCareTeamRequest(req *serverpb.ListCareTeamRequest) error {
+	var v violations
+	v.requireID("patient_id", req.PatientId)
+	return v.err()
+}
@@ -0,0 +1,250 @@
+// Package config defines the server configuration and loads it from a YAML or
+// JSON file, environment variables and command-line flags.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+)
+
+// Config is the complete server configuration. Each setting can be given in
+// the config file under its yaml/json key path, in the environment variable
+// named by its env tag, or with a flag named by its key path, such as
+// --database.port. Flags override the environment, which overrides the file,
+// which overrides the defaults from Default.
+type Config struct {
+	Server   ServerConfig   `yaml:"server" json:"server"`
+	Database DatabaseConfig `yaml:"database" json:"database"`
+	Auth     AuthConfig     `yaml:"auth" json:"auth"`
+	TLS      TLSConfig      `yaml:"tls" json:"tls"`
+	Tracing  TracingConfig  `yaml:"tracing" json:"tracing"`
+
+	// LogLevel is the minimum level of the JSON log: debug, info, warn or
+	// error. Requests are logged at info, and their messages at debug.
+	LogLevel string `yaml:"log_level" json:"log_level" env:"LOG_LEVEL" usage:"minimum log level: debug, info, warn or error"`
+
+	// PageTokenKey signs page tokens and must be shared by every replica.
+	PageTokenKey Secret `yaml:"page_token_key" json:"page_token_key" env:"PAGE_TOKEN_KEY" usage:"secret used to sign page tokens; random per process if empty"`
+}
+
+// ServerConfig configures the listeners.
+type ServerConfig struct {
+	Mode             string   `yaml:"mode" json:"mode" env:"SERVE_MODE" usage:"split serves gRPC and HTTP on separate ports, single multiplexes them on http_addr"`
+	HTTPAddr         string   `yaml:"http_addr" json:"http_addr" env:"HTTP_ADDR" usage:"listen address of the HTTP gateway"`
+	GRPCAddr         string   `yaml:"grpc_addr" json:"grpc_addr" env:"GRPC_ADDR" usage:"listen address of the gRPC server in split mode"`
+	ShutdownTimeout  Duration `yaml:"shutdown_timeout" json:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" usage:"how long in-flight requests may take to finish on shutdown"`
+	ReadinessTimeout Duration `yaml:"readiness_timeout" json:"readiness_timeout" env:"READINESS_TIMEOUT" usage:"how long the readiness check waits for a database ping"`
+}
+
+// DatabaseConfig configures the database connection pool. Postgres is
+// reached through DSN if set, or else the host, port, user, password, name and
+// sslmode; SQLite through DSN, a file path.
+type DatabaseConfig struct {
+	Driver          string   `yaml:"driver" json:"driver" env:"DB_DRIVER" usage:"database driver: postgres or sqlite"`
+	DSN             Secret   `yaml:"dsn" json:"dsn" env:"DB_DSN" usage:"data source name: a postgres:// URL, or for sqlite a file path or :memory:"`
+	Host            string   `yaml:"host" json:"host" env:"DB_HOST" usage:"database host"`
+	Port            int      `yaml:"port" json:"port" env:"DB_PORT" usage:"database port"`
+	User            string   `yaml:"user" json:"user" env:"DB_USER" usage:"database user"`
+	Password        Secret   `yaml:"password" json:"password" env:"DB_PASSWORD" usage:"database password"`
+	Name            string   `yaml:"name" json:"name" env:"DB_NAME" usage:"database name"`
+	SSLMode         string   `yaml:"sslmode" json:"sslmode" env:"DB_SSLMODE" usage:"Postgres sslmode, e.g. disable or require"`
+	MaxOpenConns    int      `yaml:"max_open_conns" json:"max_open_conns" env:"DB_MAX_OPEN_CONNS" usage:"maximum open connections; 0 means unlimited"`
+	MaxIdleConns    int      `yaml:"max_idle_conns" json:"max_idle_conns" env:"DB_MAX_IDLE_CONNS" usage:"maximum idle connections"`
+	ConnMaxLifetime Duration `yaml:"conn_max_lifetime" json:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME" usage:"maximum lifetime of a connection; 0 means unlimited"`
+	LogLevel        string   `yaml:"log_level" json:"log_level" env:"DB_LOG_LEVEL" usage:"SQL logged: silent for none, error for failures, warn also for slow statements, info for all"`
+	AutoMigrate     bool     `yaml:"auto_migrate" json:"auto_migrate" env:"DB_AUTO_MIGRATE" usage:"apply pending schema migrations at startup; if false, refuse to start until migrate up has run"`
+	ReadTimeout     Duration `yaml:"read_timeout" json:"read_timeout" env:"DB_READ_TIMEOUT" usage:"how long a lookup or listing may run; 0 means unlimited"`
+	WriteTimeout    Duration `yaml:"write_timeout" json:"write_timeout" env:"DB_WRITE_TIMEOUT" usage:"how long an insert, update or delete may run; 0 means unlimited"`
+	SearchTimeout   Duration `yaml:"search_timeout" json:"search_timeout" env:"DB_SEARCH_TIMEOUT" usage:"how long a search, count or audit trail query may run; 0 means unlimited"`
+}
+
+// AuthConfig configures authentication and authorization.
+type AuthConfig struct {
+	Disabled    bool     `yaml:"disabled" json:"disabled" env:"AUTH_DISABLED" usage:"serve without authentication, for local development only"`
+	JWKSFile    string   `yaml:"jwks_file" json:"jwks_file" env:"AUTH_JWKS_FILE" usage:"JSON Web Key Set file verifying bearer tokens"`
+	JWKSURL     string   `yaml:"jwks_url" json:"jwks_url" env:"AUTH_JWKS_URL" usage:"JSON Web Key Set URL verifying bearer tokens"`
+	JWKSRefresh Duration `yaml:"jwks_refresh" json:"jwks_refresh" env:"AUTH_JWKS_REFRESH" usage:"how long a key set fetched from jwks_url is cached"`
+	Issuer      string   `yaml:"issuer" json:"issuer" env:"AUTH_ISSUER" usage:"required iss claim of bearer tokens"`
+	Audience    string   `yaml:"audience" json:"audience" env:"AUTH_AUDIENCE" usage:"required aud claim of bearer tokens"`
+	PolicyFile  string   `yaml:"policy_file" json:"policy_file" env:"AUTHZ_POLICY_FILE" usage:"authorization policy file; the built-in policy if empty"`
+}
+
+// TLSConfig configures TLS on the listeners and the gateway's connection to
+// the gRPC server.
+type TLSConfig struct {
+	GRPC    ListenerTLS `yaml:"grpc" json:"grpc" env:"GRPC"`
+	HTTP    ListenerTLS `yaml:"http" json:"http" env:"HTTP"`
+	Gateway GatewayTLS  `yaml:"gateway" json:"gateway"`
+}
+
+// ListenerTLS configures TLS on one listener. Its environment variables are
+// prefixed with the listener's, e.g. GRPC_TLS_CERT_FILE.
+type ListenerTLS struct {
+	CertFile     string `yaml:"cert_file" json:"cert_file" env:"_TLS_CERT_FILE" usage:"PEM certificate; TLS is off if empty"`
+	KeyFile      string `yaml:"key_file" json:"key_file" env:"_TLS_KEY_FILE" usage:"PEM private key"`
+	ClientCAFile string `yaml:"client_ca_file" json:"client_ca_file" env:"_TLS_CLIENT_CA_FILE" usage:"PEM CAs verifying client certificates; enables mutual TLS"`
+	ClientAuth   string `yaml:"client_auth" json:"client_auth" env:"_TLS_CLIENT_AUTH" usage:"with mutual TLS, require or optional client certificates"`
+}
+
+// Enabled reports whether TLS is configured.
+func (t ListenerTLS) Enabled() bool {
+	return t.CertFile != "" || t.KeyFile != ""
+}
+
+// GatewayTLS configures how the gateway verifies and authenticates to the
+// gRPC server when it serves TLS.
+type GatewayTLS struct {
+	CAFile     string `yaml:"ca_file" json:"ca_file" env:"GATEWAY_TLS_CA_FILE" usage:"PEM CAs verifying the gRPC server; system roots if empty"`
+	CertFile   string `yaml:"cert_file" json:"cert_file" env:"GATEWAY_TLS_CERT_FILE" usage:"PEM client certificate presented to the gRPC server"`
+	KeyFile    string `yaml:"key_file" json:"key_file" env:"GATEWAY_TLS_KEY_FILE" usage:"PEM private key of the client certificate"`
+	ServerName string `yaml:"server_name" json:"server_name" env:"GATEWAY_TLS_SERVER_NAME" usage:"name the gRPC server certificate is checked against"`
+}
+
+// TracingConfig configures OpenTelemetry tracing.
+type TracingConfig struct {
+	Exporter    string  `yaml:"exporter" json:"exporter" env:"TRACING_EXPORTER" usage:"where spans are sent: none, otlp or file"`
+	Endpoint    string  `yaml:"endpoint" json:"endpoint" env:"TRACING_OTLP_ENDPOINT" usage:"host:port of the OTLP/gRPC collector"`
+	Insecure    bool    `yaml:"insecure" json:"insecure" env:"TRACING_OTLP_INSECURE" usage:"send spans to the collector without TLS"`
+	File        string  `yaml:"file" json:"file" env:"TRACING_FILE" usage:"file the spans are appended to as JSON"`
+	SampleRatio float64 `yaml:"sample_ratio" json:"sample_ratio" env:"TRACING_SAMPLE_RATIO" usage:"fraction of new traces recorded; callers' sampling decisions are followed"`
+	ServiceName string  `yaml:"service_name" json:"service_name" env:"OTEL_SERVICE_NAME" usage:"service name reported in traces"`
+}
+
+// Default returns the configuration used for settings that are not given.
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Mode:             "split",
+			HTTPAddr:         ":8080",
+			GRPCAddr:         ":9090",
+			ShutdownTimeout:  Duration(25 * time.Second),
+			ReadinessTimeout: Duration(2 * time.Second),
+		},
+		Database: DatabaseConfig{
+			Driver:          "postgres",
+			Host:            "localhost",
+			Port:            5432,
+			User:            "postgres",
+			Password:        "postgres",
+			Name:            "playground",
+			SSLMode:         "disable",
+			MaxOpenConns:    25,
+			MaxIdleConns:    25,
+			ConnMaxLifetime: Duration(5 * time.Minute),
+			LogLevel:        "warn",
+			AutoMigrate:     true,
+			ReadTimeout:     Duration(5 * time.Second),
+			WriteTimeout:    Duration(5 * time.Second),
+			SearchTimeout:   Duration(15 * time.Second),
+		},
+		Auth: AuthConfig{
+			JWKSRefresh: Duration(time.Hour),
+		},
+		TLS: TLSConfig{
+			GRPC:    ListenerTLS{ClientAuth: "require"},
+			HTTP:    ListenerTLS{ClientAuth: "require"},
+			Gateway: GatewayTLS{ServerName: "localhost"},
+		},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			Endpoint:    "localhost:4317",
+			SampleRatio: 1,
+			ServiceName: "playground",
+		},
+		LogLevel: "info",
+	}
+}
+
+// Validate checks the configuration and returns every problem found.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Server.Mode == "split" || c.Server.Mode == "single", "server.mode must be split or single, not %q", c.Server.Mode)
+	check(validAddr(c.Server.HTTPAddr), "server.http_addr must be host:port, not %q", c.Server.HTTPAddr)
+	if c.Server.Mode == "split" {
+		check(validAddr(c.Server.GRPCAddr), "server.grpc_addr must be host:port, not %q", c.Server.GRPCAddr)
+	} else if c.Server.Mode == "single" {
+		// The shared listener takes its TLS from tls.grpc, and the gateway
+		// calls the service in-process
+		check(!c.TLS.HTTP.Enabled() && c.TLS.HTTP.ClientCAFile == "", "tls.http does not apply in single mode; configure the shared listener with tls.grpc")
+		check(c.TLS.Gateway.CAFile == "" && c.TLS.Gateway.CertFile == "", "tls.gateway does not apply in single mode")
+	}
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout must be positive")
+	check(c.Server.ReadinessTimeout > 0, "server.readiness_timeout must be positive")
+
+	switch c.Database.Driver {
+	case "postgres":
+		if c.Database.DSN == "" {
+			check(c.Database.Host != "", "database.host is required")
+			check(c.Database.Port > 0 && c.Database.Port < 65536, "database.port must be between 1 and 65535, not %d", c.Database.Port)
+			check(c.Database.Name != "", "database.name is required")
+		}
+	case "sqlite":
+		check(c.Database.DSN != "", "database.dsn is required for sqlite")
+	default:
+		check(false, "database.driver must be postgres or sqlite, not %q", c.Database.Driver)
+	}
+	check(c.Database.MaxOpenConns >= 0, "database.max_open_conns must not be negative")
+	check(c.Database.MaxIdleConns >= 0, "database.max_idle_conns must not be negative")
+	check(c.Database.ConnMaxLifetime >= 0, "database.conn_max_lifetime must not be negative")
+	check(c.Database.ReadTimeout >= 0, "database.read_timeout must not be negative")
+	check(c.Database.WriteTimeout >= 0, "database.write_timeout must not be negative")
+	check(c.Database.SearchTimeout >= 0, "database.search_timeout must not be negative")
+	switch c.Database.LogLevel {
+	case "silent", "error", "warn", "info":
+	default:
+		check(false, "database.log_level must be silent, error, warn or info, not %q", c.Database.LogLevel)
+	}
+
+	if !c.Auth.Disabled {
+		check(c.Auth.JWKSFile != "" || c.Auth.JWKSURL != "", "auth.jwks_file or auth.jwks_url is required unless auth.disabled is set")
+		check(c.Auth.JWKSFile == "" || c.Auth.JWKSURL == "", "auth.jwks_file and auth.jwks_url are mutually exclusive")
+		check(c.Auth.JWKSRefresh > 0, "auth.jwks_refresh must be positive")
+	}
+
+	for name, t := range map[string]ListenerTLS{"tls.grpc": c.TLS.GRPC, "tls.http": c.TLS.HTTP} {
+		check((t.CertFile == "") == (t.KeyFile == ""), "%s.cert_file and %s.key_file must be set together", name, name)
+		check(t.ClientAuth == "require" || t.ClientAuth == "optional", "%s.client_auth must be require or optional, not %q", name, t.ClientAuth)
+		check(t.ClientCAFile == "" || t.Enabled(), "%s.client_ca_file needs %s.cert_file", name, name)
+	}
+	g := c.TLS.Gateway
+	check((g.CertFile == "") == (g.KeyFile == ""), "tls.gateway.cert_file and tls.gateway.key_file must be set together")
+
+	switch c.LogLevel {
+	case "debug", "info", "warn", "error":
+	default:
+		check(false, "log_level must be debug, info, warn or error, not %q", c.LogLevel)
+	}
+
+	switch c.Tracing.Exporter {
+	case "none":
+	case "otlp":
+		check(c.Tracing.Endpoint != "", "tracing.endpoint is required with the otlp exporter")
+	case "file":
+		check(c.Tracing.File != "", "tracing.file is required with the file exporter")
+	default:
+		check(false, "tracing.exporter must be none, otlp or file, not %q", c.Tracing.Exporter)
+	}
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio must be between 0 and 1, not %g", c.Tracing.SampleRatio)
+	check(c.Tracing.ServiceName != "", "tracing.service_name is required")
+
+	return errors.Join(errs...)
+}
+
+// validAddr reports whether addr is a listen address such as ":8080".
+func validAddr(addr string) bool {
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	n, err := strconv.Atoi(port)
+	return err == nil && n > 0 && n < 65536
+}
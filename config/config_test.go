@@ -0,0 +1,211 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// env returns a getenv reading vars.
+func env(vars map[string]string) func(string) string {
+	return func(name string) string { return vars[name] }
+}
+
+// writeFile writes a config file named name and returns its path.
+func writeFile(t *testing.T, name, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	file := writeFile(t, "config.yaml", "auth:\n  disabled: true\ndatabase:\n  port: 1111\n  name: from-file\nlog_level: debug\n")
+
+	tests := []struct {
+		name string
+		args []string
+		env  map[string]string
+		port int
+		db   string
+	}{
+		{name: "defaults", env: map[string]string{"AUTH_DISABLED": "true"}, port: 5432, db: "playground"},
+		{name: "file", args: []string{"--config", file}, port: 1111, db: "from-file"},
+		{name: "file named by the environment", env: map[string]string{"CONFIG_FILE": file}, port: 1111, db: "from-file"},
+		{name: "environment over file", args: []string{"--config", file}, env: map[string]string{"DB_PORT": "2222"}, port: 2222, db: "from-file"},
+		{name: "empty environment is ignored", args: []string{"--config", file}, env: map[string]string{"DB_PORT": ""}, port: 1111, db: "from-file"},
+		{name: "flag over environment", args: []string{"--config", file, "--database.port=3333"}, env: map[string]string{"DB_PORT": "2222"}, port: 3333, db: "from-file"},
+		{name: "last flag wins", args: []string{"--config", file, "--database.port", "3333", "--database.port", "4444", "--database.name", "from-flag"}, port: 4444, db: "from-flag"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, _, err := Load("test", tt.args, env(tt.env))
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if cfg.Database.Port != tt.port || cfg.Database.Name != tt.db {
+				t.Errorf("Load gave database %d/%s, want %d/%s", cfg.Database.Port, cfg.Database.Name, tt.port, tt.db)
+			}
+		})
+	}
+}
+
+func TestLoadSettings(t *testing.T) {
+	file := writeFile(t, "config.json", `{"auth": {"disabled": true}, "server": {"shutdown_timeout": "1m"}, "tls": {"grpc": {"cert_file": "c.pem"}}}`)
+	cfg, printConfig, err := Load("test", []string{"--config", file, "--print-config", "--tracing.sample_ratio=0.25"}, env(map[string]string{
+		"GRPC_TLS_KEY_FILE": "k.pem",
+		"DB_AUTO_MIGRATE":   "false",
+	}))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if !printConfig {
+		t.Error("Load did not report --print-config")
+	}
+	if cfg.Server.ShutdownTimeout != Duration(time.Minute) {
+		t.Errorf("server.shutdown_timeout = %v, want 1m", time.Duration(cfg.Server.ShutdownTimeout))
+	}
+	if cfg.TLS.GRPC.CertFile != "c.pem" || cfg.TLS.GRPC.KeyFile != "k.pem" {
+		t.Errorf("tls.grpc = %+v, want the file's cert and the environment's key", cfg.TLS.GRPC)
+	}
+	if cfg.Database.AutoMigrate || cfg.Tracing.SampleRatio != 0.25 {
+		t.Errorf("database.auto_migrate = %v and tracing.sample_ratio = %v", cfg.Database.AutoMigrate, cfg.Tracing.SampleRatio)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		env  map[string]string
+		file string
+		err  string
+	}{
+		{name: "unknown YAML key", file: "database:\n  prot: 1\n", err: "field prot not found"},
+		{name: "unknown JSON key", file: `{"database": {"prot": 1}}`, err: `unknown field "prot"`},
+		{name: "bad YAML value", file: "database:\n  port: many\n", err: "config file"},
+		{name: "missing file", args: []string{"--config", "/nonexistent/config.yaml"}, err: "config file"},
+		{name: "bad environment value", env: map[string]string{"DB_PORT": "many"}, err: "DB_PORT: must be an integer"},
+		{name: "bad flag value", args: []string{"--server.readiness_timeout=5"}, err: "must be a duration"},
+		{name: "unknown flag", args: []string{"--database.prot=1"}, err: "flag provided but not defined"},
+		{name: "argument", args: []string{"serve"}, err: `unexpected argument "serve"`},
+		{name: "invalid", env: map[string]string{"DB_DRIVER": "mysql"}, err: "database.driver must be postgres or sqlite"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := tt.args
+			if tt.file != "" {
+				name := "config.yaml"
+				if strings.HasPrefix(tt.file, "{") {
+					name = "config.json"
+				}
+				args = append(args, "--config", writeFile(t, name, tt.file))
+			}
+			vars := map[string]string{"AUTH_DISABLED": "true"}
+			for k, v := range tt.env {
+				vars[k] = v
+			}
+			_, _, err := Load("test", args, env(vars))
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("Load = %v, want error %q", err, tt.err)
+			}
+		})
+	}
+
+	// Every bad environment variable is reported at once
+	_, _, err := Load("test", nil, env(map[string]string{"DB_PORT": "many", "SHUTDOWN_TIMEOUT": "soon"}))
+	if err == nil || !strings.Contains(err.Error(), "DB_PORT") || !strings.Contains(err.Error(), "SHUTDOWN_TIMEOUT") {
+		t.Errorf("Load = %v, want both variables reported", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(*Config)
+		err  []string
+	}{
+		{name: "defaults without auth", edit: func(c *Config) {}, err: []string{"auth.jwks_file or auth.jwks_url is required"}},
+		{name: "auth disabled", edit: func(c *Config) { c.Auth.Disabled = true }},
+		{name: "JWKS file", edit: func(c *Config) { c.Auth.JWKSFile = "keys.json" }},
+		{name: "both JWKS sources", edit: func(c *Config) { c.Auth.JWKSFile, c.Auth.JWKSURL = "keys.json", "https://idp/keys" }, err: []string{"mutually exclusive"}},
+		{name: "sqlite without DSN", edit: func(c *Config) { c.Auth.Disabled = true; c.Database.Driver = "sqlite" }, err: []string{"database.dsn is required for sqlite"}},
+		{name: "postgres DSN skips host checks", edit: func(c *Config) {
+			c.Auth.Disabled = true
+			c.Database.DSN, c.Database.Host, c.Database.Port = "postgres://db/playground", "", 0
+		}},
+		{name: "bad mode", edit: func(c *Config) { c.Auth.Disabled = true; c.Server.Mode = "both" }, err: []string{"server.mode must be split or single"}},
+		{name: "bad addresses", edit: func(c *Config) { c.Auth.Disabled = true; c.Server.HTTPAddr, c.Server.GRPCAddr = "8080", ":0" }, err: []string{"server.http_addr", "server.grpc_addr"}},
+		{name: "single mode ignores grpc_addr", edit: func(c *Config) { c.Auth.Disabled = true; c.Server.Mode, c.Server.GRPCAddr = "single", "" }},
+		{name: "single mode with HTTP TLS", edit: func(c *Config) {
+			c.Auth.Disabled = true
+			c.Server.Mode = "single"
+			c.TLS.HTTP.CertFile, c.TLS.HTTP.KeyFile = "c.pem", "k.pem"
+		}, err: []string{"tls.http does not apply in single mode"}},
+		{name: "single mode with gateway TLS", edit: func(c *Config) { c.Auth.Disabled = true; c.Server.Mode, c.TLS.Gateway.CAFile = "single", "ca.pem" }, err: []string{"tls.gateway does not apply"}},
+		{name: "single mode with gRPC TLS", edit: func(c *Config) {
+			c.Auth.Disabled = true
+			c.Server.Mode = "single"
+			c.TLS.GRPC.CertFile, c.TLS.GRPC.KeyFile, c.TLS.GRPC.ClientCAFile = "c.pem", "k.pem", "ca.pem"
+		}},
+		{name: "half a key pair", edit: func(c *Config) { c.Auth.Disabled = true; c.TLS.GRPC.CertFile = "c.pem" }, err: []string{"tls.grpc.cert_file and tls.grpc.key_file must be set together"}},
+		{name: "client CA without TLS", edit: func(c *Config) { c.Auth.Disabled = true; c.TLS.HTTP.ClientCAFile = "ca.pem" }, err: []string{"tls.http.client_ca_file needs tls.http.cert_file"}},
+		{name: "bad client auth", edit: func(c *Config) { c.Auth.Disabled = true; c.TLS.GRPC.ClientAuth = "maybe" }, err: []string{"tls.grpc.client_auth"}},
+		{name: "negative timeouts", edit: func(c *Config) {
+			c.Auth.Disabled = true
+			c.Database.ReadTimeout, c.Server.ShutdownTimeout = -1, 0
+		}, err: []string{"database.read_timeout must not be negative", "server.shutdown_timeout must be positive"}},
+		{name: "tracing", edit: func(c *Config) {
+			c.Auth.Disabled = true
+			c.Tracing.Exporter, c.Tracing.SampleRatio = "file", 2
+		}, err: []string{"tracing.file is required", "tracing.sample_ratio must be between 0 and 1"}},
+		{name: "log levels", edit: func(c *Config) { c.Auth.Disabled = true; c.LogLevel, c.Database.LogLevel = "trace", "debug" }, err: []string{"log_level must be debug", "database.log_level must be silent"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			tt.edit(&cfg)
+			err := cfg.Validate()
+			if len(tt.err) == 0 {
+				if err != nil {
+					t.Fatalf("Validate: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Validate succeeded, want errors %q", tt.err)
+			}
+			for _, want := range tt.err {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Validate = %v, want %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestPrintRedactsSecrets(t *testing.T) {
+	cfg := Default()
+	cfg.Database.DSN = "postgres://app:hunter2@db/playground"
+	cfg.Database.Password = "hunter2"
+	cfg.PageTokenKey = ""
+
+	var buf bytes.Buffer
+	if err := cfg.Print(&buf); err != nil {
+		t.Fatalf("Print: %v", err)
+	}
+	out := buf.String()
+	if strings.Contains(out, "hunter2") {
+		t.Errorf("Print leaked a secret:\n%s", out)
+	}
+	for _, want := range []string{"dsn: REDACTED", "password: REDACTED", "page_token_key: \"\"", "port: 5432", "shutdown_timeout: 25s"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Print output lacks %q:\n%s", want, out)
+		}
+	}
+}
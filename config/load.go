@@ -0,0 +1,243 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Duration is a time.Duration written as a string such as "30s" in config
+// files and the environment.
+type Duration time.Duration
+
+// UnmarshalText parses a duration such as "30s".
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return fmt.Errorf("must be a duration such as 30s, not %q", text)
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// MarshalText formats d as a string such as "30s".
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// Secret is a string that is redacted whenever the configuration is printed.
+type Secret string
+
+// redacted replaces secrets when the configuration is printed.
+const redacted = "REDACTED"
+
+// MarshalText returns a placeholder for non-empty secrets.
+func (s Secret) MarshalText() ([]byte, error) {
+	if s == "" {
+		return nil, nil
+	}
+	return []byte(redacted), nil
+}
+
+// Load returns the configuration given by args, the command-line arguments
+// without the program name, and the environment read with getenv. The config
+// file is named by the --config flag or the CONFIG_FILE variable. Environment
+// variables that are set but empty are ignored. printConfig reports whether
+// --print-config was given. The configuration is validated, and every invalid
+// setting is reported in the error.
+func Load(name string, args []string, getenv func(string) string) (cfg Config, printConfig bool, err error) {
+	cfg = Default()
+	settings := fields(&cfg)
+
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	configFile := fs.String("config", getenv("CONFIG_FILE"), "YAML or JSON config file (env CONFIG_FILE)")
+	fs.BoolVar(&printConfig, "print-config", false, "print the configuration with secrets redacted and exit")
+	var given []flagSetting
+	for _, s := range settings {
+		usage := s.usage
+		if s.env != "" {
+			usage += fmt.Sprintf(" (env %s)", s.env)
+		}
+		fs.Var(&flagValue{setting: s, given: &given}, s.path, usage)
+	}
+	if err := fs.Parse(args); err != nil {
+		return cfg, false, err
+	}
+	if fs.NArg() > 0 {
+		return cfg, false, fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+
+	if *configFile != "" {
+		if err := loadFile(&cfg, *configFile); err != nil {
+			return cfg, false, err
+		}
+	}
+
+	var errs []error
+	for _, s := range settings {
+		if s.env == "" {
+			continue
+		}
+		if value := getenv(s.env); value != "" {
+			if err := setValue(s.value, value); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", s.env, err))
+			}
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return cfg, false, err
+	}
+
+	// Flags were checked when parsed, and are applied last to take precedence
+	for _, g := range given {
+		setValue(g.setting.value, g.value)
+	}
+
+	return cfg, printConfig, cfg.Validate()
+}
+
+// Print writes the configuration as YAML, with secrets redacted.
+func (c *Config) Print(w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(c); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// loadFile reads the config file at path into cfg. Files ending in .json are
+// read as JSON and others as YAML. Unknown keys are rejected so that typos do
+// not go unnoticed.
+func loadFile(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config file: %w", err)
+	}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(cfg)
+	} else {
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(cfg)
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// setting is one configurable value of a Config.
+type setting struct {
+	path  string // e.g. database.port, used in files and as the flag name
+	env   string
+	usage string
+	value reflect.Value
+}
+
+// fields lists the settings of cfg, in declaration order.
+func fields(cfg *Config) []setting {
+	var settings []setting
+	var walk func(v reflect.Value, path, env string)
+	walk = func(v reflect.Value, path, env string) {
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			key, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+			if path != "" {
+				key = path + "." + key
+			}
+			if f.Type.Kind() == reflect.Struct {
+				walk(v.Field(i), key, env+f.Tag.Get("env"))
+				continue
+			}
+			var name string
+			if tag := f.Tag.Get("env"); tag != "" {
+				name = env + tag
+			}
+			settings = append(settings, setting{path: key, env: name, usage: f.Tag.Get("usage"), value: v.Field(i)})
+		}
+	}
+	walk(reflect.ValueOf(cfg).Elem(), "", "")
+	return settings
+}
+
+// setValue parses s into the setting v.
+func setValue(v reflect.Value, s string) error {
+	if u, ok := v.Addr().Interface().(interface{ UnmarshalText([]byte) error }); ok {
+		return u.UnmarshalText([]byte(s))
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Int:
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return fmt.Errorf("must be an integer, not %q", s)
+		}
+		v.SetInt(int64(n))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return fmt.Errorf("must be a number, not %q", s)
+		}
+		v.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("must be true or false, not %q", s)
+		}
+		v.SetBool(b)
+	default:
+		return fmt.Errorf("unsupported setting type %s", v.Type())
+	}
+	return nil
+}
+
+// flagSetting is a flag given on the command line.
+type flagSetting struct {
+	setting setting
+	value   string
+}
+
+// flagValue records a flag for Load to apply after the file and environment.
+type flagValue struct {
+	setting setting
+	given   *[]flagSetting
+}
+
+func (f *flagValue) String() string {
+	if f == nil || !f.setting.value.IsValid() || f.setting.value.IsZero() {
+		return ""
+	}
+	if m, ok := f.setting.value.Interface().(interface{ MarshalText() ([]byte, error) }); ok {
+		text, _ := m.MarshalText()
+		return string(text)
+	}
+	return fmt.Sprint(f.setting.value.Interface())
+}
+
+func (f *flagValue) Set(s string) error {
+	if err := setValue(reflect.New(f.setting.value.Type()).Elem(), s); err != nil {
+		return err
+	}
+	*f.given = append(*f.given, flagSetting{setting: f.setting, value: s})
+	return nil
+}
+
+func (f *flagValue) IsBoolFlag() bool {
+	return f.setting.value.Kind() == reflect.Bool
+}
@@ -0,0 +1,86 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AuditEvent is an append-only record of one call to the API: who made it,
+// which records it touched and how it ended. Rows are only ever inserted; the
+// protect_audit_tables migration rejects updates and deletes.
+type AuditEvent struct {
+	ID        uint      `gorm:"primaryKey"`
+	CreatedAt time.Time `gorm:"not null;index"`
+
+	// Actor identifies the caller.
+	Actor string `gorm:"size:255;not null;index"`
+	// Method is the full gRPC method name, e.g. /serverpb.Api/GetPatient.
+	Method string `gorm:"size:255;not null"`
+	// Resources lists the records touched, space separated, e.g.
+	// "patients/1 prescriptions/7".
+	Resources string `gorm:"type:text"`
+	// Outcome is the gRPC status code name, e.g. OK or NotFound.
+	Outcome string `gorm:"size:50;not null"`
+	// Message is the error message for failed calls.
+	Message string `gorm:"type:text"`
+	// Changes is a JSON object mapping each field changed by a write to its
+	// "before" and "after" values.
+	Changes string `gorm:"type:text"`
+
+	// Patients indexes the event by every patient it concerns.
+	Patients []AuditEventPatient
+}
+
+// AuditEventPatient links an AuditEvent to a patient it concerns. Patient IDs
+// are deliberately not foreign keys so that the trail outlives the records.
+type AuditEventPatient struct {
+	AuditEventID uint `gorm:"primaryKey"`
+	PatientID    uint `gorm:"primaryKey;index"`
+}
+
+// AuditSearch describes an audit trail query. Zero-valued filters do not
+// restrict the results, which are returned newest first.
+type AuditSearch struct {
+	PatientID uint
+	Actor     string
+	// Since and Until bound CreatedAt to the half-open range [Since, Until).
+	Since time.Time
+	Until time.Time
+	After *SearchCursor
+	Limit int
+}
+
+// CreateAuditEvent appends an event, and its patient links, to the audit trail.
+func (db *DB) CreateAuditEvent(ctx context.Context, ev *AuditEvent) error {
+	return db.op(ctx, db.Timeouts.Write, func(conn *gorm.DB) error {
+		return conn.Create(ev).Error
+	})
+}
+
+// ListAuditEvents returns the audit events matching s with their patient links,
+// newest first and paged by keyset.
+func (db *DB) ListAuditEvents(ctx context.Context, s AuditSearch) ([]AuditEvent, error) {
+	var events []AuditEvent
+	err := db.op(ctx, db.Timeouts.Search, func(conn *gorm.DB) error {
+		q := conn.Model(&AuditEvent{}).Preload("Patients")
+		if s.PatientID != 0 {
+			q = q.Where("id IN (SELECT audit_event_id FROM audit_event_patients WHERE patient_id = ?)", s.PatientID)
+		}
+		if s.Actor != "" {
+			q = q.Where("actor = ?", s.Actor)
+		}
+		if !s.Since.IsZero() {
+			q = q.Where("created_at >= ?", s.Since)
+		}
+		if !s.Until.IsZero() {
+			q = q.Where("created_at < ?", s.Until)
+		}
+		return keyset(q, "", true, s.After, nil, s.Limit).Find(&events).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
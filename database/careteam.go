@@ -0,0 +1,88 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CareTeamMember records that a caller, identified by the subject of their
+// bearer tokens, is in a patient's care team.
+type CareTeamMember struct {
+	PatientID uint      `gorm:"primaryKey"`
+	Member    string    `gorm:"primaryKey;size:255;index"`
+	CreatedAt time.Time `gorm:"not null"`
+}
+
+// ForCareTeam returns a view of db whose patient and prescription listings only
+// include patients in member's care team. An empty member means no restriction.
+// It shares the connection pool with db.
+func (db *DB) ForCareTeam(member string) Store {
+	view := *db
+	view.careTeam = member
+	return &view
+}
+
+// careTeamScope restricts q to rows whose patientCol is a patient in the care
+// team db is restricted to, if any.
+func (db *DB) careTeamScope(q *gorm.DB, patientCol string) *gorm.DB {
+	if db.careTeam == "" {
+		return q
+	}
+	return q.Where(patientCol+" IN (SELECT patient_id FROM care_team_members WHERE member = ?)", db.careTeam)
+}
+
+// IsCareTeamMember reports whether member is in the care team of the patient.
+func (db *DB) IsCareTeamMember(ctx context.Context, patientID uint, member string) (bool, error) {
+	var n int64
+	err := db.op(ctx, db.Timeouts.Read, func(conn *gorm.DB) error {
+		return conn.Model(&CareTeamMember{}).Where("patient_id = ? AND member = ?", patientID, member).Count(&n).Error
+	})
+	return n > 0, err
+}
+
+// AddCareTeamMember adds member to the care team of the patient, doing nothing
+// if they already are, in one transaction with the check that the patient
+// exists. It returns gorm.ErrRecordNotFound when the patient does not exist or
+// is deleted.
+func (db *DB) AddCareTeamMember(ctx context.Context, patientID uint, member string) (*CareTeamMember, error) {
+	var m *CareTeamMember
+	err := db.transaction(ctx, func(tx *DB) error {
+		if err := tx.CheckPatientExists(ctx, patientID); err != nil {
+			return err
+		}
+		m = &CareTeamMember{PatientID: patientID, Member: member}
+		return tx.op(ctx, db.Timeouts.Write, func(conn *gorm.DB) error {
+			if err := conn.Clauses(clause.OnConflict{DoNothing: true}).Create(m).Error; err != nil {
+				return err
+			}
+			return conn.Where("patient_id = ? AND member = ?", patientID, member).First(m).Error
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// RemoveCareTeamMember removes member from the care team of the patient. It
+// returns gorm.ErrRecordNotFound when they are not in it.
+func (db *DB) RemoveCareTeamMember(ctx context.Context, patientID uint, member string) error {
+	return db.op(ctx, db.Timeouts.Write, func(conn *gorm.DB) error {
+		return affectedOne(conn.Where("patient_id = ? AND member = ?", patientID, member).Delete(&CareTeamMember{}))
+	})
+}
+
+// ListCareTeam returns the care team of the patient, oldest member first.
+func (db *DB) ListCareTeam(ctx context.Context, patientID uint) ([]CareTeamMember, error) {
+	var members []CareTeamMember
+	err := db.op(ctx, db.Timeouts.Read, func(conn *gorm.DB) error {
+		return conn.Where("patient_id = ?", patientID).Order("created_at, member").Find(&members).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return members, nil
+}
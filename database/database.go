@@ -1,24 +1,46 @@
 package database
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 
+	"github.com/glebarez/sqlite"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
 
 // DB is a thin wrapper around a *gorm.DB providing convenience read/write helpers
-// and lifecycle management for a Postgres connection.
+// and lifecycle management for a Postgres or SQLite connection.
 type DB struct {
 	Conn *gorm.DB
+	// Timeouts bounds each operation of the Store methods.
+	Timeouts Timeouts
+
+	// careTeam, if set, restricts listings to this member's patients; see
+	// ForCareTeam.
+	careTeam string
+}
+
+// Timeouts bound how long each kind of Store operation may run, on top of
+// any deadline of the caller's context. Zero means no bound of its own.
+type Timeouts struct {
+	// Read bounds lookups and plain listings.
+	Read time.Duration
+	// Write bounds inserts, updates and deletes.
+	Write time.Duration
+	// Search bounds searches, counts and audit trail queries, which may scan.
+	Search time.Duration
 }
 
 // NewPostgres creates a new gorm DB connection to Postgres using the provided DSN
-// and connection pool settings.
-func NewPostgres(dsn string, maxOpenConns, maxIdleConns int, connMaxLifetime time.Duration, logLevel logger.LogLevel) (*DB, error) {
+// and connection pool settings, logging statements to log.
+func NewPostgres(dsn string, maxOpenConns, maxIdleConns int, connMaxLifetime time.Duration, log logger.Interface) (*DB, error) {
 	cfg := &gorm.Config{
-		Logger: logger.Default.LogMode(logLevel),
+		Logger: log,
 	}
 
 	gdb, err := gorm.Open(postgres.Open(dsn), cfg)
@@ -38,6 +60,74 @@ func NewPostgres(dsn string, maxOpenConns, maxIdleConns int, connMaxLifetime tim
 	return &DB{Conn: gdb}, nil
 }
 
+// sqlitePragmas are set on every SQLite connection: foreign keys are enforced
+// as in Postgres, and writers wait for each other instead of failing.
+var sqlitePragmas = []string{"foreign_keys(1)", "busy_timeout(5000)", "journal_mode(WAL)"}
+
+// NewSQLite opens the SQLite database in the file named by dsn, which may carry
+// query parameters of its own, or ":memory:" for a database that lives as long
+// as db. SQLite allows one writer at a time, so the pool holds one connection.
+// Times are stored in UTC so that they compare correctly as text.
+func NewSQLite(dsn string, log logger.Interface) (*DB, error) {
+	sep := "?"
+	if strings.Contains(dsn, "?") {
+		sep = "&"
+	}
+	for _, pragma := range sqlitePragmas {
+		dsn += sep + "_pragma=" + pragma
+		sep = "&"
+	}
+
+	cfg := &gorm.Config{
+		Logger:  log,
+		NowFunc: func() time.Time { return time.Now().UTC() },
+	}
+	gdb, err := gorm.Open(sqlite.Open(dsn), cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := gdb.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(1)
+	sqlDB.SetMaxIdleConns(1)
+	sqlDB.SetConnMaxLifetime(0)
+
+	return &DB{Conn: gdb}, nil
+}
+
+// isSQLite reports whether db is a SQLite database.
+func (db *DB) isSQLite() bool {
+	return db.Conn.Dialector.Name() == "sqlite"
+}
+
+// WithDeleted returns a view of db whose queries also see soft-deleted rows.
+// It shares the connection pool with db.
+func (db *DB) WithDeleted() Store {
+	view := *db
+	view.Conn = db.Conn.Unscoped()
+	return &view
+}
+
+// op runs fn on db's connection under ctx, bounded by timeout, so that the
+// statements are cancelled with ctx and traced as part of its span. When ctx
+// ends first the error is ctx's, whatever the driver made of the interruption,
+// so that a timeout always reads as context.DeadlineExceeded.
+func (db *DB) op(ctx context.Context, timeout time.Duration, fn func(conn *gorm.DB) error) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	err := fn(db.Conn.WithContext(ctx))
+	if ctxErr := ctx.Err(); err != nil && ctxErr != nil && !errors.Is(err, ctxErr) {
+		err = fmt.Errorf("%w: %v", ctxErr, err)
+	}
+	return err
+}
+
 // Close closes the underlying sql.DB connection pool.
 func (db *DB) Close() error {
 	sqlDB, err := db.Conn.DB()
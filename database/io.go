@@ -1,104 +1,229 @@
 package database
 
-// High-level I/O helpers built on top of the DB wrapper and GORM models.
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// High-level I/O helpers built on top of the DB wrapper and GORM models. Each
+// runs under the caller's context, bounded by the timeout for its kind of
+// operation; see Timeouts.
 
 // GetPatientByID returns a patient with preloaded prescriptions.
-func (db *DB) GetPatientByID(id uint) (*Patient, error) {
+func (db *DB) GetPatientByID(ctx context.Context, id uint) (*Patient, error) {
 	var p Patient
-	if err := db.Conn.Preload("Prescriptions").First(&p, id).Error; err != nil {
+	err := db.op(ctx, db.Timeouts.Read, func(conn *gorm.DB) error {
+		return conn.Preload("Prescriptions").First(&p, id).Error
+	})
+	if err != nil {
 		return nil, err
 	}
 	return &p, nil
 }
 
-// ListPatients returns a slice of patients with basic pagination support.
-// Use limit=0 to return all (careful for large tables).
-func (db *DB) ListPatients(limit, offset int) ([]Patient, error) {
+// ListPatientsAfter returns up to limit patients ordered by descending ID. When
+// afterID is non-zero only patients with a smaller ID are returned, which gives
+// stable keyset pagination under concurrent inserts. offset skips rows after
+// the cursor and exists only for legacy offset-based callers.
+func (db *DB) ListPatientsAfter(ctx context.Context, afterID uint, offset, limit int) ([]Patient, error) {
 	var patients []Patient
-	q := db.Conn.Order("id DESC")
-	if limit > 0 {
-		q = q.Limit(limit).Offset(offset)
-	}
-	if err := q.Find(&patients).Error; err != nil {
+	err := db.op(ctx, db.Timeouts.Read, func(conn *gorm.DB) error {
+		q := db.careTeamScope(conn.Order("id DESC").Limit(limit), "id")
+		if afterID > 0 {
+			q = q.Where("id < ?", afterID)
+		}
+		if offset > 0 {
+			q = q.Offset(offset)
+		}
+		return q.Find(&patients).Error
+	})
+	if err != nil {
 		return nil, err
 	}
 	return patients, nil
 }
 
+// CountPatients returns the exact number of patients.
+func (db *DB) CountPatients(ctx context.Context) (int64, error) {
+	var n int64
+	err := db.op(ctx, db.Timeouts.Search, func(conn *gorm.DB) error {
+		return db.careTeamScope(conn.Model(&Patient{}), "id").Count(&n).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// EstimatePatientCount returns the planner's row estimate for the patients table,
+// which is cheap on large tables but only as fresh as the last ANALYZE. It falls
+// back to an exact count when no statistics are available, the view is
+// restricted to a care team or the database is SQLite, which keeps none.
+func (db *DB) EstimatePatientCount(ctx context.Context) (int64, error) {
+	if db.careTeam != "" || db.isSQLite() {
+		return db.CountPatients(ctx)
+	}
+	var estimate float64
+	err := db.op(ctx, db.Timeouts.Read, func(conn *gorm.DB) error {
+		return conn.Raw("SELECT reltuples FROM pg_class WHERE oid = to_regclass(?)", "patients").Scan(&estimate).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	if estimate < 0 {
+		return db.CountPatients(ctx)
+	}
+	return int64(estimate), nil
+}
+
+// CheckPatientExists returns gorm.ErrRecordNotFound when no patient has the
+// given ID, without loading the patient's prescriptions.
+func (db *DB) CheckPatientExists(ctx context.Context, id uint) error {
+	return db.op(ctx, db.Timeouts.Read, func(conn *gorm.DB) error {
+		return conn.Select("id").First(&Patient{}, id).Error
+	})
+}
+
 // CreatePatient inserts a new patient (and any associated prescriptions if provided).
-func (db *DB) CreatePatient(p *Patient) error {
-	return db.Conn.Create(p).Error
+func (db *DB) CreatePatient(ctx context.Context, p *Patient) error {
+	return db.op(ctx, db.Timeouts.Write, func(conn *gorm.DB) error {
+		return conn.Create(p).Error
+	})
 }
 
 // UpdatePatient saves changes to an existing patient.
-func (db *DB) UpdatePatient(p *Patient) error {
-	return db.Conn.Save(p).Error
+func (db *DB) UpdatePatient(ctx context.Context, p *Patient) error {
+	return db.op(ctx, db.Timeouts.Write, func(conn *gorm.DB) error {
+		return conn.Save(p).Error
+	})
 }
 
-// DeletePatient soft-deletes a patient by ID.
-func (db *DB) DeletePatient(id uint) error {
-	return db.Conn.Delete(&Patient{}, id).Error
+// UpdatePatientFields updates only the given columns of a patient. Unlike
+// UpdatePatient, zero values in fields are written. It returns
+// gorm.ErrRecordNotFound when no patient matches.
+func (db *DB) UpdatePatientFields(ctx context.Context, id uint, fields map[string]interface{}) error {
+	return db.op(ctx, db.Timeouts.Write, func(conn *gorm.DB) error {
+		return affectedOne(conn.Model(&Patient{}).Where("id = ?", id).Updates(fields))
+	})
+}
+
+// DeletePatient soft-deletes a patient by ID. It returns gorm.ErrRecordNotFound
+// when no patient matches or the patient is already deleted.
+func (db *DB) DeletePatient(ctx context.Context, id uint) error {
+	return db.op(ctx, db.Timeouts.Write, func(conn *gorm.DB) error {
+		return affectedOne(conn.Delete(&Patient{}, id))
+	})
+}
+
+// UndeletePatient restores a soft-deleted patient. It returns
+// gorm.ErrRecordNotFound when there is no deleted patient with the given ID.
+func (db *DB) UndeletePatient(ctx context.Context, id uint) error {
+	return db.op(ctx, db.Timeouts.Write, func(conn *gorm.DB) error {
+		return affectedOne(conn.Unscoped().Model(&Patient{}).Where("id = ? AND deleted_at IS NOT NULL", id).Update("deleted_at", nil))
+	})
 }
 
 // GetPrescriptionByID returns a single prescription.
-func (db *DB) GetPrescriptionByID(id uint) (*Prescription, error) {
+func (db *DB) GetPrescriptionByID(ctx context.Context, id uint) (*Prescription, error) {
 	var pr Prescription
-	if err := db.Conn.First(&pr, id).Error; err != nil {
+	err := db.op(ctx, db.Timeouts.Read, func(conn *gorm.DB) error {
+		return conn.First(&pr, id).Error
+	})
+	if err != nil {
 		return nil, err
 	}
 	return &pr, nil
 }
 
 // ListPrescriptionsForPatient returns all prescriptions for a patient.
-func (db *DB) ListPrescriptionsForPatient(patientID uint) ([]Prescription, error) {
+func (db *DB) ListPrescriptionsForPatient(ctx context.Context, patientID uint) ([]Prescription, error) {
 	var list []Prescription
-	if err := db.Conn.Where("patient_id = ?", patientID).Order("id DESC").Find(&list).Error; err != nil {
+	err := db.op(ctx, db.Timeouts.Read, func(conn *gorm.DB) error {
+		return conn.Where("patient_id = ?", patientID).Order("id DESC").Find(&list).Error
+	})
+	if err != nil {
 		return nil, err
 	}
 	return list, nil
 }
 
-// CreatePrescription inserts a new prescription record directly. This will work
-// when the Prescription struct includes a PatientID (if your model includes it).
-// Prefer CreatePrescriptionForPatient when your model uses GORM associations.
-func (db *DB) CreatePrescription(pr *Prescription) error {
-	return db.Conn.Create(pr).Error
+// CreatePrescription inserts a new prescription record directly. PatientID, if
+// set, must reference an existing patient.
+func (db *DB) CreatePrescription(ctx context.Context, pr *Prescription) error {
+	return db.op(ctx, db.Timeouts.Write, func(conn *gorm.DB) error {
+		return conn.Create(pr).Error
+	})
 }
 
-// CreatePrescriptionForPatient associates a prescription with the given patient
-// and inserts it using GORM associations. Use this when the child model does not
-// explicitly define PatientID but the has-many association exists on Patient.
-func (db *DB) CreatePrescriptionForPatient(patientID uint, pr *Prescription) error {
-	// Use association mode to append the prescription to the patient
-	patient := &Patient{ID: patientID}
-	// Ensure parent exists (optional): First will return error if not found
-	if err := db.Conn.First(patient, patientID).Error; err != nil {
-		return err
-	}
-	return db.Conn.Model(patient).Association("Prescriptions").Append(pr)
+// CreatePrescriptionForPatient inserts a prescription for the given patient in
+// one transaction with the check that the patient exists. It returns
+// gorm.ErrRecordNotFound when the patient does not exist or is deleted.
+func (db *DB) CreatePrescriptionForPatient(ctx context.Context, patientID uint, pr *Prescription) error {
+	given := *pr
+	return db.transaction(ctx, func(tx *DB) error {
+		// A retried attempt starts again from the prescription as given
+		*pr = given
+		if err := tx.CheckPatientExists(ctx, patientID); err != nil {
+			return err
+		}
+		pr.PatientID = &patientID
+		return tx.CreatePrescription(ctx, pr)
+	})
 }
 
 // UpdatePrescription updates an existing prescription.
-func (db *DB) UpdatePrescription(pr *Prescription) error {
-	return db.Conn.Save(pr).Error
+func (db *DB) UpdatePrescription(ctx context.Context, pr *Prescription) error {
+	return db.op(ctx, db.Timeouts.Write, func(conn *gorm.DB) error {
+		return conn.Save(pr).Error
+	})
+}
+
+// UpdatePrescriptionFields updates only the given columns of a prescription.
+// Unlike UpdatePrescription, zero values in fields are written. It returns
+// gorm.ErrRecordNotFound when no prescription matches.
+func (db *DB) UpdatePrescriptionFields(ctx context.Context, id uint, fields map[string]interface{}) error {
+	return db.op(ctx, db.Timeouts.Write, func(conn *gorm.DB) error {
+		return affectedOne(conn.Model(&Prescription{}).Where("id = ?", id).Updates(fields))
+	})
 }
 
-// DeletePrescription deletes a prescription by ID.
-func (db *DB) DeletePrescription(id uint) error {
-	return db.Conn.Delete(&Prescription{}, id).Error
+// DeletePrescription soft-deletes a prescription by ID. It returns
+// gorm.ErrRecordNotFound when no prescription matches or it is already deleted.
+func (db *DB) DeletePrescription(ctx context.Context, id uint) error {
+	return db.op(ctx, db.Timeouts.Write, func(conn *gorm.DB) error {
+		return affectedOne(conn.Delete(&Prescription{}, id))
+	})
 }
 
-// ListPrescriptionsForPatient returns all prescriptions for a patient using GORM
-// associations. This is compatible with models that keep the relationship on the
-// parent side (no explicit PatientID field).
-func (db *DB) ListPrescriptionsForPatientAssoc(patientID uint) ([]Prescription, error) {
+// ListPrescriptionsForPatientAssoc returns all prescriptions for a patient using
+// the GORM association on Patient. Unlike ListPrescriptionsForPatient it returns
+// gorm.ErrRecordNotFound when the patient does not exist.
+func (db *DB) ListPrescriptionsForPatientAssoc(ctx context.Context, patientID uint) ([]Prescription, error) {
 	var list []Prescription
-	patient := &Patient{ID: patientID}
-	if err := db.Conn.First(patient, patientID).Error; err != nil {
-		return nil, err
-	}
-	if err := db.Conn.Model(patient).Association("Prescriptions").Find(&list); err != nil {
+	err := db.readTransaction(ctx, func(tx *DB) error {
+		return tx.op(ctx, db.Timeouts.Read, func(conn *gorm.DB) error {
+			patient := &Patient{ID: patientID}
+			if err := conn.First(patient, patientID).Error; err != nil {
+				return err
+			}
+			return conn.Model(patient).Association("Prescriptions").Find(&list)
+		})
+	})
+	if err != nil {
 		return nil, err
 	}
 	return list, nil
 }
+
+// affectedOne returns the error of res, or gorm.ErrRecordNotFound when it
+// touched no rows.
+func affectedOne(res *gorm.DB) error {
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
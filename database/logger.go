@@ -0,0 +1,83 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// slowQuery is how long a statement may take before it is logged as slow.
+const slowQuery = 200 * time.Millisecond
+
+// Logger is a gorm logger writing to a slog.Logger. Statements are logged with
+// placeholders and never with the values bound to them, which may be patient
+// data. At the Error level failed statements are logged, at Warn also slow
+// ones and at Info every statement.
+type Logger struct {
+	log   *slog.Logger
+	level logger.LogLevel
+}
+
+// NewLogger returns a Logger writing to log at level.
+func NewLogger(log *slog.Logger, level logger.LogLevel) *Logger {
+	return &Logger{log: log, level: level}
+}
+
+// LogMode returns a copy of l logging at level.
+func (l *Logger) LogMode(level logger.LogLevel) logger.Interface {
+	return &Logger{log: l.log, level: level}
+}
+
+func (l *Logger) Info(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= logger.Info {
+		l.log.InfoContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l *Logger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= logger.Warn {
+		l.log.WarnContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l *Logger) Error(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= logger.Error {
+		l.log.ErrorContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+// Trace logs a statement that began at begin and failed with err, if any.
+func (l *Logger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	if l.level <= logger.Silent {
+		return
+	}
+	elapsed := time.Since(begin)
+	attrs := func() []slog.Attr {
+		sql, rows := fc()
+		return []slog.Attr{
+			slog.String("sql", sql),
+			slog.Int64("rows", rows),
+			slog.Float64("duration_ms", float64(elapsed.Microseconds())/1000),
+		}
+	}
+
+	switch {
+	case err != nil && l.level >= logger.Error && !errors.Is(err, gorm.ErrRecordNotFound):
+		l.log.LogAttrs(ctx, slog.LevelError, "SQL failed", append(attrs(), slog.String("error", err.Error()))...)
+	case elapsed > slowQuery && l.level >= logger.Warn:
+		l.log.LogAttrs(ctx, slog.LevelWarn, "SQL slow", attrs()...)
+	case l.level >= logger.Info:
+		l.log.LogAttrs(ctx, slog.LevelInfo, "SQL", attrs()...)
+	}
+}
+
+// ParamsFilter drops the values bound to a statement, so that it is logged
+// with its placeholders.
+func (l *Logger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	return sql, nil
+}
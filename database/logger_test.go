@@ -0,0 +1,69 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm/logger"
+)
+
+func TestLoggerOmitsBoundValues(t *testing.T) {
+	tests := []struct {
+		name  string
+		level logger.LogLevel
+		// want are the messages logged, in order
+		want []string
+	}{
+		{name: "silent", level: logger.Silent},
+		{name: "error", level: logger.Error, want: []string{"SQL failed"}},
+		{name: "info", level: logger.Info, want: []string{"SQL", "SQL", "SQL failed", "SQL"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestSQLite(t)
+			var buf bytes.Buffer
+			db.Conn.Logger = NewLogger(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})), tt.level)
+			ctx := context.Background()
+
+			// Patient data only ever appears as bound values
+			p := &Patient{FirstName: "Zelda", LastName: "Quixote", Email: "zq@example.com", Phone: "5550100199", Address: "1 Secret Lane"}
+			if err := db.CreatePatient(ctx, p); err != nil {
+				t.Fatalf("CreatePatient: %v", err)
+			}
+			if _, err := db.SearchPatients(ctx, PatientSearch{Conditions: []PatientCondition{{Field: PatientFieldName, Value: "Quixote"}}}); err != nil {
+				t.Fatalf("SearchPatients: %v", err)
+			}
+			if err := db.CreatePatient(ctx, &Patient{FirstName: "Zelda", LastName: "Quixote", Email: "zq@example.com"}); err == nil {
+				t.Fatal("CreatePatient with a duplicate email succeeded")
+			}
+			if _, err := db.GetPatientByID(ctx, 999); err == nil {
+				t.Fatal("GetPatientByID(999) succeeded")
+			}
+
+			out := buf.String()
+			for _, phi := range []string{"Zelda", "Quixote", "zq@example.com", "5550100199", "Secret Lane"} {
+				if strings.Contains(out, phi) {
+					t.Errorf("log leaks %q:\n%s", phi, out)
+				}
+			}
+			var got []string
+			for dec := json.NewDecoder(&buf); dec.More(); {
+				var record struct{ Msg string }
+				if err := dec.Decode(&record); err != nil {
+					t.Fatalf("invalid log line: %v", err)
+				}
+				got = append(got, record.Msg)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("logged %q, want %q:\n%s", got, tt.want, out)
+			}
+			if tt.level == logger.Info && !strings.Contains(out, "?") {
+				t.Errorf("statements are not logged with placeholders:\n%s", out)
+			}
+		})
+	}
+}
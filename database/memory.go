@@ -0,0 +1,1054 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// MemoryStore is a Store keeping everything in memory, for tests and local
+// development. It follows the semantics of DB: soft deletes, unique patient
+// emails, patient references checked like foreign keys, care team scoping and
+// keyset paging. Strings sort in byte order rather than by the database's
+// collation. Views share the data of the store they were made from.
+type MemoryStore struct {
+	mem      *memory
+	careTeam string
+	deleted  bool
+	// inTx is set in the views passed to Transaction's fn, which already hold
+	// the lock of mem.
+	inTx bool
+}
+
+// memory is the data shared by a MemoryStore and its views. Rows are stored
+// without their associations and copied in and out.
+type memory struct {
+	mu            sync.Mutex
+	patients      map[uint]*Patient
+	prescriptions map[uint]*Prescription
+	careTeams     []CareTeamMember
+	auditEvents   []AuditEvent
+
+	lastPatientID      uint
+	lastPrescriptionID uint
+	lastAuditEventID   uint
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		mem: &memory{
+			patients:      make(map[uint]*Patient),
+			prescriptions: make(map[uint]*Prescription),
+		},
+	}
+}
+
+// WithDeleted returns a view of m that also sees soft-deleted rows. Deletes
+// through it are permanent, as they are through an unscoped DB.
+func (m *MemoryStore) WithDeleted() Store {
+	v := *m
+	v.deleted = true
+	return &v
+}
+
+// ForCareTeam returns a view of m whose patient and prescription listings only
+// include patients in member's care team. An empty member means no
+// restriction.
+func (m *MemoryStore) ForCareTeam(member string) Store {
+	v := *m
+	v.careTeam = member
+	return &v
+}
+
+// Transaction runs fn holding the lock of m's data, so that other operations
+// wait for it, and puts the data back as it was if fn fails. IDs handed out in
+// the meantime are not reused, as with a database sequence. Transaction within
+// fn undoes only what the inner fn did.
+func (m *MemoryStore) Transaction(ctx context.Context, fn func(tx Store) error) error {
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	saved := m.mem.snapshot()
+	committed := false
+	defer func() {
+		if !committed {
+			m.mem.restore(saved)
+		}
+	}()
+
+	tx := *m
+	tx.inTx = true
+	if err := fn(&tx); err != nil {
+		return err
+	}
+	committed = true
+	return nil
+}
+
+// ReadTransaction runs fn holding the lock of m's data, so that what fn reads
+// is not changed by other operations meanwhile.
+func (m *MemoryStore) ReadTransaction(ctx context.Context, fn func(tx Store) error) error {
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	tx := *m
+	tx.inTx = true
+	return fn(&tx)
+}
+
+// lock locks the data of m, unless ctx is done. Within a transaction the lock
+// is already held.
+func (m *MemoryStore) lock(ctx context.Context) (func(), error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if m.inTx {
+		return func() {}, nil
+	}
+	m.mem.mu.Lock()
+	return m.mem.mu.Unlock, nil
+}
+
+// snapshot returns a copy of the rows of mem, for restore.
+func (mem *memory) snapshot() *memory {
+	saved := &memory{
+		patients:      make(map[uint]*Patient, len(mem.patients)),
+		prescriptions: make(map[uint]*Prescription, len(mem.prescriptions)),
+		careTeams:     append([]CareTeamMember(nil), mem.careTeams...),
+		auditEvents:   append([]AuditEvent(nil), mem.auditEvents...),
+	}
+	for id, p := range mem.patients {
+		row := *p
+		saved.patients[id] = &row
+	}
+	for id, pr := range mem.prescriptions {
+		row := *pr
+		saved.prescriptions[id] = &row
+	}
+	return saved
+}
+
+// restore puts back the rows of a snapshot of mem.
+func (mem *memory) restore(saved *memory) {
+	mem.patients = saved.patients
+	mem.prescriptions = saved.prescriptions
+	mem.careTeams = saved.careTeams
+	mem.auditEvents = saved.auditEvents
+}
+
+// visible reports whether a row deleted at d is seen by m.
+func (m *MemoryStore) visible(d gorm.DeletedAt) bool {
+	return m.deleted || !d.Valid
+}
+
+// inCareTeam reports whether the patient is in the care team m is restricted
+// to, if any.
+func (m *MemoryStore) inCareTeam(patientID *uint) bool {
+	if m.careTeam == "" {
+		return true
+	}
+	return patientID != nil && m.isCareTeamMember(*patientID, m.careTeam)
+}
+
+// --- Patients ---
+
+// GetPatientByID returns a patient with its prescriptions.
+func (m *MemoryStore) GetPatientByID(ctx context.Context, id uint) (*Patient, error) {
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	p, err := m.patient(id)
+	if err != nil {
+		return nil, err
+	}
+	p.Prescriptions = m.prescriptionsOf(id)
+	return p, nil
+}
+
+// ListPatientsAfter returns up to limit patients by descending ID, only those
+// with an ID below afterID when it is set, after skipping offset.
+func (m *MemoryStore) ListPatientsAfter(ctx context.Context, afterID uint, offset, limit int) ([]Patient, error) {
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	patients := m.patientRows(func(p *Patient) bool {
+		return m.inCareTeam(&p.ID) && (afterID == 0 || p.ID < afterID)
+	})
+	reverse(patients)
+	return page(patients, offset, limit), nil
+}
+
+// CountPatients returns the number of patients.
+func (m *MemoryStore) CountPatients(ctx context.Context) (int64, error) {
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	return int64(len(m.patientRows(func(p *Patient) bool { return m.inCareTeam(&p.ID) }))), nil
+}
+
+// EstimatePatientCount returns the exact number of patients, which is cheap
+// in memory.
+func (m *MemoryStore) EstimatePatientCount(ctx context.Context) (int64, error) {
+	return m.CountPatients(ctx)
+}
+
+// CheckPatientExists returns gorm.ErrRecordNotFound when no patient has the
+// given ID.
+func (m *MemoryStore) CheckPatientExists(ctx context.Context, id uint) error {
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	_, err = m.patient(id)
+	return err
+}
+
+// CreatePatient inserts a new patient and any prescriptions and care team
+// members it has, setting their IDs and timestamps.
+func (m *MemoryStore) CreatePatient(ctx context.Context, p *Patient) error {
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if p.ID != 0 && m.mem.patients[p.ID] != nil {
+		return gorm.ErrDuplicatedKey
+	}
+	return m.savePatient(p)
+}
+
+// UpdatePatient saves every field of p, inserting it if it does not exist.
+func (m *MemoryStore) UpdatePatient(ctx context.Context, p *Patient) error {
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return m.savePatient(p)
+}
+
+// UpdatePatientFields updates the given columns of a patient. It returns
+// gorm.ErrRecordNotFound when no patient matches.
+func (m *MemoryStore) UpdatePatientFields(ctx context.Context, id uint, fields map[string]interface{}) error {
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	p, err := m.patient(id)
+	if err != nil {
+		return err
+	}
+	if err := setColumns(ctx, p, fields); err != nil {
+		return err
+	}
+	if p.ID != id {
+		return fmt.Errorf("database: cannot change the ID of patient %d", id)
+	}
+	if err := m.checkEmail(p); err != nil {
+		return err
+	}
+	if _, ok := fields["updated_at"]; !ok {
+		p.UpdatedAt = time.Now()
+	}
+	m.mem.patients[id] = p
+	return nil
+}
+
+// DeletePatient soft-deletes a patient by ID. It returns
+// gorm.ErrRecordNotFound when no patient matches or the patient is already
+// deleted.
+func (m *MemoryStore) DeletePatient(ctx context.Context, id uint) error {
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	p, err := m.patient(id)
+	if err != nil {
+		return err
+	}
+	if !m.deleted {
+		p.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+		m.mem.patients[id] = p
+		return nil
+	}
+
+	// Like the foreign keys of the patients table
+	delete(m.mem.patients, id)
+	for _, pr := range m.mem.prescriptions {
+		if pr.PatientID != nil && *pr.PatientID == id {
+			pr.PatientID = nil
+		}
+	}
+	members := m.mem.careTeams[:0]
+	for _, c := range m.mem.careTeams {
+		if c.PatientID != id {
+			members = append(members, c)
+		}
+	}
+	m.mem.careTeams = members
+	return nil
+}
+
+// UndeletePatient restores a soft-deleted patient. It returns
+// gorm.ErrRecordNotFound when there is no deleted patient with the given ID.
+func (m *MemoryStore) UndeletePatient(ctx context.Context, id uint) error {
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	p, ok := m.mem.patients[id]
+	if !ok || !p.DeletedAt.Valid {
+		return gorm.ErrRecordNotFound
+	}
+	p.DeletedAt = gorm.DeletedAt{}
+	p.UpdatedAt = time.Now()
+	return nil
+}
+
+// SearchPatients returns the patients matching s, ordered and paged by keyset.
+func (m *MemoryStore) SearchPatients(ctx context.Context, s PatientSearch) ([]Patient, error) {
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	patients, err := m.searchPatients(s)
+	if err != nil {
+		return nil, err
+	}
+	var afterKey string
+	if s.After != nil {
+		afterKey = s.After.Key
+	}
+	key := func(p *Patient) string { return PatientSortKey(p, s.OrderBy) }
+	id := func(p *Patient) uint { return p.ID }
+	return keysetPage(patients, id, key, s.Desc, s.After, afterKey, s.Limit), nil
+}
+
+// CountPatientSearch returns the number of patients matching the conditions
+// of s.
+func (m *MemoryStore) CountPatientSearch(ctx context.Context, s PatientSearch) (int64, error) {
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	patients, err := m.searchPatients(s)
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(patients)), nil
+}
+
+// patient returns a copy of the patient with the given ID, without its
+// associations.
+func (m *MemoryStore) patient(id uint) (*Patient, error) {
+	p, ok := m.mem.patients[id]
+	if !ok || !m.visible(p.DeletedAt) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	out := *p
+	return &out, nil
+}
+
+// patientRows returns copies of the patients seen by m for which keep returns
+// true, by ascending ID.
+func (m *MemoryStore) patientRows(keep func(*Patient) bool) []Patient {
+	patients := []Patient{}
+	for _, p := range m.mem.patients {
+		if m.visible(p.DeletedAt) && keep(p) {
+			patients = append(patients, *p)
+		}
+	}
+	sort.Slice(patients, func(i, j int) bool { return patients[i].ID < patients[j].ID })
+	return patients
+}
+
+// savePatient inserts or replaces p and its associations. Prescriptions that
+// already exist are only moved to p, as gorm does when saving associations.
+func (m *MemoryStore) savePatient(p *Patient) error {
+	if err := m.checkEmail(p); err != nil {
+		return err
+	}
+	now := time.Now()
+	if p.ID == 0 {
+		m.mem.lastPatientID++
+		p.ID = m.mem.lastPatientID
+	} else if p.ID > m.mem.lastPatientID {
+		m.mem.lastPatientID = p.ID
+	}
+	if old, ok := m.mem.patients[p.ID]; ok && p.CreatedAt.IsZero() {
+		p.CreatedAt = old.CreatedAt
+	}
+	if p.CreatedAt.IsZero() {
+		p.CreatedAt = now
+	}
+	p.UpdatedAt = now
+
+	row := *p
+	row.Prescriptions, row.CareTeam = nil, nil
+	m.mem.patients[p.ID] = &row
+
+	for i := range p.Prescriptions {
+		pr := &p.Prescriptions[i]
+		patientID := p.ID
+		pr.PatientID = &patientID
+		if old := m.mem.prescriptions[pr.ID]; pr.ID != 0 && old != nil {
+			old.PatientID = &patientID
+			continue
+		}
+		m.savePrescription(pr)
+	}
+	for i := range p.CareTeam {
+		c := &p.CareTeam[i]
+		c.PatientID = p.ID
+		if !m.isCareTeamMember(c.PatientID, c.Member) {
+			if c.CreatedAt.IsZero() {
+				c.CreatedAt = now
+			}
+			m.mem.careTeams = append(m.mem.careTeams, *c)
+		}
+	}
+	return nil
+}
+
+// checkEmail returns gorm.ErrDuplicatedKey when another patient, deleted or
+// not, has the email of p, like the unique index on patients.email. Any number
+// of patients may have no email.
+func (m *MemoryStore) checkEmail(p *Patient) error {
+	if p.Email == "" {
+		return nil
+	}
+	for id, other := range m.mem.patients {
+		if id != p.ID && other.Email == p.Email {
+			return gorm.ErrDuplicatedKey
+		}
+	}
+	return nil
+}
+
+// searchPatients returns the patients matching the conditions of s, by
+// ascending ID.
+func (m *MemoryStore) searchPatients(s PatientSearch) ([]Patient, error) {
+	switch s.OrderBy {
+	case "", PatientFieldID, PatientFieldFirstName, PatientFieldLastName, PatientFieldEmail:
+	default:
+		return nil, fmt.Errorf("database: cannot order patients by %q", s.OrderBy)
+	}
+	for _, c := range s.Conditions {
+		switch c.Field {
+		case PatientFieldFirstName, PatientFieldLastName, PatientFieldEmail, PatientFieldName,
+			PatientFieldPhone, PatientFieldMedication:
+		default:
+			return nil, fmt.Errorf("database: cannot search patients by %q", c.Field)
+		}
+	}
+
+	return m.patientRows(func(p *Patient) bool {
+		if !m.inCareTeam(&p.ID) {
+			return false
+		}
+		for _, c := range s.Conditions {
+			if !m.matchPatient(p, c) {
+				return false
+			}
+		}
+		return true
+	}), nil
+}
+
+// matchPatient reports whether p matches c, as patientSearchQuery does.
+func (m *MemoryStore) matchPatient(p *Patient, c PatientCondition) bool {
+	value := strings.ToLower(c.Value)
+	match := func(s string) bool {
+		if c.Exact {
+			return strings.ToLower(s) == value
+		}
+		return strings.Contains(strings.ToLower(s), value)
+	}
+
+	switch c.Field {
+	case PatientFieldFirstName:
+		return match(p.FirstName)
+	case PatientFieldLastName:
+		return match(p.LastName)
+	case PatientFieldEmail:
+		return match(p.Email)
+	case PatientFieldName:
+		return match(p.FirstName) || match(p.LastName)
+	case PatientFieldPhone:
+		digits := strings.NewReplacer(" ", "", "-", "", "(", "", ")", "", ".", "", "+", "").Replace(p.Phone)
+		if c.Exact {
+			return digits == c.Value
+		}
+		return strings.Contains(digits, c.Value)
+	case PatientFieldMedication:
+		// Only current prescriptions count, deleted or not in the view
+		for _, pr := range m.mem.prescriptions {
+			if pr.PatientID != nil && *pr.PatientID == p.ID && !pr.DeletedAt.Valid &&
+				pr.Status == PrescriptionActive && match(pr.Medication) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// --- Prescriptions ---
+
+// GetPrescriptionByID returns a single prescription.
+func (m *MemoryStore) GetPrescriptionByID(ctx context.Context, id uint) (*Prescription, error) {
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	return m.prescription(id)
+}
+
+// CreatePrescription inserts a new prescription. PatientID, if set, must
+// reference an existing patient.
+func (m *MemoryStore) CreatePrescription(ctx context.Context, pr *Prescription) error {
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if pr.ID != 0 && m.mem.prescriptions[pr.ID] != nil {
+		return gorm.ErrDuplicatedKey
+	}
+	if err := m.checkPatientRef(pr.PatientID); err != nil {
+		return err
+	}
+	m.savePrescription(pr)
+	return nil
+}
+
+// CreatePrescriptionForPatient inserts a prescription for the given patient.
+// It returns gorm.ErrRecordNotFound when the patient does not exist or is
+// deleted.
+func (m *MemoryStore) CreatePrescriptionForPatient(ctx context.Context, patientID uint, pr *Prescription) error {
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if _, err := m.patient(patientID); err != nil {
+		return err
+	}
+	if pr.ID != 0 && m.mem.prescriptions[pr.ID] != nil {
+		return gorm.ErrDuplicatedKey
+	}
+	pr.PatientID = &patientID
+	m.savePrescription(pr)
+	return nil
+}
+
+// UpdatePrescription saves every field of pr, inserting it if it does not
+// exist.
+func (m *MemoryStore) UpdatePrescription(ctx context.Context, pr *Prescription) error {
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := m.checkPatientRef(pr.PatientID); err != nil {
+		return err
+	}
+	m.savePrescription(pr)
+	return nil
+}
+
+// UpdatePrescriptionFields updates the given columns of a prescription. It
+// returns gorm.ErrRecordNotFound when no prescription matches.
+func (m *MemoryStore) UpdatePrescriptionFields(ctx context.Context, id uint, fields map[string]interface{}) error {
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	pr, err := m.prescription(id)
+	if err != nil {
+		return err
+	}
+	if err := setColumns(ctx, pr, fields); err != nil {
+		return err
+	}
+	if pr.ID != id {
+		return fmt.Errorf("database: cannot change the ID of prescription %d", id)
+	}
+	if err := m.checkPatientRef(pr.PatientID); err != nil {
+		return err
+	}
+	if _, ok := fields["updated_at"]; !ok {
+		pr.UpdatedAt = time.Now()
+	}
+	m.mem.prescriptions[id] = pr
+	return nil
+}
+
+// DeletePrescription soft-deletes a prescription by ID. It returns
+// gorm.ErrRecordNotFound when no prescription matches or it is already
+// deleted.
+func (m *MemoryStore) DeletePrescription(ctx context.Context, id uint) error {
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	pr, err := m.prescription(id)
+	if err != nil {
+		return err
+	}
+	if m.deleted {
+		delete(m.mem.prescriptions, id)
+		return nil
+	}
+	pr.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	m.mem.prescriptions[id] = pr
+	return nil
+}
+
+// SearchPrescriptions returns the prescriptions matching s, ordered and paged
+// by keyset.
+func (m *MemoryStore) SearchPrescriptions(ctx context.Context, s PrescriptionSearch) ([]Prescription, error) {
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	list, err := m.searchPrescriptions(s)
+	if err != nil {
+		return nil, err
+	}
+
+	key := func(pr *Prescription) string { return PrescriptionSortKey(pr, s.OrderBy) }
+	var afterKey string
+	if s.After != nil {
+		afterKey = s.After.Key
+	}
+	if s.OrderBy == PrescriptionFieldPrescribedAt {
+		// RFC 3339 times with trimmed fractions do not sort as strings
+		key = func(pr *Prescription) string { return sortableTime(pr.PrescribedAt) }
+		if s.After != nil {
+			t, err := time.Parse(time.RFC3339Nano, s.After.Key)
+			if err != nil {
+				return nil, fmt.Errorf("database: invalid prescribed_at cursor %q: %w", s.After.Key, err)
+			}
+			afterKey = sortableTime(t)
+		}
+	}
+	id := func(pr *Prescription) uint { return pr.ID }
+	return keysetPage(list, id, key, s.Desc, s.After, afterKey, s.Limit), nil
+}
+
+// CountPrescriptionSearch returns the number of prescriptions matching the
+// filters of s.
+func (m *MemoryStore) CountPrescriptionSearch(ctx context.Context, s PrescriptionSearch) (int64, error) {
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	list, err := m.searchPrescriptions(s)
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(list)), nil
+}
+
+// prescription returns a copy of the prescription with the given ID.
+func (m *MemoryStore) prescription(id uint) (*Prescription, error) {
+	pr, ok := m.mem.prescriptions[id]
+	if !ok || !m.visible(pr.DeletedAt) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	out := copyPrescription(pr)
+	return &out, nil
+}
+
+// prescriptionRows returns copies of the prescriptions seen by m for which
+// keep returns true, by ascending ID.
+func (m *MemoryStore) prescriptionRows(keep func(*Prescription) bool) []Prescription {
+	list := []Prescription{}
+	for _, pr := range m.mem.prescriptions {
+		if m.visible(pr.DeletedAt) && keep(pr) {
+			list = append(list, copyPrescription(pr))
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// prescriptionsOf returns the prescriptions of a patient, by ascending ID.
+func (m *MemoryStore) prescriptionsOf(patientID uint) []Prescription {
+	return m.prescriptionRows(func(pr *Prescription) bool {
+		return pr.PatientID != nil && *pr.PatientID == patientID
+	})
+}
+
+// savePrescription inserts or replaces pr, setting its ID, defaults and
+// timestamps.
+func (m *MemoryStore) savePrescription(pr *Prescription) {
+	now := time.Now()
+	old, exists := m.mem.prescriptions[pr.ID]
+	if pr.ID == 0 {
+		m.mem.lastPrescriptionID++
+		pr.ID = m.mem.lastPrescriptionID
+	} else if pr.ID > m.mem.lastPrescriptionID {
+		m.mem.lastPrescriptionID = pr.ID
+	}
+	if !exists {
+		pr.BeforeCreate(nil)
+	}
+	if exists && pr.CreatedAt.IsZero() {
+		pr.CreatedAt = old.CreatedAt
+	}
+	if pr.CreatedAt.IsZero() {
+		pr.CreatedAt = now
+	}
+	pr.UpdatedAt = now
+
+	row := copyPrescription(pr)
+	m.mem.prescriptions[pr.ID] = &row
+}
+
+// checkPatientRef returns gorm.ErrForeignKeyViolated when patientID is set
+// but no patient, deleted or not, has it, like the foreign key on
+// prescriptions.patient_id.
+func (m *MemoryStore) checkPatientRef(patientID *uint) error {
+	if patientID != nil && m.mem.patients[*patientID] == nil {
+		return gorm.ErrForeignKeyViolated
+	}
+	return nil
+}
+
+// searchPrescriptions returns the prescriptions matching the filters of s, by
+// ascending ID.
+func (m *MemoryStore) searchPrescriptions(s PrescriptionSearch) ([]Prescription, error) {
+	switch s.OrderBy {
+	case "", PrescriptionFieldID, PrescriptionFieldMedication, PrescriptionFieldPrescribedAt:
+	default:
+		return nil, fmt.Errorf("database: cannot order prescriptions by %q", s.OrderBy)
+	}
+
+	medication := strings.ToLower(s.Medication)
+	return m.prescriptionRows(func(pr *Prescription) bool {
+		switch {
+		case !m.inCareTeam(pr.PatientID):
+			return false
+		case s.PatientID != 0 && (pr.PatientID == nil || *pr.PatientID != s.PatientID):
+			return false
+		case medication != "" && !strings.Contains(strings.ToLower(pr.Medication), medication):
+			return false
+		case s.Status != "" && pr.Status != s.Status:
+			return false
+		case !s.PrescribedAfter.IsZero() && pr.PrescribedAt.Before(s.PrescribedAfter):
+			return false
+		case !s.PrescribedBefore.IsZero() && !pr.PrescribedAt.Before(s.PrescribedBefore):
+			return false
+		}
+		return true
+	}), nil
+}
+
+// copyPrescription returns a copy of pr that shares no memory with it.
+func copyPrescription(pr *Prescription) Prescription {
+	out := *pr
+	if pr.PatientID != nil {
+		id := *pr.PatientID
+		out.PatientID = &id
+	}
+	return out
+}
+
+// sortableTime formats t so that times sort as strings.
+func sortableTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.000000000Z")
+}
+
+// --- Care teams ---
+
+// IsCareTeamMember reports whether member is in the care team of the patient.
+func (m *MemoryStore) IsCareTeamMember(ctx context.Context, patientID uint, member string) (bool, error) {
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	return m.isCareTeamMember(patientID, member), nil
+}
+
+// AddCareTeamMember adds member to the care team of the patient, doing
+// nothing if they already are. It returns gorm.ErrRecordNotFound when the
+// patient does not exist or is deleted.
+func (m *MemoryStore) AddCareTeamMember(ctx context.Context, patientID uint, member string) (*CareTeamMember, error) {
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if _, err := m.patient(patientID); err != nil {
+		return nil, err
+	}
+	for _, c := range m.mem.careTeams {
+		if c.PatientID == patientID && c.Member == member {
+			return &c, nil
+		}
+	}
+	c := CareTeamMember{PatientID: patientID, Member: member, CreatedAt: time.Now()}
+	m.mem.careTeams = append(m.mem.careTeams, c)
+	return &c, nil
+}
+
+// RemoveCareTeamMember removes member from the care team of the patient. It
+// returns gorm.ErrRecordNotFound when they are not in it.
+func (m *MemoryStore) RemoveCareTeamMember(ctx context.Context, patientID uint, member string) error {
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for i, c := range m.mem.careTeams {
+		if c.PatientID == patientID && c.Member == member {
+			m.mem.careTeams = append(m.mem.careTeams[:i], m.mem.careTeams[i+1:]...)
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+// ListCareTeam returns the care team of the patient, oldest member first.
+func (m *MemoryStore) ListCareTeam(ctx context.Context, patientID uint) ([]CareTeamMember, error) {
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	members := []CareTeamMember{}
+	for _, c := range m.mem.careTeams {
+		if c.PatientID == patientID {
+			members = append(members, c)
+		}
+	}
+	sort.Slice(members, func(i, j int) bool {
+		if !members[i].CreatedAt.Equal(members[j].CreatedAt) {
+			return members[i].CreatedAt.Before(members[j].CreatedAt)
+		}
+		return members[i].Member < members[j].Member
+	})
+	return members, nil
+}
+
+func (m *MemoryStore) isCareTeamMember(patientID uint, member string) bool {
+	for _, c := range m.mem.careTeams {
+		if c.PatientID == patientID && c.Member == member {
+			return true
+		}
+	}
+	return false
+}
+
+// --- Audit trail ---
+
+// CreateAuditEvent appends an event, and its patient links, to the audit
+// trail.
+func (m *MemoryStore) CreateAuditEvent(ctx context.Context, ev *AuditEvent) error {
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if ev.ID != 0 {
+		for _, other := range m.mem.auditEvents {
+			if other.ID == ev.ID {
+				return gorm.ErrDuplicatedKey
+			}
+		}
+		if ev.ID > m.mem.lastAuditEventID {
+			m.mem.lastAuditEventID = ev.ID
+		}
+	} else {
+		m.mem.lastAuditEventID++
+		ev.ID = m.mem.lastAuditEventID
+	}
+	if ev.CreatedAt.IsZero() {
+		ev.CreatedAt = time.Now()
+	}
+	for i := range ev.Patients {
+		ev.Patients[i].AuditEventID = ev.ID
+	}
+	m.mem.auditEvents = append(m.mem.auditEvents, copyAuditEvent(ev))
+	return nil
+}
+
+// ListAuditEvents returns the audit events matching s with their patient
+// links, newest first and paged by keyset.
+func (m *MemoryStore) ListAuditEvents(ctx context.Context, s AuditSearch) ([]AuditEvent, error) {
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	events := []AuditEvent{}
+	for i := range m.mem.auditEvents {
+		ev := &m.mem.auditEvents[i]
+		switch {
+		case s.PatientID != 0 && !concernsPatient(ev, s.PatientID):
+		case s.Actor != "" && ev.Actor != s.Actor:
+		case !s.Since.IsZero() && ev.CreatedAt.Before(s.Since):
+		case !s.Until.IsZero() && !ev.CreatedAt.Before(s.Until):
+		default:
+			events = append(events, copyAuditEvent(ev))
+		}
+	}
+	id := func(ev *AuditEvent) uint { return ev.ID }
+	key := func(*AuditEvent) string { return "" }
+	return keysetPage(events, id, key, true, s.After, "", s.Limit), nil
+}
+
+func concernsPatient(ev *AuditEvent, patientID uint) bool {
+	for _, p := range ev.Patients {
+		if p.PatientID == patientID {
+			return true
+		}
+	}
+	return false
+}
+
+// copyAuditEvent returns a copy of ev that shares no memory with it.
+func copyAuditEvent(ev *AuditEvent) AuditEvent {
+	out := *ev
+	out.Patients = append([]AuditEventPatient(nil), ev.Patients...)
+	return out
+}
+
+// --- Helpers ---
+
+// memorySchemas caches the schemas setColumns parses.
+var memorySchemas sync.Map
+
+// setColumns sets the fields of model, a pointer to a struct, that are named
+// by the column names in fields, converting values as gorm does.
+func setColumns(ctx context.Context, model interface{}, fields map[string]interface{}) error {
+	s, err := schema.Parse(model, &memorySchemas, schema.NamingStrategy{})
+	if err != nil {
+		return err
+	}
+	rv := reflect.ValueOf(model).Elem()
+	for column, value := range fields {
+		field := s.LookUpField(column)
+		if field == nil || field.DBName == "" {
+			return fmt.Errorf("database: %s has no column %q", s.Table, column)
+		}
+		if err := field.Set(ctx, rv, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// keysetPage sorts rows by key and then ID in the given direction and, when
+// after is set, keeps only the rows strictly after the cursor row, whose key
+// is afterKey. It returns at most limit rows, or all of them if limit is 0.
+func keysetPage[T any](rows []T, id func(*T) uint, key func(*T) string, desc bool, after *SearchCursor, afterKey string, limit int) []T {
+	compare := func(keyA string, idA uint, keyB string, idB uint) int {
+		c := strings.Compare(keyA, keyB)
+		if c == 0 && idA != idB {
+			c = 1
+			if idA < idB {
+				c = -1
+			}
+		}
+		if desc {
+			c = -c
+		}
+		return c
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		return compare(key(&rows[i]), id(&rows[i]), key(&rows[j]), id(&rows[j])) < 0
+	})
+	if after != nil {
+		kept := rows[:0]
+		for i := range rows {
+			if compare(key(&rows[i]), id(&rows[i]), afterKey, after.ID) > 0 {
+				kept = append(kept, rows[i])
+			}
+		}
+		rows = kept
+	}
+	if limit > 0 && len(rows) > limit {
+		rows = rows[:limit]
+	}
+	return rows
+}
+
+// page returns at most limit of rows after skipping offset. A negative limit
+// returns all of them.
+func page[T any](rows []T, offset, limit int) []T {
+	if offset > len(rows) {
+		offset = len(rows)
+	}
+	rows = rows[offset:]
+	if limit >= 0 && limit < len(rows) {
+		rows = rows[:limit]
+	}
+	return rows
+}
+
+// reverse reverses rows in place.
+func reverse[T any](rows []T) {
+	for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+		rows[i], rows[j] = rows[j], rows[i]
+	}
+}
@@ -0,0 +1,55 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// QueryObserver is called after every statement gorm runs, with the kind of
+// operation (create, query, update, delete, row or raw), the table it touched,
+// if known, how long it took and whether it failed. Finding no record is not
+// a failure.
+type QueryObserver func(operation, table string, elapsed time.Duration, err error)
+
+// queryStartKey holds a statement's start time while it runs.
+const queryStartKey = "database:query_start"
+
+// ObserveQueries calls observe after every statement run on db or any view of
+// it.
+func ObserveQueries(db *DB, observe QueryObserver) error {
+	before := func(tx *gorm.DB) {
+		tx.InstanceSet(queryStartKey, time.Now())
+	}
+	after := func(operation string) func(*gorm.DB) {
+		return func(tx *gorm.DB) {
+			v, _ := tx.InstanceGet(queryStartKey)
+			start, ok := v.(time.Time)
+			if !ok {
+				return
+			}
+			err := tx.Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				err = nil
+			}
+			observe(operation, tx.Statement.Table, time.Since(start), err)
+		}
+	}
+
+	cb := db.Conn.Callback()
+	return errors.Join(
+		cb.Create().Before("gorm:create").Register("database:before_create", before),
+		cb.Create().After("gorm:create").Register("database:after_create", after("create")),
+		cb.Query().Before("gorm:query").Register("database:before_query", before),
+		cb.Query().After("gorm:query").Register("database:after_query", after("query")),
+		cb.Update().Before("gorm:update").Register("database:before_update", before),
+		cb.Update().After("gorm:update").Register("database:after_update", after("update")),
+		cb.Delete().Before("gorm:delete").Register("database:before_delete", before),
+		cb.Delete().After("gorm:delete").Register("database:after_delete", after("delete")),
+		cb.Row().Before("gorm:row").Register("database:before_row", before),
+		cb.Row().After("gorm:row").Register("database:after_row", after("row")),
+		cb.Raw().Before("gorm:raw").Register("database:before_raw", before),
+		cb.Raw().After("gorm:raw").Register("database:after_raw", after("raw")),
+	)
+}
@@ -0,0 +1,330 @@
+package database
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// migrationFiles holds the schema's history, one directory per dialect, as
+// pairs of files named like 0001_create_tables.up.sql and
+// 0001_create_tables.down.sql.
+//
+//go:embed migrations
+var migrationFiles embed.FS
+
+// migrationLockID is the Postgres advisory lock held while migrating, so that
+// replicas starting together take turns.
+const migrationLockID = 0x706c61796d696772
+
+// migrationLockPoll is how often a replica waiting for another to finish
+// migrating tries the lock again. Waiting by polling keeps no statement open, which
+// CREATE INDEX CONCURRENTLY in the other replica's migration would wait for.
+const migrationLockPoll = 500 * time.Millisecond
+
+// noTransaction is the comment that, heading both files of a migration, runs
+// it outside a transaction.
+const noTransaction = "-- migrate:no-transaction"
+
+// Migration is one versioned change to the schema.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+
+	// NoTransaction runs the migration outside a transaction, one statement
+	// at a time, as Postgres requires of CREATE INDEX CONCURRENTLY. Its
+	// statements must each end a line with a semicolon and should be safe to
+	// run again, since a failure leaves those before it applied.
+	NoTransaction bool
+}
+
+// MigrationState is a migration and when it was applied, if it was.
+type MigrationState struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// schemaMigration records an applied migration.
+type schemaMigration struct {
+	Version   int
+	Name      string
+	AppliedAt time.Time
+}
+
+// Migrations returns the migrations for db's dialect, oldest first.
+func Migrations(db *DB) ([]Migration, error) {
+	dir := path.Join("migrations", db.Conn.Dialector.Name())
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, fmt.Errorf("database: no migrations for %s: %w", db.Conn.Dialector.Name(), err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, e := range entries {
+		base, direction, ok := strings.Cut(strings.TrimSuffix(e.Name(), ".sql"), ".")
+		version, name, ok2 := strings.Cut(base, "_")
+		n, err := strconv.Atoi(version)
+		if !ok || !ok2 || err != nil || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("database: migration file %s is not named like 0001_name.up.sql", e.Name())
+		}
+		b, err := fs.ReadFile(migrationFiles, path.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m := byVersion[n]
+		if m == nil {
+			m = &Migration{Version: n, Name: name}
+			byVersion[n] = m
+		} else if m.Name != name {
+			return nil, fmt.Errorf("database: migration %d is named both %s and %s", n, m.Name, name)
+		}
+		if direction == "up" {
+			m.Up = string(b)
+		} else {
+			m.Down = string(b)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("database: migration %04d_%s needs both an up and a down file", m.Version, m.Name)
+		}
+		m.NoTransaction = hasDirective(m.Up, noTransaction)
+		if m.NoTransaction != hasDirective(m.Down, noTransaction) {
+			return nil, fmt.Errorf("database: migration %04d_%s must run outside a transaction in both directions or neither", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// MigrateUp applies every pending migration, oldest first, and returns those
+// it applied. Each runs in a transaction of its own unless it is marked
+// NoTransaction.
+func MigrateUp(ctx context.Context, db *DB) ([]Migration, error) {
+	migrations, err := Migrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	err = migrationSession(ctx, db, func(conn *gorm.DB, done map[int]schemaMigration) error {
+		for _, m := range migrations {
+			if _, ok := done[m.Version]; ok {
+				continue
+			}
+			err := runMigration(conn, m, m.Up, func(tx *gorm.DB) error {
+				return tx.Table("schema_migrations").Create(&schemaMigration{
+					Version:   m.Version,
+					Name:      m.Name,
+					AppliedAt: time.Now().UTC(),
+				}).Error
+			})
+			if err != nil {
+				return err
+			}
+			applied = append(applied, m)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// MigrateDown reverts the steps most recently applied migrations, newest
+// first, and returns those it reverted. Each runs in a transaction of its own
+// unless it is marked NoTransaction.
+func MigrateDown(ctx context.Context, db *DB, steps int) ([]Migration, error) {
+	migrations, err := Migrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var reverted []Migration
+	err = migrationSession(ctx, db, func(conn *gorm.DB, done map[int]schemaMigration) error {
+		for i := len(migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			m := migrations[i]
+			if _, ok := done[m.Version]; !ok {
+				continue
+			}
+			err := runMigration(conn, m, m.Down, func(tx *gorm.DB) error {
+				return tx.Exec("DELETE FROM schema_migrations WHERE version = ?", m.Version).Error
+			})
+			if err != nil {
+				return err
+			}
+			reverted = append(reverted, m)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// MigrationStatus returns every migration known to this build or recorded as
+// applied, oldest first. Applied migrations missing from this build, which a
+// newer build applied, have no SQL.
+func MigrationStatus(ctx context.Context, db *DB) ([]MigrationState, error) {
+	migrations, err := Migrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var states []MigrationState
+	err = migrationSession(ctx, db, func(conn *gorm.DB, done map[int]schemaMigration) error {
+		for _, m := range migrations {
+			state := MigrationState{Migration: m}
+			if row, ok := done[m.Version]; ok {
+				state.AppliedAt = &row.AppliedAt
+				delete(done, m.Version)
+			}
+			states = append(states, state)
+		}
+		for _, row := range done {
+			appliedAt := row.AppliedAt
+			states = append(states, MigrationState{
+				Migration: Migration{Version: row.Version, Name: row.Name},
+				AppliedAt: &appliedAt,
+			})
+		}
+		return nil
+	})
+	sort.Slice(states, func(i, j int) bool { return states[i].Version < states[j].Version })
+	return states, err
+}
+
+// PendingMigrations returns the migrations not yet applied to db.
+func PendingMigrations(ctx context.Context, db *DB) ([]Migration, error) {
+	states, err := MigrationStatus(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, s := range states {
+		if s.AppliedAt == nil {
+			pending = append(pending, s.Migration)
+		}
+	}
+	return pending, nil
+}
+
+// migrationSession runs fn on a connection of its own holding the migration
+// lock, passing the applied migrations by version. The schema_migrations table
+// is created first if need be.
+func migrationSession(ctx context.Context, db *DB, fn func(conn *gorm.DB, done map[int]schemaMigration) error) error {
+	return db.Conn.WithContext(ctx).Connection(func(conn *gorm.DB) error {
+		// SQLite serializes writers to the file by itself
+		createTable := "CREATE TABLE IF NOT EXISTS schema_migrations (version integer PRIMARY KEY, name text NOT NULL, applied_at datetime NOT NULL)"
+		if !db.isSQLite() {
+			if err := lockMigrations(ctx, conn); err != nil {
+				return fmt.Errorf("database: failed to take the migration lock: %w", err)
+			}
+			// Unlock even if ctx is done, or the lock stays with the
+			// connection in the pool
+			defer conn.WithContext(context.WithoutCancel(ctx)).Exec("SELECT pg_advisory_unlock(?)", migrationLockID)
+			createTable = "CREATE TABLE IF NOT EXISTS schema_migrations (version bigint PRIMARY KEY, name varchar(255) NOT NULL, applied_at timestamptz NOT NULL)"
+		}
+		if err := conn.Exec(createTable).Error; err != nil {
+			return err
+		}
+
+		var rows []schemaMigration
+		if err := conn.Table("schema_migrations").Find(&rows).Error; err != nil {
+			return err
+		}
+		done := make(map[int]schemaMigration, len(rows))
+		for _, row := range rows {
+			done[row.Version] = row
+		}
+		return fn(conn, done)
+	})
+}
+
+// lockMigrations takes the session-level migration lock on conn, trying again
+// every migrationLockPoll until it is free or ctx is done.
+func lockMigrations(ctx context.Context, conn *gorm.DB) error {
+	for {
+		var locked bool
+		if err := conn.Raw("SELECT pg_try_advisory_lock(?)", migrationLockID).Scan(&locked).Error; err != nil {
+			return err
+		}
+		if locked {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(migrationLockPoll):
+		}
+	}
+}
+
+// runMigration runs sql, one direction of m, on conn and then record, which
+// updates schema_migrations to match: together in a transaction, or one
+// statement at a time if m is marked NoTransaction.
+func runMigration(conn *gorm.DB, m Migration, sql string, record func(tx *gorm.DB) error) error {
+	if !m.NoTransaction {
+		return conn.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(sql).Error; err != nil {
+				return fmt.Errorf("migration %04d_%s: %w", m.Version, m.Name, err)
+			}
+			return record(tx)
+		})
+	}
+
+	for _, stmt := range splitStatements(sql) {
+		if err := conn.Exec(stmt).Error; err != nil {
+			return fmt.Errorf("migration %04d_%s: %w", m.Version, m.Name, err)
+		}
+	}
+	return record(conn)
+}
+
+// splitStatements splits sql into statements at the semicolons that end a
+// line, leaving out comments on lines of their own.
+func splitStatements(sql string) []string {
+	var stmts []string
+	var stmt strings.Builder
+	for _, line := range strings.Split(sql, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		stmt.WriteString(line)
+		stmt.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			stmts = append(stmts, stmt.String())
+			stmt.Reset()
+		}
+	}
+	if strings.TrimSpace(stmt.String()) != "" {
+		stmts = append(stmts, stmt.String())
+	}
+	return stmts
+}
+
+// hasDirective reports whether the comments heading sql include directive on
+// a line of its own.
+func hasDirective(sql, directive string) bool {
+	for _, line := range strings.Split(sql, "\n") {
+		line = strings.TrimSpace(line)
+		if line == directive {
+			return true
+		}
+		if line != "" && !strings.HasPrefix(line, "--") {
+			return false
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestMigrateUpAndDown(t *testing.T) {
+	ctx := context.Background()
+	db := newTestSQLite(t)
+	migrations, err := Migrations(db)
+	if err != nil {
+		t.Fatalf("Migrations: %v", err)
+	}
+
+	if applied, err := MigrateUp(ctx, db); err != nil || len(applied) != 0 {
+		t.Errorf("MigrateUp on a migrated database applied %d, %v; want none", len(applied), err)
+	}
+	reverted, err := MigrateDown(ctx, db, len(migrations))
+	if err != nil || len(reverted) != len(migrations) {
+		t.Fatalf("MigrateDown reverted %d, %v; want %d", len(reverted), err, len(migrations))
+	}
+	pending, err := PendingMigrations(ctx, db)
+	if err != nil || len(pending) != len(migrations) {
+		t.Errorf("PendingMigrations = %d, %v; want %d", len(pending), err, len(migrations))
+	}
+	if applied, err := MigrateUp(ctx, db); err != nil || len(applied) != len(migrations) {
+		t.Errorf("MigrateUp applied %d, %v; want %d", len(applied), err, len(migrations))
+	}
+}
+
+func TestPostgresMigrations(t *testing.T) {
+	// Reading the migrations needs the dialect but no server
+	conn, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("gorm.Open: %v", err)
+	}
+	migrations, err := Migrations(&DB{Conn: conn})
+	if err != nil {
+		t.Fatalf("Migrations: %v", err)
+	}
+
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			t.Errorf("migration %04d_%s follows version %d", m.Version, m.Name, i)
+		}
+		if !m.NoTransaction {
+			continue
+		}
+		for _, sql := range []string{m.Up, m.Down} {
+			for _, stmt := range splitStatements(sql) {
+				if !strings.HasSuffix(strings.TrimSpace(stmt), ";") || strings.Count(stmt, ";") != 1 {
+					t.Errorf("migration %04d_%s: %q is not a single statement", m.Version, m.Name, stmt)
+				}
+				if strings.Contains(stmt, "INDEX") && !strings.Contains(stmt, "CONCURRENTLY") {
+					t.Errorf("migration %04d_%s: %q does not run concurrently", m.Version, m.Name, stmt)
+				}
+			}
+		}
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	sql := noTransaction + "\n-- A comment.\n\nCREATE INDEX a\n\tON t (x);\nDROP INDEX b;\n"
+	got := splitStatements(sql)
+	want := []string{"CREATE INDEX a\n\tON t (x);\n", "DROP INDEX b;\n"}
+	if len(got) != len(want) {
+		t.Fatalf("splitStatements = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("statement %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+
+	if !hasDirective(sql, noTransaction) {
+		t.Error("hasDirective missed the directive heading the file")
+	}
+	if hasDirective("CREATE INDEX a ON t (x);\n"+noTransaction+"\n", noTransaction) {
+		t.Error("hasDirective found the directive after a statement")
+	}
+}
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS prescriptions;
+DROP TABLE IF EXISTS patients;
@@ -0,0 +1,24 @@
+-- The tables as the first release defined them. Every later change to them is
+-- a migration of its own, written to also adopt a database that AutoMigrate
+-- already brought up to date, so any database those releases created can
+-- start from here.
+
+CREATE TABLE IF NOT EXISTS patients (
+	id bigserial PRIMARY KEY,
+	first_name varchar(100) NOT NULL,
+	last_name varchar(100) NOT NULL,
+	gender varchar(20),
+	email varchar(200),
+	phone varchar(50),
+	address varchar(500)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_patients_email ON patients (email);
+
+CREATE TABLE IF NOT EXISTS prescriptions (
+	id bigserial PRIMARY KEY,
+	medication varchar(255) NOT NULL,
+	dosage varchar(100),
+	frequency varchar(100),
+	quantity bigint,
+	notes text
+);
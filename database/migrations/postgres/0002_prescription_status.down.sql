@@ -0,0 +1,2 @@
+ALTER TABLE prescriptions DROP COLUMN IF EXISTS prescribed_at;
+ALTER TABLE prescriptions DROP COLUMN IF EXISTS status;
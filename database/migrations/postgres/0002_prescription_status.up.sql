@@ -0,0 +1,8 @@
+-- Existing prescriptions count as active and as prescribed when this runs. The
+-- application always sets prescribed_at, so its default only fills them in.
+
+ALTER TABLE prescriptions ADD COLUMN IF NOT EXISTS status varchar(20) NOT NULL DEFAULT 'active';
+ALTER TABLE prescriptions ADD COLUMN IF NOT EXISTS prescribed_at timestamptz NOT NULL DEFAULT now();
+ALTER TABLE prescriptions ALTER COLUMN prescribed_at DROP DEFAULT;
+CREATE INDEX IF NOT EXISTS idx_prescriptions_status ON prescriptions (status);
+CREATE INDEX IF NOT EXISTS idx_prescriptions_prescribed_at ON prescriptions (prescribed_at);
@@ -0,0 +1,7 @@
+ALTER TABLE prescriptions DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE prescriptions DROP COLUMN IF EXISTS updated_at;
+ALTER TABLE prescriptions DROP COLUMN IF EXISTS created_at;
+
+ALTER TABLE patients DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE patients DROP COLUMN IF EXISTS updated_at;
+ALTER TABLE patients DROP COLUMN IF EXISTS created_at;
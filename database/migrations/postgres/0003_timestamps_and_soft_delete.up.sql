@@ -0,0 +1,9 @@
+ALTER TABLE patients ADD COLUMN IF NOT EXISTS created_at timestamptz;
+ALTER TABLE patients ADD COLUMN IF NOT EXISTS updated_at timestamptz;
+ALTER TABLE patients ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+CREATE INDEX IF NOT EXISTS idx_patients_deleted_at ON patients (deleted_at);
+
+ALTER TABLE prescriptions ADD COLUMN IF NOT EXISTS created_at timestamptz;
+ALTER TABLE prescriptions ADD COLUMN IF NOT EXISTS updated_at timestamptz;
+ALTER TABLE prescriptions ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+CREATE INDEX IF NOT EXISTS idx_prescriptions_deleted_at ON prescriptions (deleted_at);
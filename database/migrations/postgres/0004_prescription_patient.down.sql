@@ -0,0 +1 @@
+ALTER TABLE prescriptions DROP COLUMN IF EXISTS patient_id;
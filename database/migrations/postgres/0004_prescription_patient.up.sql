@@ -0,0 +1,12 @@
+ALTER TABLE prescriptions ADD COLUMN IF NOT EXISTS patient_id bigint;
+CREATE INDEX IF NOT EXISTS idx_prescriptions_patient_id ON prescriptions (patient_id);
+
+-- Postgres has no ADD CONSTRAINT IF NOT EXISTS
+DO $$
+BEGIN
+	IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_patients_prescriptions') THEN
+		ALTER TABLE prescriptions ADD CONSTRAINT fk_patients_prescriptions
+			FOREIGN KEY (patient_id) REFERENCES patients (id) ON DELETE SET NULL ON UPDATE CASCADE;
+	END IF;
+END
+$$;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS audit_event_patients;
+DROP TABLE IF EXISTS audit_events;
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS audit_events (
+	id bigserial PRIMARY KEY,
+	created_at timestamptz NOT NULL,
+	actor varchar(255) NOT NULL,
+	method varchar(255) NOT NULL,
+	resources text,
+	outcome varchar(50) NOT NULL,
+	message text,
+	changes text
+);
+CREATE INDEX IF NOT EXISTS idx_audit_events_actor ON audit_events (actor);
+CREATE INDEX IF NOT EXISTS idx_audit_events_created_at ON audit_events (created_at);
+
+CREATE TABLE IF NOT EXISTS audit_event_patients (
+	audit_event_id bigint,
+	patient_id bigint,
+	PRIMARY KEY (audit_event_id, patient_id),
+	CONSTRAINT fk_audit_events_patients FOREIGN KEY (audit_event_id) REFERENCES audit_events (id)
+);
+CREATE INDEX IF NOT EXISTS idx_audit_event_patients_patient_id ON audit_event_patients (patient_id);
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS audit_event_patients_append_only ON audit_event_patients;
+DROP TRIGGER IF EXISTS audit_events_append_only ON audit_events;
+DROP FUNCTION IF EXISTS audit_append_only();
@@ -0,0 +1,15 @@
+-- The audit trail is append-only, even for callers that bypass the service.
+
+CREATE OR REPLACE FUNCTION audit_append_only() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION '% is append-only', TG_TABLE_NAME;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_events_append_only ON audit_events;
+CREATE TRIGGER audit_events_append_only BEFORE UPDATE OR DELETE ON audit_events
+	FOR EACH ROW EXECUTE FUNCTION audit_append_only();
+
+DROP TRIGGER IF EXISTS audit_event_patients_append_only ON audit_event_patients;
+CREATE TRIGGER audit_event_patients_append_only BEFORE UPDATE OR DELETE ON audit_event_patients
+	FOR EACH ROW EXECUTE FUNCTION audit_append_only();
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS care_team_members (
+	patient_id bigint,
+	member varchar(255),
+	created_at timestamptz NOT NULL,
+	PRIMARY KEY (patient_id, member),
+	CONSTRAINT fk_patients_care_team FOREIGN KEY (patient_id) REFERENCES patients (id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_care_team_members_member ON care_team_members (member);
@@ -0,0 +1,5 @@
+-- Trigram matching for the search indexes in the next migration. Creating an
+-- extension takes privileges the application's role may lack; an
+-- administrator can run this statement first, after which it is a no-op.
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
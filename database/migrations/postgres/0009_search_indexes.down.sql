@@ -0,0 +1,10 @@
+-- migrate:no-transaction
+
+DROP INDEX CONCURRENTLY IF EXISTS idx_prescriptions_patient_id_prescribed_at;
+DROP INDEX CONCURRENTLY IF EXISTS idx_prescriptions_medication_trgm;
+DROP INDEX CONCURRENTLY IF EXISTS idx_patients_last_name_id;
+DROP INDEX CONCURRENTLY IF EXISTS idx_patients_first_name_id;
+DROP INDEX CONCURRENTLY IF EXISTS idx_patients_phone_digits_trgm;
+DROP INDEX CONCURRENTLY IF EXISTS idx_patients_email_trgm;
+DROP INDEX CONCURRENTLY IF EXISTS idx_patients_last_name_trgm;
+DROP INDEX CONCURRENTLY IF EXISTS idx_patients_first_name_trgm;
@@ -0,0 +1,15 @@
+-- migrate:no-transaction
+-- Trigram indexes for substring search and b-tree indexes for keyset paging,
+-- built concurrently so that the tables stay writable meanwhile. A build that
+-- fails leaves an invalid index, which IF NOT EXISTS would keep: drop it
+-- before migrating again. The phone expression must stay identical to
+-- phoneDigitsExpr in search.go.
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_patients_first_name_trgm ON patients USING gin (lower(first_name) gin_trgm_ops);
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_patients_last_name_trgm ON patients USING gin (lower(last_name) gin_trgm_ops);
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_patients_email_trgm ON patients USING gin (lower(email) gin_trgm_ops);
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_patients_phone_digits_trgm ON patients USING gin ((replace(replace(replace(replace(replace(replace(phone, ' ', ''), '-', ''), '(', ''), ')', ''), '.', ''), '+', '')) gin_trgm_ops);
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_patients_first_name_id ON patients (first_name, id);
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_patients_last_name_id ON patients (last_name, id);
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_prescriptions_medication_trgm ON prescriptions USING gin (lower(medication) gin_trgm_ops);
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_prescriptions_patient_id_prescribed_at ON prescriptions (patient_id, prescribed_at, id);
@@ -0,0 +1,5 @@
+-- migrate:no-transaction
+-- Fails while more than one patient has no email.
+
+CREATE UNIQUE INDEX CONCURRENTLY IF NOT EXISTS idx_patients_email ON patients (email);
+DROP INDEX CONCURRENTLY IF EXISTS idx_patients_email_nonempty;
@@ -0,0 +1,6 @@
+-- migrate:no-transaction
+-- Email is optional, so only emails that are given need to be unique. The new
+-- index is built before the old one goes, so uniqueness holds throughout.
+
+CREATE UNIQUE INDEX CONCURRENTLY IF NOT EXISTS idx_patients_email_nonempty ON patients (email) WHERE email <> '';
+DROP INDEX CONCURRENTLY IF EXISTS idx_patients_email;
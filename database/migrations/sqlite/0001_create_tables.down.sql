@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS audit_event_patients;
+DROP TABLE IF EXISTS audit_events;
+DROP TABLE IF EXISTS care_team_members;
+DROP TABLE IF EXISTS prescriptions;
+DROP TABLE IF EXISTS patients;
@@ -0,0 +1,68 @@
+-- The tables as AutoMigrate created them in the first release with SQLite
+-- support, so that databases it set up adopt this migration unchanged. Unlike
+-- Postgres databases, no SQLite database has an older schema.
+
+CREATE TABLE IF NOT EXISTS patients (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	first_name text NOT NULL,
+	last_name text NOT NULL,
+	gender text,
+	email text,
+	phone text,
+	address text,
+	created_at datetime,
+	updated_at datetime,
+	deleted_at datetime
+);
+CREATE INDEX IF NOT EXISTS idx_patients_deleted_at ON patients (deleted_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_patients_email ON patients (email);
+
+CREATE TABLE IF NOT EXISTS prescriptions (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	patient_id integer,
+	medication text NOT NULL,
+	dosage text,
+	frequency text,
+	quantity integer,
+	notes text,
+	status text NOT NULL DEFAULT 'active',
+	prescribed_at datetime NOT NULL,
+	created_at datetime,
+	updated_at datetime,
+	deleted_at datetime,
+	CONSTRAINT fk_patients_prescriptions FOREIGN KEY (patient_id) REFERENCES patients (id) ON DELETE SET NULL ON UPDATE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_prescriptions_deleted_at ON prescriptions (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_prescriptions_prescribed_at ON prescriptions (prescribed_at);
+CREATE INDEX IF NOT EXISTS idx_prescriptions_status ON prescriptions (status);
+CREATE INDEX IF NOT EXISTS idx_prescriptions_patient_id ON prescriptions (patient_id);
+
+CREATE TABLE IF NOT EXISTS care_team_members (
+	patient_id integer,
+	member text,
+	created_at datetime NOT NULL,
+	PRIMARY KEY (patient_id, member),
+	CONSTRAINT fk_patients_care_team FOREIGN KEY (patient_id) REFERENCES patients (id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_care_team_members_member ON care_team_members (member);
+
+CREATE TABLE IF NOT EXISTS audit_events (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	created_at datetime NOT NULL,
+	actor text NOT NULL,
+	method text NOT NULL,
+	resources text,
+	outcome text NOT NULL,
+	message text,
+	changes text
+);
+CREATE INDEX IF NOT EXISTS idx_audit_events_actor ON audit_events (actor);
+CREATE INDEX IF NOT EXISTS idx_audit_events_created_at ON audit_events (created_at);
+
+CREATE TABLE IF NOT EXISTS audit_event_patients (
+	audit_event_id integer,
+	patient_id integer,
+	PRIMARY KEY (audit_event_id, patient_id),
+	CONSTRAINT fk_audit_events_patients FOREIGN KEY (audit_event_id) REFERENCES audit_events (id)
+);
+CREATE INDEX IF NOT EXISTS idx_audit_event_patients_patient_id ON audit_event_patients (patient_id);
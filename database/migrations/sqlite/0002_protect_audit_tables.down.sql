@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS audit_event_patients_append_only_delete;
+DROP TRIGGER IF EXISTS audit_event_patients_append_only_update;
+DROP TRIGGER IF EXISTS audit_events_append_only_delete;
+DROP TRIGGER IF EXISTS audit_events_append_only_update;
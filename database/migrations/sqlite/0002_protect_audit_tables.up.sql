@@ -0,0 +1,19 @@
+-- The audit trail is append-only, even for callers that bypass the service.
+-- SQLite triggers fire on one event each and cannot call functions.
+
+CREATE TRIGGER IF NOT EXISTS audit_events_append_only_update BEFORE UPDATE ON audit_events
+BEGIN
+	SELECT RAISE(ABORT, 'audit_events is append-only');
+END;
+CREATE TRIGGER IF NOT EXISTS audit_events_append_only_delete BEFORE DELETE ON audit_events
+BEGIN
+	SELECT RAISE(ABORT, 'audit_events is append-only');
+END;
+CREATE TRIGGER IF NOT EXISTS audit_event_patients_append_only_update BEFORE UPDATE ON audit_event_patients
+BEGIN
+	SELECT RAISE(ABORT, 'audit_event_patients is append-only');
+END;
+CREATE TRIGGER IF NOT EXISTS audit_event_patients_append_only_delete BEFORE DELETE ON audit_event_patients
+BEGIN
+	SELECT RAISE(ABORT, 'audit_event_patients is append-only');
+END;
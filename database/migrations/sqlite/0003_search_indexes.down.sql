@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_prescriptions_patient_id_prescribed_at;
+DROP INDEX IF EXISTS idx_patients_last_name_id;
+DROP INDEX IF EXISTS idx_patients_first_name_id;
@@ -0,0 +1,6 @@
+-- B-tree indexes for keyset paging. SQLite has no trigram indexes, so
+-- substring searches scan.
+
+CREATE INDEX IF NOT EXISTS idx_patients_first_name_id ON patients (first_name, id);
+CREATE INDEX IF NOT EXISTS idx_patients_last_name_id ON patients (last_name, id);
+CREATE INDEX IF NOT EXISTS idx_prescriptions_patient_id_prescribed_at ON prescriptions (patient_id, prescribed_at, id);
@@ -0,0 +1,4 @@
+-- Fails while more than one patient has no email.
+
+DROP INDEX IF EXISTS idx_patients_email_nonempty;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_patients_email ON patients (email);
@@ -0,0 +1,4 @@
+-- Email is optional, so only emails that are given need to be unique.
+
+DROP INDEX IF EXISTS idx_patients_email;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_patients_email_nonempty ON patients (email) WHERE email <> '';
@@ -1,26 +1,66 @@
 package database
 
-// Patient models a patient record.
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Patient models a patient record. Deleting a patient only sets DeletedAt;
+// gorm hides such rows from queries unless they are made Unscoped.
 type Patient struct {
 	ID uint `gorm:"primaryKey"`
 
 	FirstName string `gorm:"size:100;not null"`
 	LastName  string `gorm:"size:100;not null"`
 	Gender    string `gorm:"size:20"`
-	Email     string `gorm:"size:200;uniqueIndex"`
+	Email     string `gorm:"size:200;uniqueIndex:idx_patients_email_nonempty,where:email <> ''"`
 	Phone     string `gorm:"size:50"`
 	Address   string `gorm:"size:500"`
 
 	// One-to-many: Patient has multiple Prescriptions
 	Prescriptions []Prescription `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
+
+	// Callers allowed to see the patient under care-team-scoped roles
+	CareTeam []CareTeamMember `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
 }
 
-// Prescription models a medication prescription linked to a Patient.
+// Prescription statuses.
+const (
+	PrescriptionActive    = "active"
+	PrescriptionCompleted = "completed"
+	PrescriptionCancelled = "cancelled"
+)
+
+// Prescription models a medication prescription linked to a Patient. Like
+// patients, prescriptions are soft-deleted.
 type Prescription struct {
-	ID         uint   `gorm:"primaryKey"`
-	Medication string `gorm:"size:255;not null"`
-	Dosage     string `gorm:"size:100"`
-	Frequency  string `gorm:"size:100"`
-	Quantity   int
-	Notes      string `gorm:"type:text"`
+	ID           uint      `gorm:"primaryKey"`
+	PatientID    *uint     `gorm:"index"`
+	Medication   string    `gorm:"size:255;not null"`
+	Dosage       string    `gorm:"size:100"`
+	Frequency    string    `gorm:"size:100"`
+	Quantity     int
+	Notes        string    `gorm:"type:text"`
+	Status       string    `gorm:"size:20;not null;default:active;index"`
+	PrescribedAt time.Time `gorm:"not null;index"`
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+// BeforeCreate defaults the status to active and the prescription time to now.
+func (pr *Prescription) BeforeCreate(tx *gorm.DB) error {
+	if pr.Status == "" {
+		pr.Status = PrescriptionActive
+	}
+	if pr.PrescribedAt.IsZero() {
+		pr.PrescribedAt = time.Now().UTC()
+	}
+	return nil
 }
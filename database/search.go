@@ -0,0 +1,303 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Searchable patient fields, used in PatientCondition.Field and
+// PatientSearch.OrderBy.
+const (
+	PatientFieldID         = "id"
+	PatientFieldFirstName  = "first_name"
+	PatientFieldLastName   = "last_name"
+	PatientFieldName       = "name"
+	PatientFieldEmail      = "email"
+	PatientFieldPhone      = "phone"
+	PatientFieldMedication = "medication"
+)
+
+// phoneDigitsExpr strips the usual separators from patients.phone so that
+// searches match on digits alone. It is portable SQL and must stay identical to
+// the expression indexed by the search_indexes migration.
+const phoneDigitsExpr = "replace(replace(replace(replace(replace(replace(phone, ' ', ''), '-', ''), '(', ''), ')', ''), '.', ''), '+', '')"
+
+// PatientCondition is a single search condition on a patient field. Exact
+// conditions compare the whole value, otherwise the value is matched as a
+// substring. String comparisons are case-insensitive.
+type PatientCondition struct {
+	Field string
+	Value string
+	Exact bool
+}
+
+// SearchCursor is the keyset position of the last row of the previous page:
+// its ID and the value of the sort column.
+type SearchCursor struct {
+	ID  uint
+	Key string
+}
+
+// PatientSearch describes a patient search. All conditions must match.
+type PatientSearch struct {
+	Conditions []PatientCondition
+	// OrderBy is one of id, first_name, last_name or email; ties are broken by ID
+	// in the same direction. Empty sorts by ID.
+	OrderBy string
+	Desc    bool
+	// After resumes the search after the given row; nil starts at the beginning.
+	After *SearchCursor
+	Limit int
+}
+
+// SearchPatients returns the patients matching s, ordered and paged by keyset.
+func (db *DB) SearchPatients(ctx context.Context, s PatientSearch) ([]Patient, error) {
+	var patients []Patient
+	err := db.op(ctx, db.Timeouts.Search, func(conn *gorm.DB) error {
+		q, err := db.patientSearchQuery(conn, s)
+		if err != nil {
+			return err
+		}
+
+		var key interface{}
+		if s.After != nil {
+			key = s.After.Key
+		}
+		return keyset(q, s.OrderBy, s.Desc, s.After, key, s.Limit).Find(&patients).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return patients, nil
+}
+
+// CountPatientSearch returns the number of patients matching the conditions of
+// s, ignoring its ordering and paging.
+func (db *DB) CountPatientSearch(ctx context.Context, s PatientSearch) (int64, error) {
+	var n int64
+	err := db.op(ctx, db.Timeouts.Search, func(conn *gorm.DB) error {
+		q, err := db.patientSearchQuery(conn, s)
+		if err != nil {
+			return err
+		}
+		return q.Count(&n).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// PatientSortKey returns the value of the sort column of p, for building a
+// SearchCursor from the last row of a page.
+func PatientSortKey(p *Patient, orderBy string) string {
+	switch orderBy {
+	case PatientFieldFirstName:
+		return p.FirstName
+	case PatientFieldLastName:
+		return p.LastName
+	case PatientFieldEmail:
+		return p.Email
+	default:
+		return ""
+	}
+}
+
+// Sortable prescription fields, used in PrescriptionSearch.OrderBy.
+const (
+	PrescriptionFieldID           = "id"
+	PrescriptionFieldMedication   = "medication"
+	PrescriptionFieldPrescribedAt = "prescribed_at"
+)
+
+// PrescriptionSearch describes a prescription listing. Zero-valued filters do
+// not restrict the results.
+type PrescriptionSearch struct {
+	// PatientID restricts the listing to one patient; 0 lists every patient.
+	PatientID uint
+	// Medication matches a case-insensitive substring of the medication name.
+	Medication string
+	Status     string
+	// PrescribedAfter and PrescribedBefore bound PrescribedAt to the half-open
+	// range [PrescribedAfter, PrescribedBefore).
+	PrescribedAfter  time.Time
+	PrescribedBefore time.Time
+	// OrderBy is one of id, medication or prescribed_at; ties are broken by ID
+	// in the same direction. Empty sorts by ID.
+	OrderBy string
+	Desc    bool
+	After   *SearchCursor
+	Limit   int
+}
+
+// SearchPrescriptions returns the prescriptions matching s, ordered and paged
+// by keyset.
+func (db *DB) SearchPrescriptions(ctx context.Context, s PrescriptionSearch) ([]Prescription, error) {
+	var key interface{}
+	if s.After != nil {
+		key = s.After.Key
+		if s.OrderBy == PrescriptionFieldPrescribedAt {
+			t, err := time.Parse(time.RFC3339Nano, s.After.Key)
+			if err != nil {
+				return nil, fmt.Errorf("database: invalid prescribed_at cursor %q: %w", s.After.Key, err)
+			}
+			key = t
+		}
+	}
+
+	var list []Prescription
+	err := db.op(ctx, db.Timeouts.Search, func(conn *gorm.DB) error {
+		q, err := db.prescriptionSearchQuery(conn, s)
+		if err != nil {
+			return err
+		}
+		return keyset(q, s.OrderBy, s.Desc, s.After, key, s.Limit).Find(&list).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// CountPrescriptionSearch returns the number of prescriptions matching the
+// filters of s, ignoring its ordering and paging.
+func (db *DB) CountPrescriptionSearch(ctx context.Context, s PrescriptionSearch) (int64, error) {
+	var n int64
+	err := db.op(ctx, db.Timeouts.Search, func(conn *gorm.DB) error {
+		q, err := db.prescriptionSearchQuery(conn, s)
+		if err != nil {
+			return err
+		}
+		return q.Count(&n).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// PrescriptionSortKey returns the value of the sort column of pr, for building
+// a SearchCursor from the last row of a page.
+func PrescriptionSortKey(pr *Prescription, orderBy string) string {
+	switch orderBy {
+	case PrescriptionFieldMedication:
+		return pr.Medication
+	case PrescriptionFieldPrescribedAt:
+		return pr.PrescribedAt.UTC().Format(time.RFC3339Nano)
+	default:
+		return ""
+	}
+}
+
+// prescriptionSearchQuery applies the filters of s to a query on prescriptions
+// over conn.
+func (db *DB) prescriptionSearchQuery(conn *gorm.DB, s PrescriptionSearch) (*gorm.DB, error) {
+	switch s.OrderBy {
+	case "", PrescriptionFieldID, PrescriptionFieldMedication, PrescriptionFieldPrescribedAt:
+	default:
+		return nil, fmt.Errorf("database: cannot order prescriptions by %q", s.OrderBy)
+	}
+
+	q := db.careTeamScope(conn.Model(&Prescription{}), "patient_id")
+	if s.PatientID != 0 {
+		q = q.Where("patient_id = ?", s.PatientID)
+	}
+	if s.Medication != "" {
+		q = q.Where(`lower(medication) LIKE ? ESCAPE '\'`, "%"+escapeLike(strings.ToLower(s.Medication))+"%")
+	}
+	if s.Status != "" {
+		q = q.Where("status = ?", s.Status)
+	}
+	if !s.PrescribedAfter.IsZero() {
+		q = q.Where("prescribed_at >= ?", s.PrescribedAfter)
+	}
+	if !s.PrescribedBefore.IsZero() {
+		q = q.Where("prescribed_at < ?", s.PrescribedBefore)
+	}
+	return q, nil
+}
+
+// patientSearchQuery applies the conditions of s to a query on patients over
+// conn.
+func (db *DB) patientSearchQuery(conn *gorm.DB, s PatientSearch) (*gorm.DB, error) {
+	switch s.OrderBy {
+	case "", PatientFieldID, PatientFieldFirstName, PatientFieldLastName, PatientFieldEmail:
+	default:
+		return nil, fmt.Errorf("database: cannot order patients by %q", s.OrderBy)
+	}
+
+	q := db.careTeamScope(conn.Model(&Patient{}), "id")
+	for _, c := range s.Conditions {
+		value := strings.ToLower(c.Value)
+		pattern := "%" + escapeLike(value) + "%"
+
+		switch c.Field {
+		case PatientFieldFirstName, PatientFieldLastName, PatientFieldEmail:
+			if c.Exact {
+				q = q.Where(fmt.Sprintf("lower(%s) = ?", c.Field), value)
+			} else {
+				q = q.Where(fmt.Sprintf(`lower(%s) LIKE ? ESCAPE '\'`, c.Field), pattern)
+			}
+		case PatientFieldName:
+			if c.Exact {
+				q = q.Where("(lower(first_name) = ? OR lower(last_name) = ?)", value, value)
+			} else {
+				q = q.Where(`(lower(first_name) LIKE ? ESCAPE '\' OR lower(last_name) LIKE ? ESCAPE '\')`, pattern, pattern)
+			}
+		case PatientFieldPhone:
+			if c.Exact {
+				q = q.Where(phoneDigitsExpr+" = ?", c.Value)
+			} else {
+				q = q.Where(phoneDigitsExpr+` LIKE ? ESCAPE '\'`, "%"+escapeLike(c.Value)+"%")
+			}
+		case PatientFieldMedication:
+			// Only current prescriptions count: "everyone on warfarin"
+			if c.Exact {
+				q = q.Where("id IN (SELECT patient_id FROM prescriptions WHERE deleted_at IS NULL AND status = ? AND lower(medication) = ?)", PrescriptionActive, value)
+			} else {
+				q = q.Where(`id IN (SELECT patient_id FROM prescriptions WHERE deleted_at IS NULL AND status = ? AND lower(medication) LIKE ? ESCAPE '\')`, PrescriptionActive, pattern)
+			}
+		default:
+			return nil, fmt.Errorf("database: cannot search patients by %q", c.Field)
+		}
+	}
+	return q, nil
+}
+
+// keyset orders q by col and ID in the given direction and, when after is set,
+// resumes strictly after that row. key is the sort column value of the cursor
+// row converted to the column's type. An empty col orders by ID alone.
+func keyset(q *gorm.DB, col string, desc bool, after *SearchCursor, key interface{}, limit int) *gorm.DB {
+	if col == "" {
+		col = "id"
+	}
+	dir, cmp := "ASC", ">"
+	if desc {
+		dir, cmp = "DESC", "<"
+	}
+
+	if after != nil {
+		if col == "id" {
+			q = q.Where(fmt.Sprintf("id %s ?", cmp), after.ID)
+		} else {
+			q = q.Where(fmt.Sprintf("(%[1]s %[2]s ? OR (%[1]s = ? AND id %[2]s ?))", col, cmp), key, key, after.ID)
+		}
+	}
+	if col != "id" {
+		q = q.Order(fmt.Sprintf("%s %s", col, dir))
+	}
+	q = q.Order("id " + dir)
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+	return q
+}
+
+// escapeLike escapes the LIKE wildcards in s so it matches literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
@@ -0,0 +1,79 @@
+package database
+
+import "context"
+
+// PatientStore reads and writes patients. Lookups of a missing or deleted
+// patient fail with gorm.ErrRecordNotFound and writes reusing another
+// patient's email with gorm.ErrDuplicatedKey, or a driver error wrapping it.
+type PatientStore interface {
+	GetPatientByID(ctx context.Context, id uint) (*Patient, error)
+	ListPatientsAfter(ctx context.Context, afterID uint, offset, limit int) ([]Patient, error)
+	CountPatients(ctx context.Context) (int64, error)
+	EstimatePatientCount(ctx context.Context) (int64, error)
+	CheckPatientExists(ctx context.Context, id uint) error
+	CreatePatient(ctx context.Context, p *Patient) error
+	UpdatePatient(ctx context.Context, p *Patient) error
+	UpdatePatientFields(ctx context.Context, id uint, fields map[string]interface{}) error
+	DeletePatient(ctx context.Context, id uint) error
+	UndeletePatient(ctx context.Context, id uint) error
+	SearchPatients(ctx context.Context, s PatientSearch) ([]Patient, error)
+	CountPatientSearch(ctx context.Context, s PatientSearch) (int64, error)
+}
+
+// PrescriptionStore reads and writes prescriptions. Lookups of a missing or
+// deleted prescription fail with gorm.ErrRecordNotFound and writes naming a
+// patient that does not exist with gorm.ErrForeignKeyViolated, or a driver
+// error wrapping it.
+type PrescriptionStore interface {
+	GetPrescriptionByID(ctx context.Context, id uint) (*Prescription, error)
+	CreatePrescription(ctx context.Context, pr *Prescription) error
+	CreatePrescriptionForPatient(ctx context.Context, patientID uint, pr *Prescription) error
+	UpdatePrescription(ctx context.Context, pr *Prescription) error
+	UpdatePrescriptionFields(ctx context.Context, id uint, fields map[string]interface{}) error
+	DeletePrescription(ctx context.Context, id uint) error
+	SearchPrescriptions(ctx context.Context, s PrescriptionSearch) ([]Prescription, error)
+	CountPrescriptionSearch(ctx context.Context, s PrescriptionSearch) (int64, error)
+}
+
+// CareTeamStore reads and writes patients' care teams.
+type CareTeamStore interface {
+	IsCareTeamMember(ctx context.Context, patientID uint, member string) (bool, error)
+	AddCareTeamMember(ctx context.Context, patientID uint, member string) (*CareTeamMember, error)
+	RemoveCareTeamMember(ctx context.Context, patientID uint, member string) error
+	ListCareTeam(ctx context.Context, patientID uint) ([]CareTeamMember, error)
+}
+
+// AuditStore appends to and reads the audit trail.
+type AuditStore interface {
+	CreateAuditEvent(ctx context.Context, ev *AuditEvent) error
+	ListAuditEvents(ctx context.Context, s AuditSearch) ([]AuditEvent, error)
+}
+
+// Store is the storage behind the API. DB stores in Postgres and MemoryStore
+// in memory, for tests and local development.
+type Store interface {
+	PatientStore
+	PrescriptionStore
+	CareTeamStore
+	AuditStore
+
+	// Transaction runs fn as a unit of work on tx, a view of the store whose
+	// changes are committed together if fn returns nil and discarded
+	// otherwise. fn may run more than once and must only use tx.
+	Transaction(ctx context.Context, fn func(tx Store) error) error
+	// ReadTransaction runs fn, which must only read, on tx, a view of the
+	// store as of one moment. Unlike Transaction it does not contend with
+	// concurrent writes.
+	ReadTransaction(ctx context.Context, fn func(tx Store) error) error
+	// WithDeleted returns a view of the store that also sees soft-deleted
+	// rows.
+	WithDeleted() Store
+	// ForCareTeam returns a view of the store whose patient and prescription
+	// listings only include patients in member's care team.
+	ForCareTeam(member string) Store
+}
+
+var (
+	_ Store = (*DB)(nil)
+	_ Store = (*MemoryStore)(nil)
+)
@@ -0,0 +1,443 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	gosqlite "github.com/glebarez/go-sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// SQLite extended result codes the contract tests accept in place of gorm's
+// errors, which DB passes on untranslated.
+const (
+	sqliteUniqueViolation     = 2067
+	sqliteForeignKeyViolation = 787
+)
+
+// storeFactories open an empty store of each kind that must honour the Store
+// contract.
+var storeFactories = map[string]func(t *testing.T) Store{
+	"memory": func(t *testing.T) Store { return NewMemoryStore() },
+	"sqlite": func(t *testing.T) Store { return newTestSQLite(t) },
+}
+
+// newTestSQLite returns a migrated in-memory SQLite database, closed when the
+// test ends.
+func newTestSQLite(t *testing.T) *DB {
+	t.Helper()
+	db, err := NewSQLite(":memory:", logger.Discard)
+	if err != nil {
+		t.Fatalf("NewSQLite: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if _, err := MigrateUp(context.Background(), db); err != nil {
+		t.Fatalf("MigrateUp: %v", err)
+	}
+	return db
+}
+
+// forEachStore runs test as a subtest against a fresh store of each kind.
+func forEachStore(t *testing.T, test func(t *testing.T, ctx context.Context, st Store)) {
+	for name, open := range storeFactories {
+		t.Run(name, func(t *testing.T) {
+			test(t, context.Background(), open(t))
+		})
+	}
+}
+
+func isDuplicate(err error) bool {
+	var sqliteErr *gosqlite.Error
+	return errors.Is(err, gorm.ErrDuplicatedKey) || errors.As(err, &sqliteErr) && sqliteErr.Code() == sqliteUniqueViolation
+}
+
+func isForeignKeyViolation(err error) bool {
+	var sqliteErr *gosqlite.Error
+	return errors.Is(err, gorm.ErrForeignKeyViolated) || errors.As(err, &sqliteErr) && sqliteErr.Code() == sqliteForeignKeyViolation
+}
+
+func mustCreatePatient(t *testing.T, ctx context.Context, st Store, p *Patient) *Patient {
+	t.Helper()
+	if err := st.CreatePatient(ctx, p); err != nil {
+		t.Fatalf("CreatePatient(%s %s): %v", p.FirstName, p.LastName, err)
+	}
+	return p
+}
+
+func patientIDs(patients []Patient) []uint {
+	ids := make([]uint, len(patients))
+	for i, p := range patients {
+		ids[i] = p.ID
+	}
+	return ids
+}
+
+func equalIDs(a, b []uint) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestStorePatients(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ctx context.Context, st Store) {
+		p := mustCreatePatient(t, ctx, st, &Patient{FirstName: "Ann", LastName: "Lee", Email: "ann@example.com"})
+		if p.ID == 0 {
+			t.Fatal("CreatePatient left the ID unset")
+		}
+
+		got, err := st.GetPatientByID(ctx, p.ID)
+		if err != nil {
+			t.Fatalf("GetPatientByID: %v", err)
+		}
+		if got.FirstName != "Ann" || got.Email != "ann@example.com" {
+			t.Errorf("GetPatientByID = %+v", got)
+		}
+
+		if err := st.UpdatePatientFields(ctx, p.ID, map[string]interface{}{"first_name": "Anne", "phone": ""}); err != nil {
+			t.Fatalf("UpdatePatientFields: %v", err)
+		}
+		if got, _ := st.GetPatientByID(ctx, p.ID); got.FirstName != "Anne" {
+			t.Errorf("first name after update = %q, want Anne", got.FirstName)
+		}
+
+		if _, err := st.GetPatientByID(ctx, p.ID+100); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Errorf("GetPatientByID(missing) = %v, want ErrRecordNotFound", err)
+		}
+		if err := st.UpdatePatientFields(ctx, p.ID+100, map[string]interface{}{"first_name": "X"}); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Errorf("UpdatePatientFields(missing) = %v, want ErrRecordNotFound", err)
+		}
+		if err := st.CheckPatientExists(ctx, p.ID+100); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Errorf("CheckPatientExists(missing) = %v, want ErrRecordNotFound", err)
+		}
+	})
+}
+
+func TestStorePatientEmails(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ctx context.Context, st Store) {
+		a := mustCreatePatient(t, ctx, st, &Patient{FirstName: "Ann", LastName: "Lee", Email: "ann@example.com"})
+		b := mustCreatePatient(t, ctx, st, &Patient{FirstName: "Bob", LastName: "Ray"})
+
+		// Any number of patients may have no email
+		mustCreatePatient(t, ctx, st, &Patient{FirstName: "Cy", LastName: "Fox"})
+
+		if err := st.CreatePatient(ctx, &Patient{FirstName: "Dee", LastName: "Lee", Email: "ann@example.com"}); !isDuplicate(err) {
+			t.Errorf("CreatePatient with a taken email = %v, want a duplicate key error", err)
+		}
+		if err := st.UpdatePatientFields(ctx, b.ID, map[string]interface{}{"email": "ann@example.com"}); !isDuplicate(err) {
+			t.Errorf("UpdatePatientFields to a taken email = %v, want a duplicate key error", err)
+		}
+
+		// A deleted patient's email stays taken until it is purged
+		if err := st.DeletePatient(ctx, a.ID); err != nil {
+			t.Fatalf("DeletePatient: %v", err)
+		}
+		if err := st.CreatePatient(ctx, &Patient{FirstName: "Eve", LastName: "Lee", Email: "ann@example.com"}); !isDuplicate(err) {
+			t.Errorf("CreatePatient with a deleted patient's email = %v, want a duplicate key error", err)
+		}
+	})
+}
+
+func TestStoreSoftDelete(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ctx context.Context, st Store) {
+		p := mustCreatePatient(t, ctx, st, &Patient{FirstName: "Ann", LastName: "Lee"})
+		mustCreatePatient(t, ctx, st, &Patient{FirstName: "Bob", LastName: "Ray"})
+
+		if err := st.DeletePatient(ctx, p.ID); err != nil {
+			t.Fatalf("DeletePatient: %v", err)
+		}
+		if err := st.DeletePatient(ctx, p.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Errorf("DeletePatient twice = %v, want ErrRecordNotFound", err)
+		}
+		if _, err := st.GetPatientByID(ctx, p.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Errorf("GetPatientByID(deleted) = %v, want ErrRecordNotFound", err)
+		}
+		if n, _ := st.CountPatients(ctx); n != 1 {
+			t.Errorf("CountPatients = %d, want 1", n)
+		}
+
+		got, err := st.WithDeleted().GetPatientByID(ctx, p.ID)
+		if err != nil {
+			t.Fatalf("WithDeleted().GetPatientByID: %v", err)
+		}
+		if !got.DeletedAt.Valid {
+			t.Error("deleted patient has no DeletedAt")
+		}
+		if n, _ := st.WithDeleted().CountPatients(ctx); n != 2 {
+			t.Errorf("WithDeleted().CountPatients = %d, want 2", n)
+		}
+
+		if err := st.UndeletePatient(ctx, p.ID); err != nil {
+			t.Fatalf("UndeletePatient: %v", err)
+		}
+		if err := st.UndeletePatient(ctx, p.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Errorf("UndeletePatient twice = %v, want ErrRecordNotFound", err)
+		}
+		if _, err := st.GetPatientByID(ctx, p.ID); err != nil {
+			t.Errorf("GetPatientByID(undeleted): %v", err)
+		}
+	})
+}
+
+func TestStoreListPatientsAfter(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ctx context.Context, st Store) {
+		var ids []uint
+		for _, name := range []string{"A", "B", "C", "D", "E"} {
+			ids = append(ids, mustCreatePatient(t, ctx, st, &Patient{FirstName: name, LastName: name}).ID)
+		}
+
+		page, err := st.ListPatientsAfter(ctx, 0, 0, 2)
+		if err != nil {
+			t.Fatalf("ListPatientsAfter: %v", err)
+		}
+		if want := []uint{ids[4], ids[3]}; !equalIDs(patientIDs(page), want) {
+			t.Errorf("first page = %v, want %v", patientIDs(page), want)
+		}
+		page, _ = st.ListPatientsAfter(ctx, ids[3], 0, 2)
+		if want := []uint{ids[2], ids[1]}; !equalIDs(patientIDs(page), want) {
+			t.Errorf("second page = %v, want %v", patientIDs(page), want)
+		}
+		page, _ = st.ListPatientsAfter(ctx, ids[1], 0, 2)
+		if want := []uint{ids[0]}; !equalIDs(patientIDs(page), want) {
+			t.Errorf("last page = %v, want %v", patientIDs(page), want)
+		}
+		page, _ = st.ListPatientsAfter(ctx, 0, 3, 10)
+		if want := []uint{ids[1], ids[0]}; !equalIDs(patientIDs(page), want) {
+			t.Errorf("page at offset 3 = %v, want %v", patientIDs(page), want)
+		}
+	})
+}
+
+func TestStoreSearchPatients(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ctx context.Context, st Store) {
+		ann := mustCreatePatient(t, ctx, st, &Patient{FirstName: "Ann", LastName: "Lee", Email: "ann@example.com", Phone: "+1 (555) 123-4567"})
+		bob := mustCreatePatient(t, ctx, st, &Patient{FirstName: "Bob", LastName: "Annson", Email: "bob@example.com"})
+		if err := st.CreatePrescriptionForPatient(ctx, bob.ID, &Prescription{Medication: "Warfarin"}); err != nil {
+			t.Fatalf("CreatePrescriptionForPatient: %v", err)
+		}
+
+		tests := []struct {
+			cond PatientCondition
+			want []uint
+		}{
+			{PatientCondition{Field: PatientFieldName, Value: "ann"}, []uint{ann.ID, bob.ID}},
+			{PatientCondition{Field: PatientFieldFirstName, Value: "ann"}, []uint{ann.ID}},
+			{PatientCondition{Field: PatientFieldPhone, Value: "555123"}, []uint{ann.ID}},
+			{PatientCondition{Field: PatientFieldMedication, Value: "warf"}, []uint{bob.ID}},
+			{PatientCondition{Field: PatientFieldEmail, Value: "BOB@example.com", Exact: true}, []uint{bob.ID}},
+			{PatientCondition{Field: PatientFieldEmail, Value: "bob", Exact: true}, []uint{}},
+		}
+		for _, tt := range tests {
+			s := PatientSearch{Conditions: []PatientCondition{tt.cond}}
+			got, err := st.SearchPatients(ctx, s)
+			if err != nil {
+				t.Errorf("SearchPatients(%+v): %v", tt.cond, err)
+				continue
+			}
+			if !equalIDs(patientIDs(got), tt.want) {
+				t.Errorf("SearchPatients(%+v) = %v, want %v", tt.cond, patientIDs(got), tt.want)
+			}
+			if n, _ := st.CountPatientSearch(ctx, s); n != int64(len(tt.want)) {
+				t.Errorf("CountPatientSearch(%+v) = %d, want %d", tt.cond, n, len(tt.want))
+			}
+		}
+
+		// Keyset paging by last name: Annson, then Lee
+		page, err := st.SearchPatients(ctx, PatientSearch{OrderBy: PatientFieldLastName, Limit: 1})
+		if err != nil || len(page) != 1 || page[0].ID != bob.ID {
+			t.Fatalf("first page by last name = %v, %v", patientIDs(page), err)
+		}
+		after := &SearchCursor{ID: page[0].ID, Key: PatientSortKey(&page[0], PatientFieldLastName)}
+		page, _ = st.SearchPatients(ctx, PatientSearch{OrderBy: PatientFieldLastName, Limit: 1, After: after})
+		if len(page) != 1 || page[0].ID != ann.ID {
+			t.Errorf("second page by last name = %v, want [%d]", patientIDs(page), ann.ID)
+		}
+	})
+}
+
+func TestStorePrescriptions(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ctx context.Context, st Store) {
+		p := mustCreatePatient(t, ctx, st, &Patient{FirstName: "Ann", LastName: "Lee"})
+
+		pr := &Prescription{Medication: "Aspirin", PrescribedAt: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+		if err := st.CreatePrescriptionForPatient(ctx, p.ID, pr); err != nil {
+			t.Fatalf("CreatePrescriptionForPatient: %v", err)
+		}
+		if pr.PatientID == nil || *pr.PatientID != p.ID || pr.Status != PrescriptionActive {
+			t.Errorf("created prescription = %+v", pr)
+		}
+		if err := st.CreatePrescriptionForPatient(ctx, p.ID+100, &Prescription{Medication: "X"}); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Errorf("CreatePrescriptionForPatient(missing patient) = %v, want ErrRecordNotFound", err)
+		}
+		missing := p.ID + 100
+		if err := st.CreatePrescription(ctx, &Prescription{Medication: "X", PatientID: &missing}); !isForeignKeyViolation(err) {
+			t.Errorf("CreatePrescription(missing patient) = %v, want a foreign key violation", err)
+		}
+
+		if err := st.UpdatePrescriptionFields(ctx, pr.ID, map[string]interface{}{"status": "completed", "quantity": 3}); err != nil {
+			t.Fatalf("UpdatePrescriptionFields: %v", err)
+		}
+		got, err := st.GetPrescriptionByID(ctx, pr.ID)
+		if err != nil {
+			t.Fatalf("GetPrescriptionByID: %v", err)
+		}
+		if got.Status != "completed" || got.Quantity != 3 {
+			t.Errorf("prescription after update = %+v", got)
+		}
+
+		list, err := st.SearchPrescriptions(ctx, PrescriptionSearch{PatientID: p.ID})
+		if err != nil || len(list) != 1 {
+			t.Errorf("SearchPrescriptions(patient) = %d prescriptions, %v; want 1", len(list), err)
+		}
+		if list, _ := st.SearchPrescriptions(ctx, PrescriptionSearch{PatientID: missing}); len(list) != 0 {
+			t.Errorf("SearchPrescriptions(missing patient) = %d prescriptions, want none", len(list))
+		}
+
+		if err := st.DeletePrescription(ctx, pr.ID); err != nil {
+			t.Fatalf("DeletePrescription: %v", err)
+		}
+		if _, err := st.GetPrescriptionByID(ctx, pr.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Errorf("GetPrescriptionByID(deleted) = %v, want ErrRecordNotFound", err)
+		}
+		if n, _ := st.WithDeleted().CountPrescriptionSearch(ctx, PrescriptionSearch{}); n != 1 {
+			t.Errorf("WithDeleted().CountPrescriptionSearch = %d, want 1", n)
+		}
+	})
+}
+
+func TestStoreCareTeam(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ctx context.Context, st Store) {
+		mine := mustCreatePatient(t, ctx, st, &Patient{FirstName: "Ann", LastName: "Lee"})
+		other := mustCreatePatient(t, ctx, st, &Patient{FirstName: "Bob", LastName: "Ray"})
+		for _, p := range []*Patient{mine, other} {
+			if err := st.CreatePrescriptionForPatient(ctx, p.ID, &Prescription{Medication: "Aspirin"}); err != nil {
+				t.Fatalf("CreatePrescriptionForPatient: %v", err)
+			}
+		}
+
+		m, err := st.AddCareTeamMember(ctx, mine.ID, "dr-a")
+		if err != nil || m.Member != "dr-a" {
+			t.Fatalf("AddCareTeamMember = %+v, %v", m, err)
+		}
+		if _, err := st.AddCareTeamMember(ctx, mine.ID, "dr-a"); err != nil {
+			t.Errorf("AddCareTeamMember again: %v", err)
+		}
+		if _, err := st.AddCareTeamMember(ctx, other.ID+100, "dr-a"); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Errorf("AddCareTeamMember(missing patient) = %v, want ErrRecordNotFound", err)
+		}
+		if ok, _ := st.IsCareTeamMember(ctx, mine.ID, "dr-a"); !ok {
+			t.Error("IsCareTeamMember = false after adding")
+		}
+
+		scoped := st.ForCareTeam("dr-a")
+		if n, _ := scoped.CountPatients(ctx); n != 1 {
+			t.Errorf("scoped CountPatients = %d, want 1", n)
+		}
+		page, _ := scoped.ListPatientsAfter(ctx, 0, 0, 10)
+		if want := []uint{mine.ID}; !equalIDs(patientIDs(page), want) {
+			t.Errorf("scoped ListPatientsAfter = %v, want %v", patientIDs(page), want)
+		}
+		found, _ := scoped.SearchPatients(ctx, PatientSearch{})
+		if want := []uint{mine.ID}; !equalIDs(patientIDs(found), want) {
+			t.Errorf("scoped SearchPatients = %v, want %v", patientIDs(found), want)
+		}
+		if n, _ := scoped.CountPrescriptionSearch(ctx, PrescriptionSearch{}); n != 1 {
+			t.Errorf("scoped CountPrescriptionSearch = %d, want 1", n)
+		}
+
+		if err := st.RemoveCareTeamMember(ctx, mine.ID, "dr-a"); err != nil {
+			t.Fatalf("RemoveCareTeamMember: %v", err)
+		}
+		if err := st.RemoveCareTeamMember(ctx, mine.ID, "dr-a"); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Errorf("RemoveCareTeamMember twice = %v, want ErrRecordNotFound", err)
+		}
+		if n, _ := scoped.CountPatients(ctx); n != 0 {
+			t.Errorf("scoped CountPatients after removal = %d, want 0", n)
+		}
+	})
+}
+
+func TestStoreAuditEvents(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ctx context.Context, st Store) {
+		p := mustCreatePatient(t, ctx, st, &Patient{FirstName: "Ann", LastName: "Lee"})
+		ev := &AuditEvent{Actor: "me", Method: "/serverpb.Api/GetPatient", Outcome: "OK", Patients: []AuditEventPatient{{PatientID: p.ID}}}
+		if err := st.CreateAuditEvent(ctx, ev); err != nil {
+			t.Fatalf("CreateAuditEvent: %v", err)
+		}
+		if err := st.CreateAuditEvent(ctx, &AuditEvent{Actor: "you", Method: "/serverpb.Api/ListPatients", Outcome: "OK"}); err != nil {
+			t.Fatalf("CreateAuditEvent: %v", err)
+		}
+
+		events, err := st.ListAuditEvents(ctx, AuditSearch{PatientID: p.ID})
+		if err != nil || len(events) != 1 || events[0].ID != ev.ID {
+			t.Errorf("ListAuditEvents(patient) = %d events, %v; want event %d", len(events), err, ev.ID)
+		}
+		events, _ = st.ListAuditEvents(ctx, AuditSearch{Actor: "you"})
+		if len(events) != 1 || events[0].Actor != "you" {
+			t.Errorf("ListAuditEvents(actor) = %+v", events)
+		}
+	})
+}
+
+func TestStoreTransaction(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ctx context.Context, st Store) {
+		failure := errors.New("failed")
+		err := st.Transaction(ctx, func(tx Store) error {
+			mustCreatePatient(t, ctx, tx, &Patient{FirstName: "Ann", LastName: "Lee"})
+			return failure
+		})
+		if !errors.Is(err, failure) {
+			t.Fatalf("Transaction = %v, want %v", err, failure)
+		}
+		if n, _ := st.CountPatients(ctx); n != 0 {
+			t.Errorf("CountPatients after rollback = %d, want 0", n)
+		}
+
+		err = st.Transaction(ctx, func(tx Store) error {
+			mustCreatePatient(t, ctx, tx, &Patient{FirstName: "Bob", LastName: "Ray"})
+			// A failed nested transaction only undoes its own work
+			inner := tx.Transaction(ctx, func(tx Store) error {
+				mustCreatePatient(t, ctx, tx, &Patient{FirstName: "Cy", LastName: "Fox"})
+				return failure
+			})
+			if !errors.Is(inner, failure) {
+				t.Errorf("nested Transaction = %v, want %v", inner, failure)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Transaction: %v", err)
+		}
+		if n, _ := st.CountPatients(ctx); n != 1 {
+			t.Errorf("CountPatients after commit = %d, want 1", n)
+		}
+
+		var n int64
+		err = st.ReadTransaction(ctx, func(tx Store) error {
+			var err error
+			n, err = tx.CountPatients(ctx)
+			return err
+		})
+		if err != nil || n != 1 {
+			t.Errorf("ReadTransaction counted %d, %v; want 1", n, err)
+		}
+	})
+}
+
+func TestStoreCanceledContext(t *testing.T) {
+	forEachStore(t, func(t *testing.T, _ context.Context, st Store) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, err := st.GetPatientByID(ctx, 1); !errors.Is(err, context.Canceled) {
+			t.Errorf("GetPatientByID with a canceled context = %v, want context.Canceled", err)
+		}
+	})
+}
@@ -0,0 +1,70 @@
+package database
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+var tracer = otel.Tracer("github.com/hcliff-zhang/playground/database")
+
+// querySpanKey holds a statement's span while it runs.
+const querySpanKey = "database:query_span"
+
+// Trace records an OpenTelemetry span, named like "query patients", for every
+// statement run on db or any view of it, as a child of the span in the
+// statement's context; see WithContext. Spans carry the SQL with placeholders
+// but never the values bound to them, which may be patient data.
+func Trace(db *DB) error {
+	before := func(operation string) func(*gorm.DB) {
+		return func(tx *gorm.DB) {
+			ctx, span := tracer.Start(tx.Statement.Context, operation, trace.WithSpanKind(trace.SpanKindClient))
+			tx.Statement.Context = ctx
+			tx.InstanceSet(querySpanKey, span)
+		}
+	}
+	after := func(operation string) func(*gorm.DB) {
+		return func(tx *gorm.DB) {
+			v, _ := tx.InstanceGet(querySpanKey)
+			span, ok := v.(trace.Span)
+			if !ok {
+				return
+			}
+			defer span.End()
+
+			if table := tx.Statement.Table; table != "" {
+				span.SetName(operation + " " + table)
+				span.SetAttributes(attribute.String("db.collection.name", table))
+			}
+			span.SetAttributes(
+				attribute.String("db.system.name", tx.Dialector.Name()),
+				attribute.String("db.query.text", tx.Statement.SQL.String()),
+				attribute.Int64("db.response.rows_affected", tx.RowsAffected),
+			)
+			if err := tx.Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+		}
+	}
+
+	cb := db.Conn.Callback()
+	return errors.Join(
+		cb.Create().Before("gorm:create").Register("database:trace_before_create", before("create")),
+		cb.Create().After("gorm:create").Register("database:trace_after_create", after("create")),
+		cb.Query().Before("gorm:query").Register("database:trace_before_query", before("query")),
+		cb.Query().After("gorm:query").Register("database:trace_after_query", after("query")),
+		cb.Update().Before("gorm:update").Register("database:trace_before_update", before("update")),
+		cb.Update().After("gorm:update").Register("database:trace_after_update", after("update")),
+		cb.Delete().Before("gorm:delete").Register("database:trace_before_delete", before("delete")),
+		cb.Delete().After("gorm:delete").Register("database:trace_after_delete", after("delete")),
+		cb.Row().Before("gorm:row").Register("database:trace_before_row", before("row")),
+		cb.Row().After("gorm:row").Register("database:trace_after_row", after("row")),
+		cb.Raw().Before("gorm:raw").Register("database:trace_before_raw", before("raw")),
+		cb.Raw().After("gorm:raw").Register("database:trace_after_raw", after("raw")),
+	)
+}
@@ -0,0 +1,120 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	gosqlite "github.com/glebarez/go-sqlite"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+// maxTxAttempts is how many times Transaction runs a transaction that keeps
+// failing on serialization.
+const maxTxAttempts = 5
+
+// txRetryBackoff is the longest wait before the first retry of a transaction;
+// it doubles with each further attempt and the actual wait is a random
+// fraction of it, so that colliding transactions spread out.
+const txRetryBackoff = 10 * time.Millisecond
+
+// Postgres SQLSTATE codes on which Transaction retries.
+const (
+	pgSerializationFailure = "40001"
+	pgDeadlockDetected     = "40P01"
+)
+
+// SQLite primary result codes on which Transaction retries, matching any of
+// their extended codes.
+const (
+	sqliteBusy   = 5
+	sqliteLocked = 6
+)
+
+// Isolation of the transactions run by Transaction and ReadTransaction on
+// Postgres. SQLite transactions are serializable regardless.
+var (
+	writeTxOptions = sql.TxOptions{Isolation: sql.LevelSerializable}
+	readTxOptions  = sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}
+)
+
+// Transaction runs fn as a unit of work: everything fn does through tx, a view
+// of db bound to the transaction, is committed together if fn returns nil and
+// rolled back otherwise. fn must not use db itself, whose connection may be
+// the one tx holds.
+//
+// On Postgres the transaction is serializable. When the database aborts it
+// for a serialization failure or a deadlock, or SQLite finds the database
+// busy, it is retried from the start, up to maxTxAttempts times in all, so fn
+// must be safe to run more than once and leave its results in variables it
+// sets afresh on every run. Transaction called within fn, on tx or a view of
+// it, runs in a savepoint of the enclosing transaction, which alone retries.
+func (db *DB) Transaction(ctx context.Context, fn func(tx Store) error) error {
+	return db.transaction(ctx, func(tx *DB) error { return fn(tx) })
+}
+
+// ReadTransaction runs fn like Transaction, for fn that only reads: all it
+// reads through tx comes from one snapshot of the database. On Postgres the
+// transaction is read-only and repeatable read, which a serialization failure
+// never aborts, so concurrent writes do not make it run again.
+func (db *DB) ReadTransaction(ctx context.Context, fn func(tx Store) error) error {
+	return db.readTransaction(ctx, func(tx *DB) error { return fn(tx) })
+}
+
+// transaction is Transaction for use within the package, where tx is known to
+// be a DB.
+func (db *DB) transaction(ctx context.Context, fn func(tx *DB) error) error {
+	return db.runTransaction(ctx, writeTxOptions, fn)
+}
+
+// readTransaction is ReadTransaction for use within the package.
+func (db *DB) readTransaction(ctx context.Context, fn func(tx *DB) error) error {
+	return db.runTransaction(ctx, readTxOptions, fn)
+}
+
+// runTransaction runs fn in a transaction with opts on Postgres, retrying it
+// as Transaction describes.
+func (db *DB) runTransaction(ctx context.Context, opts sql.TxOptions, fn func(tx *DB) error) error {
+	run := func(conn *gorm.DB) error {
+		tx := *db
+		tx.Conn = conn
+		return fn(&tx)
+	}
+	if _, nested := db.Conn.Statement.ConnPool.(gorm.TxCommitter); nested {
+		return db.Conn.WithContext(ctx).Transaction(run)
+	}
+
+	var txOpts []*sql.TxOptions
+	if !db.isSQLite() {
+		txOpts = append(txOpts, &opts)
+	}
+	for attempt := 1; ; attempt++ {
+		err := db.Conn.WithContext(ctx).Transaction(run, txOpts...)
+		if err == nil || attempt == maxTxAttempts || !retryableTx(err) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(rand.N(txRetryBackoff << (attempt - 1))):
+		}
+	}
+}
+
+// retryableTx reports whether err aborted a transaction that may succeed if
+// run again.
+func retryableTx(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == pgSerializationFailure || pgErr.Code == pgDeadlockDetected
+	}
+	var sqliteErr *gosqlite.Error
+	if errors.As(err, &sqliteErr) {
+		code := sqliteErr.Code() & 0xff
+		return code == sqliteBusy || code == sqliteLocked
+	}
+	return false
+}
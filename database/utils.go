@@ -1,6 +1,8 @@
 package database
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
 )
 
@@ -33,8 +35,20 @@ func Ping(db *DB) error {
 	return sqlDB.Ping()
 }
 
-// AutoMigrate runs GORM automigrations for the provided models. Pass model struct types
-// (e.g. &User{}, &Order{}) to create/update tables.
-func AutoMigrate(db *DB, models ...interface{}) error {
-	return db.Conn.AutoMigrate(models...)
+// PingContext is like Ping but gives up when ctx is done.
+func PingContext(ctx context.Context, db *DB) error {
+	sqlDB, err := db.Conn.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// PoolStats returns the connection pool statistics of db.
+func PoolStats(db *DB) (sql.DBStats, error) {
+	sqlDB, err := db.Conn.DB()
+	if err != nil {
+		return sql.DBStats{}, err
+	}
+	return sqlDB.Stats(), nil
 }
@@ -2,114 +2,390 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
+	"log/slog"
 	"net"
 	"net/http"
 	"os"
-	"strconv"
+	"os/signal"
+	"syscall"
 	"time"
 
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"github.com/hcliff-zhang/playground/application"
+	"github.com/hcliff-zhang/playground/config"
 	"github.com/hcliff-zhang/playground/database"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"gorm.io/gorm/logger"
 )
 
-const (
-	HTTPPort = ":8080"
-	GRPCPort = ":9090"
-)
+// logLevels maps the database.log_level setting to gorm log levels.
+var logLevels = map[string]logger.LogLevel{
+	"silent": logger.Silent,
+	"error":  logger.Error,
+	"warn":   logger.Warn,
+	"info":   logger.Info,
+}
+
+// openDatabase connects to the database cfg configures.
+func openDatabase(cfg config.DatabaseConfig) (*database.DB, error) {
+	log := database.NewLogger(slog.Default(), logLevels[cfg.LogLevel])
 
-// getEnv retrieves an environment variable or returns a default value
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+	var db *database.DB
+	var err error
+	if cfg.Driver == "sqlite" {
+		db, err = database.NewSQLite(string(cfg.DSN), log)
+	} else {
+		dsn := string(cfg.DSN)
+		if dsn == "" {
+			dsn = database.BuildPostgresDSN(database.PostgresConfig{
+				Host:     cfg.Host,
+				Port:     cfg.Port,
+				User:     cfg.User,
+				Password: string(cfg.Password),
+				DBName:   cfg.Name,
+				SSLMode:  cfg.SSLMode,
+			})
+		}
+		db, err = database.NewPostgres(dsn, cfg.MaxOpenConns, cfg.MaxIdleConns, time.Duration(cfg.ConnMaxLifetime), log)
+	}
+	if err != nil {
+		return nil, err
 	}
-	return defaultValue
+
+	db.Timeouts = database.Timeouts{
+		Read:   time.Duration(cfg.ReadTimeout),
+		Write:  time.Duration(cfg.WriteTimeout),
+		Search: time.Duration(cfg.SearchTimeout),
+	}
+	return db, nil
 }
 
-// getEnvInt retrieves an environment variable as an integer or returns a default value
-func getEnvInt(key string, defaultValue int) int {
-	if value := os.Getenv(key); value != "" {
-		if intVal, err := strconv.Atoi(value); err == nil {
-			return intVal
+// newAuthenticator configures bearer token authentication. It returns nil if
+// authentication is explicitly disabled.
+func newAuthenticator(cfg config.AuthConfig) (application.Authenticator, error) {
+	if cfg.Disabled {
+		return nil, nil
+	}
+	var keys application.KeySet
+	if cfg.JWKSFile != "" {
+		fileKeys, err := application.LoadJWKSFile(cfg.JWKSFile)
+		if err != nil {
+			return nil, err
 		}
+		keys = fileKeys
+	} else {
+		keys = application.NewRemoteKeySet(cfg.JWKSURL, time.Duration(cfg.JWKSRefresh))
+	}
+	return application.NewJWTAuthenticator(keys, cfg.Issuer, cfg.Audience), nil
+}
+
+// serverTLS returns the TLS config of a listener, or nil if no certificate is
+// configured.
+func serverTLS(cfg config.ListenerTLS) (*tls.Config, error) {
+	if !cfg.Enabled() {
+		return nil, nil
 	}
-	return defaultValue
+	return application.ServerTLS{
+		CertFile:           cfg.CertFile,
+		KeyFile:            cfg.KeyFile,
+		ClientCAFile:       cfg.ClientCAFile,
+		ClientCertOptional: cfg.ClientAuth == "optional",
+	}.Config()
 }
 
 func main() {
-	// Database configuration from environment variables
-	dbConfig := database.PostgresConfig{
-		Host:     getEnv("DB_HOST", "localhost"),
-		Port:     getEnvInt("DB_PORT", 5432),
-		User:     getEnv("DB_USER", "postgres"),
-		Password: getEnv("DB_PASSWORD", "postgres"),
-		DBName:   getEnv("DB_NAME", "playground"),
-		SSLMode:  getEnv("DB_SSLMODE", "disable"),
-	}
-
-	// Build DSN from config
-	dsn := database.BuildPostgresDSN(dbConfig)
-
-	// Initialize database connection
-	db, err := database.NewPostgres(
-		dsn,
-		25,            // maxOpenConns
-		25,            // maxIdleConns
-		5*time.Minute, // connMaxLifetime
-		logger.Info,   // logLevel
-	)
+	name, args := os.Args[0], os.Args[1:]
+	var migrate migrateCommand
+	if len(args) > 0 && args[0] == "migrate" {
+		var err error
+		if migrate, args, err = parseMigrate(args[1:]); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\nUsage: %s migrate up|down [steps]|status [flags]\n", err, name)
+			os.Exit(2)
+		}
+		name += " migrate " + migrate.action
+	}
+
+	cfg, printConfig, err := config.Load(name, args, os.Getenv)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatalf("Invalid configuration:\n%v", err)
+	}
+	if printConfig {
+		if err := cfg.Print(os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// The standard logger writes through slog too
+	var level slog.Level
+	level.UnmarshalText([]byte(cfg.LogLevel))
+	slog.SetDefault(application.NewLogger(os.Stderr, level))
+
+	if migrate.action != "" {
+		if err := migrate.run(cfg.Database); err != nil {
+			log.Printf("Exiting: %v", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if err := run(cfg); err != nil {
+		log.Printf("Exiting: %v", err)
+		os.Exit(1)
+	}
+}
+
+// run starts the servers and blocks until they have shut down, either after
+// SIGINT or SIGTERM or because one of them failed.
+func run(cfg config.Config) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Spans are flushed once the servers have stopped
+	stopTracing, err := application.Tracing{
+		Exporter:    cfg.Tracing.Exporter,
+		Endpoint:    cfg.Tracing.Endpoint,
+		Insecure:    cfg.Tracing.Insecure,
+		File:        cfg.Tracing.File,
+		SampleRatio: cfg.Tracing.SampleRatio,
+		ServiceName: cfg.Tracing.ServiceName,
+	}.Start(ctx)
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		return fmt.Errorf("failed to set up tracing: %w", err)
 	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := stopTracing(ctx); err != nil {
+			log.Printf("Failed to flush traces: %v", err)
+		}
+	}()
 
-	// Run migrations
-	if err := database.AutoMigrate(db, &database.Patient{}, &database.Prescription{}); err != nil {
-		log.Fatalf("Failed to run migrations: %v", err)
+	db, err := openDatabase(cfg.Database)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	if err := database.Trace(db); err != nil {
+		db.Close()
+		return fmt.Errorf("failed to trace database queries: %w", err)
 	}
 
-	// Create the service implementation with database
-	service := application.NewService(db)
+	// The pool is closed last, once no request can still be using it
+	lc := &application.Lifecycle{ShutdownTimeout: time.Duration(cfg.Server.ShutdownTimeout)}
+	lc.OnClose("database", db.Close)
+	started := false
+	defer func() {
+		if !started {
+			db.Close()
+		}
+	}()
 
-	// Start gRPC server in a goroutine
-	go func() {
-		// Create a TCP listener on the gRPC port
-		listener, err := net.Listen("tcp", GRPCPort)
+	// Replicas starting together migrate one at a time; the rest find nothing
+	// left to do
+	if cfg.Database.AutoMigrate {
+		if err := migrateUp(ctx, db); err != nil {
+			return err
+		}
+	} else {
+		pending, err := database.PendingMigrations(ctx, db)
 		if err != nil {
-			log.Fatalf("Failed to listen on gRPC port %s: %v", GRPCPort, err)
+			return fmt.Errorf("failed to check migrations: %w", err)
+		}
+		if len(pending) > 0 {
+			return fmt.Errorf("database schema has %d pending migrations; run migrate up", len(pending))
+		}
+	}
+
+	// Page tokens must be signed with the same key on every replica
+	pageTokenKey := string(cfg.PageTokenKey)
+	if pageTokenKey == "" {
+		log.Printf("page_token_key is not set; page tokens will only be valid on this instance")
+	}
+
+	// Every call must carry a bearer token unless authentication is disabled
+	auth, err := newAuthenticator(cfg.Auth)
+	if err != nil {
+		return fmt.Errorf("failed to configure authentication: %w", err)
+	}
+
+	// Every call is logged and counted, including those that fail
+	// authentication
+	metrics, err := application.NewMetrics(db)
+	if err != nil {
+		return fmt.Errorf("failed to set up metrics: %w", err)
+	}
+	interceptors := []grpc.UnaryServerInterceptor{application.LoggingUnaryServerInterceptor(), metrics.UnaryServerInterceptor()}
+	serviceOpts := []application.Option{application.WithPageTokenKey([]byte(pageTokenKey))}
+	if auth != nil {
+		interceptors = append(interceptors, application.AuthUnaryServerInterceptor(auth))
+
+		// Authenticated callers are authorized by role, using the built-in
+		// policy unless ops provide their own
+		policy := application.DefaultPolicy()
+		if path := cfg.Auth.PolicyFile; path != "" {
+			if policy, err = application.LoadPolicyFile(path); err != nil {
+				return fmt.Errorf("failed to load authorization policy: %w", err)
+			}
 		}
+		serviceOpts = append(serviceOpts, application.WithPolicy(policy))
+	} else {
+		log.Printf("auth.disabled is set; the API is open to anyone who can reach it")
+	}
+
+	// Create the service implementation with database
+	service := application.NewService(db, serviceOpts...)
+
+	// Readiness follows the database and turns off as soon as shutdown starts
+	health := application.NewHealth(db, time.Duration(cfg.Server.ReadinessTimeout))
+	go health.Watch(ctx, 5*time.Second)
+	lc.OnStop("health", health.Shutdown)
+
+	// Serve TLS, and verify client certificates if a client CA is configured
+	grpcTLS, err := serverTLS(cfg.TLS.GRPC)
+	if err != nil {
+		return fmt.Errorf("failed to configure gRPC TLS: %w", err)
+	}
+	httpTLS, err := serverTLS(cfg.TLS.HTTP)
+	if err != nil {
+		return fmt.Errorf("failed to configure HTTP TLS: %w", err)
+	}
+	serverOpts := []grpc.ServerOption{application.TraceGRPCServer(), grpc.ChainUnaryInterceptor(interceptors...)}
+
+	// The gateway's connection to the gRPC server lives until the servers stop
+	gatewayCtx, cancelGateway := context.WithCancel(context.Background())
+	defer cancelGateway()
+	lc.OnClose("gateway connection", func() error {
+		cancelGateway()
+		return nil
+	})
+
+	// A second signal during shutdown exits immediately
+	lc.OnStop("signal handler", func() error {
+		stop()
+		return nil
+	})
+
+	if cfg.Server.Mode == "single" {
+		err = addSinglePort(lc, gatewayCtx, cfg.Server, service, health, metrics, serverOpts, interceptors, grpcTLS)
+	} else {
+		err = addSplitPorts(lc, gatewayCtx, cfg.Server, cfg.TLS.Gateway, service, health, metrics, serverOpts, grpcTLS, httpTLS)
+	}
+	if err != nil {
+		return err
+	}
+
+	started = true
+	return lc.Run(ctx)
+}
 
-		// Create a new gRPC server
-		grpcServer := grpc.NewServer()
+// gatewayOptions record metrics and traces for each gateway route and pass
+// request IDs on to the service.
+func gatewayOptions(metrics *application.Metrics) []runtime.ServeMuxOption {
+	return []runtime.ServeMuxOption{
+		metrics.GatewayMiddleware(),
+		application.TraceGatewayRoutes(),
+		application.GatewayRequestID(),
+	}
+}
+
+// httpHandler serves the gateway, the health and metrics endpoints, and traces
+// and logs requests.
+func httpHandler(gateway http.Handler, health *application.Health, metrics *application.Metrics) http.Handler {
+	return application.TraceHTTP(application.LogHTTP(metrics.Handler(health.Handler(gateway))))
+}
+
+// addSinglePort serves gRPC and the HTTP gateway on one listener, and TLS is
+// terminated by the HTTP server with the gRPC listener's settings. The gateway
+// calls service in-process through interceptors, the same chain the gRPC
+// server runs, so HTTP requests are handled like gRPC calls without another
+// port or server.
+func addSinglePort(lc *application.Lifecycle, ctx context.Context, cfg config.ServerConfig, service *application.Service, health *application.Health, metrics *application.Metrics, serverOpts []grpc.ServerOption, interceptors []grpc.UnaryServerInterceptor, grpcTLS *tls.Config) error {
+	grpcServer := grpc.NewServer(serverOpts...)
+	application.RegisterGRPCHandlers(grpcServer, service)
+	health.RegisterGRPC(grpcServer)
+
+	httpGateway, err := application.RegisterLocalGateway(ctx, service, interceptors, gatewayOptions(metrics)...)
+	if err != nil {
+		return fmt.Errorf("failed to register HTTP gateway: %w", err)
+	}
+
+	listener, err := net.Listen("tcp", cfg.HTTPAddr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", cfg.HTTPAddr, err)
+	}
+	server := application.NewSinglePortServer(cfg.HTTPAddr, grpcServer, httpHandler(httpGateway, health, metrics), grpcTLS)
+	lc.AddHTTPServer("gRPC and HTTP gateway", server, listener)
+	log.Printf("Starting gRPC and HTTP gateway on %s", cfg.HTTPAddr)
+	return nil
+}
 
-		// Register the gRPC handlers
-		application.RegisterGRPCHandlers(grpcServer, service)
+// addSplitPorts serves gRPC and the HTTP gateway on separate ports, with the
+// gateway dialling the gRPC port.
+func addSplitPorts(lc *application.Lifecycle, ctx context.Context, cfg config.ServerConfig, gateway config.GatewayTLS, service *application.Service, health *application.Health, metrics *application.Metrics, serverOpts []grpc.ServerOption, grpcTLS, httpTLS *tls.Config) error {
+	var gatewayCreds credentials.TransportCredentials
+	if grpcTLS != nil {
+		serverOpts = append(serverOpts, grpc.Creds(credentials.NewTLS(grpcTLS)))
 
-		log.Printf("Starting gRPC server on port %s", GRPCPort)
-		if err := grpcServer.Serve(listener); err != nil {
-			log.Fatalf("Failed to serve gRPC: %v", err)
+		// The gateway dials the gRPC server over TLS too, presenting its own
+		// certificate when the server requires one
+		gatewayTLS, err := application.ClientTLS{
+			CAFile:     gateway.CAFile,
+			CertFile:   gateway.CertFile,
+			KeyFile:    gateway.KeyFile,
+			ServerName: gateway.ServerName,
+		}.Config()
+		if err != nil {
+			return fmt.Errorf("failed to configure gateway TLS: %w", err)
 		}
-	}()
+		gatewayCreds = credentials.NewTLS(gatewayTLS)
+	}
 
-	// Give the gRPC server a moment to start
-	time.Sleep(100 * time.Millisecond)
+	// Listen on both ports before serving, so the gateway never dials a
+	// port that is not open yet
+	grpcListener, err := net.Listen("tcp", cfg.GRPCAddr)
+	if err != nil {
+		return fmt.Errorf("failed to listen for gRPC on %s: %w", cfg.GRPCAddr, err)
+	}
+	httpListener, err := net.Listen("tcp", cfg.HTTPAddr)
+	if err != nil {
+		grpcListener.Close()
+		return fmt.Errorf("failed to listen for HTTP on %s: %w", cfg.HTTPAddr, err)
+	}
+
+	// Create a new gRPC server
+	grpcServer := grpc.NewServer(serverOpts...)
 
-	// Register HTTP gateway
-	ctx := context.Background()
-	httpHandler, err := application.RegisterHTTPGateway(ctx, GRPCPort)
+	// Register the gRPC handlers
+	application.RegisterGRPCHandlers(grpcServer, service)
+	health.RegisterGRPC(grpcServer)
+
+	// Register HTTP gateway, which dials the gRPC port on this host
+	_, grpcPort, _ := net.SplitHostPort(grpcListener.Addr().String())
+	httpGateway, err := application.RegisterHTTPGateway(ctx, ":"+grpcPort, gatewayCreds, gatewayOptions(metrics)...)
 	if err != nil {
-		log.Fatalf("Failed to register HTTP gateway: %v", err)
+		grpcListener.Close()
+		httpListener.Close()
+		return fmt.Errorf("failed to register HTTP gateway: %w", err)
 	}
 
-	// Start HTTP server
+	// Create HTTP server
 	httpServer := &http.Server{
-		Addr:    HTTPPort,
-		Handler: httpHandler,
+		Addr:      cfg.HTTPAddr,
+		Handler:   httpHandler(httpGateway, health, metrics),
+		TLSConfig: httpTLS,
 	}
 
-	log.Printf("Starting HTTP gateway on port %s", HTTPPort)
-	if err := httpServer.ListenAndServe(); err != nil {
-		log.Fatalf("Failed to serve HTTP: %v", err)
-	}
+	lc.AddGRPCServer("gRPC server", grpcServer, grpcListener)
+	lc.AddHTTPServer("HTTP gateway", httpServer, httpListener)
+	log.Printf("Starting gRPC server on %s", cfg.GRPCAddr)
+	log.Printf("Starting HTTP gateway on %s", cfg.HTTPAddr)
+	return nil
 }
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/hcliff-zhang/playground/config"
+	"github.com/hcliff-zhang/playground/database"
+)
+
+// migrateCommand is the migrate subcommand: up applies every pending
+// migration, down reverts the last steps applied ones and status lists them.
+type migrateCommand struct {
+	action string
+	steps  int
+}
+
+// parseMigrate parses the arguments following "migrate" and returns the rest,
+// which are configuration flags.
+func parseMigrate(args []string) (migrateCommand, []string, error) {
+	if len(args) == 0 {
+		return migrateCommand{}, nil, errors.New("missing migrate action")
+	}
+	cmd := migrateCommand{action: args[0], steps: 1}
+	args = args[1:]
+
+	switch cmd.action {
+	case "up", "status":
+	case "down":
+		if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+			n, err := strconv.Atoi(args[0])
+			if err != nil || n < 1 {
+				return migrateCommand{}, nil, fmt.Errorf("steps must be a positive number, not %q", args[0])
+			}
+			cmd.steps = n
+			args = args[1:]
+		}
+	default:
+		return migrateCommand{}, nil, fmt.Errorf("unknown migrate action %q", cmd.action)
+	}
+	return cmd, args, nil
+}
+
+// run connects to the database cfg configures and carries out the command.
+func (c migrateCommand) run(cfg config.DatabaseConfig) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	db, err := openDatabase(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer db.Close()
+
+	switch c.action {
+	case "up":
+		return migrateUp(ctx, db)
+	case "down":
+		reverted, err := database.MigrateDown(ctx, db, c.steps)
+		for _, m := range reverted {
+			log.Printf("Reverted migration %04d_%s", m.Version, m.Name)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to revert migrations: %w", err)
+		}
+		if len(reverted) == 0 {
+			log.Printf("No migrations to revert")
+		}
+		return nil
+	default:
+		states, err := database.MigrationStatus(ctx, db)
+		if err != nil {
+			return fmt.Errorf("failed to read migrations: %w", err)
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED")
+		for _, s := range states {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.UTC().Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, applied)
+		}
+		return w.Flush()
+	}
+}
+
+// migrateUp applies the pending migrations to db, logging each one.
+func migrateUp(ctx context.Context, db *database.DB) error {
+	applied, err := database.MigrateUp(ctx, db)
+	for _, m := range applied {
+		log.Printf("Applied migration %04d_%s", m.Version, m.Name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to migrate the database: %w", err)
+	}
+	return nil
+}
@@ -10,6 +10,9 @@ import (
 	_ "google.golang.org/genproto/googleapis/api/annotations"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	fieldmaskpb "google.golang.org/protobuf/types/known/fieldmaskpb"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
 	unsafe "unsafe"
@@ -33,6 +36,12 @@ type Patient struct {
 	Phone         string                 `protobuf:"bytes,6,opt,name=phone,proto3" json:"phone,omitempty"`
 	Address       string                 `protobuf:"bytes,7,opt,name=address,proto3" json:"address,omitempty"`
 	Prescriptions []*Prescription        `protobuf:"bytes,8,rep,name=prescriptions,proto3" json:"prescriptions,omitempty"`
+	// Output only.
+	CreatedAt *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	// Output only.
+	UpdatedAt *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	// Output only. Set when the patient has been deleted.
+	DeletedAt     *timestamppb.Timestamp `protobuf:"bytes,11,opt,name=deleted_at,json=deletedAt,proto3" json:"deleted_at,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -123,15 +132,49 @@ func (x *Patient) GetPrescriptions() []*Prescription {
 	return nil
 }
 
+func (x *Patient) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Patient) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+func (x *Patient) GetDeletedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DeletedAt
+	}
+	return nil
+}
+
 // Prescription message
 type Prescription struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
-	Medication    string                 `protobuf:"bytes,2,opt,name=medication,proto3" json:"medication,omitempty"`
-	Dosage        string                 `protobuf:"bytes,3,opt,name=dosage,proto3" json:"dosage,omitempty"`
-	Frequency     string                 `protobuf:"bytes,4,opt,name=frequency,proto3" json:"frequency,omitempty"`
-	Quantity      int32                  `protobuf:"varint,5,opt,name=quantity,proto3" json:"quantity,omitempty"`
-	Notes         string                 `protobuf:"bytes,6,opt,name=notes,proto3" json:"notes,omitempty"`
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Id         uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Medication string                 `protobuf:"bytes,2,opt,name=medication,proto3" json:"medication,omitempty"`
+	Dosage     string                 `protobuf:"bytes,3,opt,name=dosage,proto3" json:"dosage,omitempty"`
+	Frequency  string                 `protobuf:"bytes,4,opt,name=frequency,proto3" json:"frequency,omitempty"`
+	Quantity   int32                  `protobuf:"varint,5,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	Notes      string                 `protobuf:"bytes,6,opt,name=notes,proto3" json:"notes,omitempty"`
+	// One of active, completed or cancelled. Defaults to active.
+	Status string `protobuf:"bytes,7,opt,name=status,proto3" json:"status,omitempty"`
+	// When the prescription was written. Defaults to the creation time.
+	PrescribedAt *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=prescribed_at,json=prescribedAt,proto3" json:"prescribed_at,omitempty"`
+	// Output only.
+	CreatedAt *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	// Output only.
+	UpdatedAt *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	// Output only. Set when the prescription has been deleted.
+	DeletedAt *timestamppb.Timestamp `protobuf:"bytes,11,opt,name=deleted_at,json=deletedAt,proto3" json:"deleted_at,omitempty"`
+	// The patient the prescription belongs to. Set it in an update to move the
+	// prescription to another patient, e.g. after merging duplicate records.
+	PatientId     uint64 `protobuf:"varint,12,opt,name=patient_id,json=patientId,proto3" json:"patient_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -208,6 +251,48 @@ func (x *Prescription) GetNotes() string {
 	return ""
 }
 
+func (x *Prescription) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Prescription) GetPrescribedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.PrescribedAt
+	}
+	return nil
+}
+
+func (x *Prescription) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Prescription) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+func (x *Prescription) GetDeletedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DeletedAt
+	}
+	return nil
+}
+
+func (x *Prescription) GetPatientId() uint64 {
+	if x != nil {
+		return x.PatientId
+	}
+	return 0
+}
+
 // --- Patient RPC messages ---
 type CreatePatientRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -298,10 +383,12 @@ func (x *CreatePatientResponse) GetPatient() *Patient {
 }
 
 type GetPatientRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Also return the patient if it has been deleted.
+	IncludeDeleted bool `protobuf:"varint,2,opt,name=include_deleted,json=includeDeleted,proto3" json:"include_deleted,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
 }
 
 func (x *GetPatientRequest) Reset() {
@@ -341,6 +428,13 @@ func (x *GetPatientRequest) GetId() uint64 {
 	return 0
 }
 
+func (x *GetPatientRequest) GetIncludeDeleted() bool {
+	if x != nil {
+		return x.IncludeDeleted
+	}
+	return false
+}
+
 type GetPatientResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Patient       *Patient               `protobuf:"bytes,1,opt,name=patient,proto3" json:"patient,omitempty"`
@@ -386,11 +480,25 @@ func (x *GetPatientResponse) GetPatient() *Patient {
 }
 
 type ListPatientsRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Limit         int32                  `protobuf:"varint,1,opt,name=limit,proto3" json:"limit,omitempty"`
-	Offset        int32                  `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Deprecated: use page_size. Treated as page_size when page_size is unset.
+	//
+	// Deprecated: Marked as deprecated in server/serverpb/api.proto.
+	Limit int32 `protobuf:"varint,1,opt,name=limit,proto3" json:"limit,omitempty"`
+	// Deprecated: use page_token. Ignored when page_token is set.
+	//
+	// Deprecated: Marked as deprecated in server/serverpb/api.proto.
+	Offset int32 `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	// Maximum number of patients to return. Defaults to 50 and is capped at 500.
+	PageSize int32 `protobuf:"varint,3,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// next_page_token from a previous ListPatients response.
+	PageToken string `protobuf:"bytes,4,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	// Return a fast planner estimate in total instead of an exact count.
+	EstimateTotal bool `protobuf:"varint,5,opt,name=estimate_total,json=estimateTotal,proto3" json:"estimate_total,omitempty"`
+	// Also list deleted patients.
+	IncludeDeleted bool `protobuf:"varint,6,opt,name=include_deleted,json=includeDeleted,proto3" json:"include_deleted,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
 }
 
 func (x *ListPatientsRequest) Reset() {
@@ -423,6 +531,7 @@ func (*ListPatientsRequest) Descriptor() ([]byte, []int) {
 	return file_server_serverpb_api_proto_rawDescGZIP(), []int{6}
 }
 
+// Deprecated: Marked as deprecated in server/serverpb/api.proto.
 func (x *ListPatientsRequest) GetLimit() int32 {
 	if x != nil {
 		return x.Limit
@@ -430,6 +539,7 @@ func (x *ListPatientsRequest) GetLimit() int32 {
 	return 0
 }
 
+// Deprecated: Marked as deprecated in server/serverpb/api.proto.
 func (x *ListPatientsRequest) GetOffset() int32 {
 	if x != nil {
 		return x.Offset
@@ -437,12 +547,45 @@ func (x *ListPatientsRequest) GetOffset() int32 {
 	return 0
 }
 
+func (x *ListPatientsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListPatientsRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+func (x *ListPatientsRequest) GetEstimateTotal() bool {
+	if x != nil {
+		return x.EstimateTotal
+	}
+	return false
+}
+
+func (x *ListPatientsRequest) GetIncludeDeleted() bool {
+	if x != nil {
+		return x.IncludeDeleted
+	}
+	return false
+}
+
 type ListPatientsResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Patients      []*Patient             `protobuf:"bytes,1,rep,name=patients,proto3" json:"patients,omitempty"`
-	Total         int32                  `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Patients []*Patient             `protobuf:"bytes,1,rep,name=patients,proto3" json:"patients,omitempty"`
+	// Number of patients across all pages.
+	Total int32 `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`
+	// Token for the next page, empty on the last page.
+	NextPageToken string `protobuf:"bytes,3,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	// Whether total is an estimate.
+	TotalEstimated bool `protobuf:"varint,4,opt,name=total_estimated,json=totalEstimated,proto3" json:"total_estimated,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
 }
 
 func (x *ListPatientsResponse) Reset() {
@@ -489,29 +632,57 @@ func (x *ListPatientsResponse) GetTotal() int32 {
 	return 0
 }
 
-// --- Prescription RPC messages ---
-type CreatePrescriptionRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	PatientId     uint64                 `protobuf:"varint,1,opt,name=patient_id,json=patientId,proto3" json:"patient_id,omitempty"`
-	Prescription  *Prescription          `protobuf:"bytes,2,opt,name=prescription,proto3" json:"prescription,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *ListPatientsResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
 }
 
-func (x *CreatePrescriptionRequest) Reset() {
-	*x = CreatePrescriptionRequest{}
+func (x *ListPatientsResponse) GetTotalEstimated() bool {
+	if x != nil {
+		return x.TotalEstimated
+	}
+	return false
+}
+
+type SearchPatientsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Space separated conditions that must all match, e.g.
+	// `last_name:smi phone:555 medication=warfarin`. "field:value" matches a
+	// case-insensitive substring and "field=value" an exact value. Fields are
+	// first_name, last_name, name (first or last), email, phone (digits only)
+	// and medication (active prescriptions only). Values containing spaces are
+	// double quoted.
+	Filter string `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"`
+	// Sort field optionally followed by "asc" or "desc": id, first_name,
+	// last_name or email. Defaults to "id desc".
+	OrderBy string `protobuf:"bytes,2,opt,name=order_by,json=orderBy,proto3" json:"order_by,omitempty"`
+	// Maximum number of patients to return. Defaults to 50 and is capped at 500.
+	PageSize int32 `protobuf:"varint,3,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// next_page_token from a previous SearchPatients response with the same
+	// filter and order_by.
+	PageToken string `protobuf:"bytes,4,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	// Also search deleted patients.
+	IncludeDeleted bool `protobuf:"varint,5,opt,name=include_deleted,json=includeDeleted,proto3" json:"include_deleted,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *SearchPatientsRequest) Reset() {
+	*x = SearchPatientsRequest{}
 	mi := &file_server_serverpb_api_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *CreatePrescriptionRequest) String() string {
+func (x *SearchPatientsRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CreatePrescriptionRequest) ProtoMessage() {}
+func (*SearchPatientsRequest) ProtoMessage() {}
 
-func (x *CreatePrescriptionRequest) ProtoReflect() protoreflect.Message {
+func (x *SearchPatientsRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_server_serverpb_api_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -523,46 +694,71 @@ func (x *CreatePrescriptionRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CreatePrescriptionRequest.ProtoReflect.Descriptor instead.
-func (*CreatePrescriptionRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use SearchPatientsRequest.ProtoReflect.Descriptor instead.
+func (*SearchPatientsRequest) Descriptor() ([]byte, []int) {
 	return file_server_serverpb_api_proto_rawDescGZIP(), []int{8}
 }
 
-func (x *CreatePrescriptionRequest) GetPatientId() uint64 {
+func (x *SearchPatientsRequest) GetFilter() string {
 	if x != nil {
-		return x.PatientId
+		return x.Filter
+	}
+	return ""
+}
+
+func (x *SearchPatientsRequest) GetOrderBy() string {
+	if x != nil {
+		return x.OrderBy
+	}
+	return ""
+}
+
+func (x *SearchPatientsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
 	}
 	return 0
 }
 
-func (x *CreatePrescriptionRequest) GetPrescription() *Prescription {
+func (x *SearchPatientsRequest) GetPageToken() string {
 	if x != nil {
-		return x.Prescription
+		return x.PageToken
 	}
-	return nil
+	return ""
 }
 
-type CreatePrescriptionResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Prescription  *Prescription          `protobuf:"bytes,1,opt,name=prescription,proto3" json:"prescription,omitempty"`
+func (x *SearchPatientsRequest) GetIncludeDeleted() bool {
+	if x != nil {
+		return x.IncludeDeleted
+	}
+	return false
+}
+
+type SearchPatientsResponse struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Patients []*Patient             `protobuf:"bytes,1,rep,name=patients,proto3" json:"patients,omitempty"`
+	// Number of patients matching the filter across all pages.
+	Total int32 `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`
+	// Token for the next page, empty on the last page.
+	NextPageToken string `protobuf:"bytes,3,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *CreatePrescriptionResponse) Reset() {
-	*x = CreatePrescriptionResponse{}
+func (x *SearchPatientsResponse) Reset() {
+	*x = SearchPatientsResponse{}
 	mi := &file_server_serverpb_api_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *CreatePrescriptionResponse) String() string {
+func (x *SearchPatientsResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CreatePrescriptionResponse) ProtoMessage() {}
+func (*SearchPatientsResponse) ProtoMessage() {}
 
-func (x *CreatePrescriptionResponse) ProtoReflect() protoreflect.Message {
+func (x *SearchPatientsResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_server_serverpb_api_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -574,39 +770,55 @@ func (x *CreatePrescriptionResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CreatePrescriptionResponse.ProtoReflect.Descriptor instead.
-func (*CreatePrescriptionResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use SearchPatientsResponse.ProtoReflect.Descriptor instead.
+func (*SearchPatientsResponse) Descriptor() ([]byte, []int) {
 	return file_server_serverpb_api_proto_rawDescGZIP(), []int{9}
 }
 
-func (x *CreatePrescriptionResponse) GetPrescription() *Prescription {
+func (x *SearchPatientsResponse) GetPatients() []*Patient {
 	if x != nil {
-		return x.Prescription
+		return x.Patients
 	}
 	return nil
 }
 
-type GetPrescriptionRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+func (x *SearchPatientsResponse) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *SearchPatientsResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+type UpdatePatientRequest struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Patient *Patient               `protobuf:"bytes,1,opt,name=patient,proto3" json:"patient,omitempty"`
+	// Fields of patient to update. An empty mask or "*" replaces every field.
+	UpdateMask    *fieldmaskpb.FieldMask `protobuf:"bytes,2,opt,name=update_mask,json=updateMask,proto3" json:"update_mask,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetPrescriptionRequest) Reset() {
-	*x = GetPrescriptionRequest{}
+func (x *UpdatePatientRequest) Reset() {
+	*x = UpdatePatientRequest{}
 	mi := &file_server_serverpb_api_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetPrescriptionRequest) String() string {
+func (x *UpdatePatientRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetPrescriptionRequest) ProtoMessage() {}
+func (*UpdatePatientRequest) ProtoMessage() {}
 
-func (x *GetPrescriptionRequest) ProtoReflect() protoreflect.Message {
+func (x *UpdatePatientRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_server_serverpb_api_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -618,39 +830,46 @@ func (x *GetPrescriptionRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetPrescriptionRequest.ProtoReflect.Descriptor instead.
-func (*GetPrescriptionRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use UpdatePatientRequest.ProtoReflect.Descriptor instead.
+func (*UpdatePatientRequest) Descriptor() ([]byte, []int) {
 	return file_server_serverpb_api_proto_rawDescGZIP(), []int{10}
 }
 
-func (x *GetPrescriptionRequest) GetId() uint64 {
+func (x *UpdatePatientRequest) GetPatient() *Patient {
 	if x != nil {
-		return x.Id
+		return x.Patient
 	}
-	return 0
+	return nil
 }
 
-type GetPrescriptionResponse struct {
+func (x *UpdatePatientRequest) GetUpdateMask() *fieldmaskpb.FieldMask {
+	if x != nil {
+		return x.UpdateMask
+	}
+	return nil
+}
+
+type UpdatePatientResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Prescription  *Prescription          `protobuf:"bytes,1,opt,name=prescription,proto3" json:"prescription,omitempty"`
+	Patient       *Patient               `protobuf:"bytes,1,opt,name=patient,proto3" json:"patient,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetPrescriptionResponse) Reset() {
-	*x = GetPrescriptionResponse{}
+func (x *UpdatePatientResponse) Reset() {
+	*x = UpdatePatientResponse{}
 	mi := &file_server_serverpb_api_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetPrescriptionResponse) String() string {
+func (x *UpdatePatientResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetPrescriptionResponse) ProtoMessage() {}
+func (*UpdatePatientResponse) ProtoMessage() {}
 
-func (x *GetPrescriptionResponse) ProtoReflect() protoreflect.Message {
+func (x *UpdatePatientResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_server_serverpb_api_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -662,39 +881,39 @@ func (x *GetPrescriptionResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetPrescriptionResponse.ProtoReflect.Descriptor instead.
-func (*GetPrescriptionResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use UpdatePatientResponse.ProtoReflect.Descriptor instead.
+func (*UpdatePatientResponse) Descriptor() ([]byte, []int) {
 	return file_server_serverpb_api_proto_rawDescGZIP(), []int{11}
 }
 
-func (x *GetPrescriptionResponse) GetPrescription() *Prescription {
+func (x *UpdatePatientResponse) GetPatient() *Patient {
 	if x != nil {
-		return x.Prescription
+		return x.Patient
 	}
 	return nil
 }
 
-type ListPrescriptionsForPatientRequest struct {
+type DeletePatientRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	PatientId     uint64                 `protobuf:"varint,1,opt,name=patient_id,json=patientId,proto3" json:"patient_id,omitempty"`
+	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ListPrescriptionsForPatientRequest) Reset() {
-	*x = ListPrescriptionsForPatientRequest{}
+func (x *DeletePatientRequest) Reset() {
+	*x = DeletePatientRequest{}
 	mi := &file_server_serverpb_api_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListPrescriptionsForPatientRequest) String() string {
+func (x *DeletePatientRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListPrescriptionsForPatientRequest) ProtoMessage() {}
+func (*DeletePatientRequest) ProtoMessage() {}
 
-func (x *ListPrescriptionsForPatientRequest) ProtoReflect() protoreflect.Message {
+func (x *DeletePatientRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_server_serverpb_api_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -706,39 +925,38 @@ func (x *ListPrescriptionsForPatientRequest) ProtoReflect() protoreflect.Message
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListPrescriptionsForPatientRequest.ProtoReflect.Descriptor instead.
-func (*ListPrescriptionsForPatientRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use DeletePatientRequest.ProtoReflect.Descriptor instead.
+func (*DeletePatientRequest) Descriptor() ([]byte, []int) {
 	return file_server_serverpb_api_proto_rawDescGZIP(), []int{12}
 }
 
-func (x *ListPrescriptionsForPatientRequest) GetPatientId() uint64 {
+func (x *DeletePatientRequest) GetId() uint64 {
 	if x != nil {
-		return x.PatientId
+		return x.Id
 	}
 	return 0
 }
 
-type ListPrescriptionsResponse struct {
+type DeletePatientResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Prescriptions []*Prescription        `protobuf:"bytes,1,rep,name=prescriptions,proto3" json:"prescriptions,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ListPrescriptionsResponse) Reset() {
-	*x = ListPrescriptionsResponse{}
+func (x *DeletePatientResponse) Reset() {
+	*x = DeletePatientResponse{}
 	mi := &file_server_serverpb_api_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListPrescriptionsResponse) String() string {
+func (x *DeletePatientResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListPrescriptionsResponse) ProtoMessage() {}
+func (*DeletePatientResponse) ProtoMessage() {}
 
-func (x *ListPrescriptionsResponse) ProtoReflect() protoreflect.Message {
+func (x *DeletePatientResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_server_serverpb_api_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -750,79 +968,1558 @@ func (x *ListPrescriptionsResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListPrescriptionsResponse.ProtoReflect.Descriptor instead.
-func (*ListPrescriptionsResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use DeletePatientResponse.ProtoReflect.Descriptor instead.
+func (*DeletePatientResponse) Descriptor() ([]byte, []int) {
 	return file_server_serverpb_api_proto_rawDescGZIP(), []int{13}
 }
 
-func (x *ListPrescriptionsResponse) GetPrescriptions() []*Prescription {
+type UndeletePatientRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UndeletePatientRequest) Reset() {
+	*x = UndeletePatientRequest{}
+	mi := &file_server_serverpb_api_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UndeletePatientRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UndeletePatientRequest) ProtoMessage() {}
+
+func (x *UndeletePatientRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_server_serverpb_api_proto_msgTypes[14]
 	if x != nil {
-		return x.Prescriptions
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return nil
+	return mi.MessageOf(x)
 }
 
-var File_server_serverpb_api_proto protoreflect.FileDescriptor
+// Deprecated: Use UndeletePatientRequest.ProtoReflect.Descriptor instead.
+func (*UndeletePatientRequest) Descriptor() ([]byte, []int) {
+	return file_server_serverpb_api_proto_rawDescGZIP(), []int{14}
+}
 
-const file_server_serverpb_api_proto_rawDesc = "" +
-	"\n" +
-	"\x19server/serverpb/api.proto\x12\bserverpb\x1a\x1cgoogle/api/annotations.proto\"\xf1\x01\n" +
-	"\aPatient\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x1d\n" +
-	"\n" +
-	"first_name\x18\x02 \x01(\tR\tfirstName\x12\x1b\n" +
-	"\tlast_name\x18\x03 \x01(\tR\blastName\x12\x16\n" +
-	"\x06gender\x18\x04 \x01(\tR\x06gender\x12\x14\n" +
-	"\x05email\x18\x05 \x01(\tR\x05email\x12\x14\n" +
-	"\x05phone\x18\x06 \x01(\tR\x05phone\x12\x18\n" +
-	"\aaddress\x18\a \x01(\tR\aaddress\x12<\n" +
-	"\rprescriptions\x18\b \x03(\v2\x16.serverpb.PrescriptionR\rprescriptions\"\xa6\x01\n" +
-	"\fPrescription\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x1e\n" +
-	"\n" +
-	"medication\x18\x02 \x01(\tR\n" +
-	"medication\x12\x16\n" +
-	"\x06dosage\x18\x03 \x01(\tR\x06dosage\x12\x1c\n" +
-	"\tfrequency\x18\x04 \x01(\tR\tfrequency\x12\x1a\n" +
-	"\bquantity\x18\x05 \x01(\x05R\bquantity\x12\x14\n" +
-	"\x05notes\x18\x06 \x01(\tR\x05notes\"C\n" +
-	"\x14CreatePatientRequest\x12+\n" +
-	"\apatient\x18\x01 \x01(\v2\x11.serverpb.PatientR\apatient\"D\n" +
-	"\x15CreatePatientResponse\x12+\n" +
-	"\apatient\x18\x01 \x01(\v2\x11.serverpb.PatientR\apatient\"#\n" +
-	"\x11GetPatientRequest\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\x04R\x02id\"A\n" +
-	"\x12GetPatientResponse\x12+\n" +
-	"\apatient\x18\x01 \x01(\v2\x11.serverpb.PatientR\apatient\"C\n" +
-	"\x13ListPatientsRequest\x12\x14\n" +
-	"\x05limit\x18\x01 \x01(\x05R\x05limit\x12\x16\n" +
-	"\x06offset\x18\x02 \x01(\x05R\x06offset\"[\n" +
-	"\x14ListPatientsResponse\x12-\n" +
-	"\bpatients\x18\x01 \x03(\v2\x11.serverpb.PatientR\bpatients\x12\x14\n" +
-	"\x05total\x18\x02 \x01(\x05R\x05total\"v\n" +
-	"\x19CreatePrescriptionRequest\x12\x1d\n" +
-	"\n" +
-	"patient_id\x18\x01 \x01(\x04R\tpatientId\x12:\n" +
-	"\fprescription\x18\x02 \x01(\v2\x16.serverpb.PrescriptionR\fprescription\"X\n" +
-	"\x1aCreatePrescriptionResponse\x12:\n" +
-	"\fprescription\x18\x01 \x01(\v2\x16.serverpb.PrescriptionR\fprescription\"(\n" +
-	"\x16GetPrescriptionRequest\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\x04R\x02id\"U\n" +
-	"\x17GetPrescriptionResponse\x12:\n" +
-	"\fprescription\x18\x01 \x01(\v2\x16.serverpb.PrescriptionR\fprescription\"C\n" +
+func (x *UndeletePatientRequest) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type UndeletePatientResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Patient       *Patient               `protobuf:"bytes,1,opt,name=patient,proto3" json:"patient,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UndeletePatientResponse) Reset() {
+	*x = UndeletePatientResponse{}
+	mi := &file_server_serverpb_api_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UndeletePatientResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UndeletePatientResponse) ProtoMessage() {}
+
+func (x *UndeletePatientResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_server_serverpb_api_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UndeletePatientResponse.ProtoReflect.Descriptor instead.
+func (*UndeletePatientResponse) Descriptor() ([]byte, []int) {
+	return file_server_serverpb_api_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *UndeletePatientResponse) GetPatient() *Patient {
+	if x != nil {
+		return x.Patient
+	}
+	return nil
+}
+
+// --- Prescription RPC messages ---
+type CreatePrescriptionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PatientId     uint64                 `protobuf:"varint,1,opt,name=patient_id,json=patientId,proto3" json:"patient_id,omitempty"`
+	Prescription  *Prescription          `protobuf:"bytes,2,opt,name=prescription,proto3" json:"prescription,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreatePrescriptionRequest) Reset() {
+	*x = CreatePrescriptionRequest{}
+	mi := &file_server_serverpb_api_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreatePrescriptionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreatePrescriptionRequest) ProtoMessage() {}
+
+func (x *CreatePrescriptionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_server_serverpb_api_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreatePrescriptionRequest.ProtoReflect.Descriptor instead.
+func (*CreatePrescriptionRequest) Descriptor() ([]byte, []int) {
+	return file_server_serverpb_api_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *CreatePrescriptionRequest) GetPatientId() uint64 {
+	if x != nil {
+		return x.PatientId
+	}
+	return 0
+}
+
+func (x *CreatePrescriptionRequest) GetPrescription() *Prescription {
+	if x != nil {
+		return x.Prescription
+	}
+	return nil
+}
+
+type CreatePrescriptionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Prescription  *Prescription          `protobuf:"bytes,1,opt,name=prescription,proto3" json:"prescription,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreatePrescriptionResponse) Reset() {
+	*x = CreatePrescriptionResponse{}
+	mi := &file_server_serverpb_api_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreatePrescriptionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreatePrescriptionResponse) ProtoMessage() {}
+
+func (x *CreatePrescriptionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_server_serverpb_api_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreatePrescriptionResponse.ProtoReflect.Descriptor instead.
+func (*CreatePrescriptionResponse) Descriptor() ([]byte, []int) {
+	return file_server_serverpb_api_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *CreatePrescriptionResponse) GetPrescription() *Prescription {
+	if x != nil {
+		return x.Prescription
+	}
+	return nil
+}
+
+type GetPrescriptionRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Also return the prescription if it has been deleted.
+	IncludeDeleted bool `protobuf:"varint,2,opt,name=include_deleted,json=includeDeleted,proto3" json:"include_deleted,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *GetPrescriptionRequest) Reset() {
+	*x = GetPrescriptionRequest{}
+	mi := &file_server_serverpb_api_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPrescriptionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPrescriptionRequest) ProtoMessage() {}
+
+func (x *GetPrescriptionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_server_serverpb_api_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPrescriptionRequest.ProtoReflect.Descriptor instead.
+func (*GetPrescriptionRequest) Descriptor() ([]byte, []int) {
+	return file_server_serverpb_api_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *GetPrescriptionRequest) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *GetPrescriptionRequest) GetIncludeDeleted() bool {
+	if x != nil {
+		return x.IncludeDeleted
+	}
+	return false
+}
+
+type GetPrescriptionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Prescription  *Prescription          `protobuf:"bytes,1,opt,name=prescription,proto3" json:"prescription,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetPrescriptionResponse) Reset() {
+	*x = GetPrescriptionResponse{}
+	mi := &file_server_serverpb_api_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPrescriptionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPrescriptionResponse) ProtoMessage() {}
+
+func (x *GetPrescriptionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_server_serverpb_api_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPrescriptionResponse.ProtoReflect.Descriptor instead.
+func (*GetPrescriptionResponse) Descriptor() ([]byte, []int) {
+	return file_server_serverpb_api_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *GetPrescriptionResponse) GetPrescription() *Prescription {
+	if x != nil {
+		return x.Prescription
+	}
+	return nil
+}
+
+type ListPrescriptionsForPatientRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	PatientId uint64                 `protobuf:"varint,1,opt,name=patient_id,json=patientId,proto3" json:"patient_id,omitempty"`
+	// Case-insensitive substring of the medication name.
+	Medication string `protobuf:"bytes,2,opt,name=medication,proto3" json:"medication,omitempty"`
+	// Only prescriptions with this status.
+	Status string `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
+	// Only prescriptions written at or after this time.
+	PrescribedAfter *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=prescribed_after,json=prescribedAfter,proto3" json:"prescribed_after,omitempty"`
+	// Only prescriptions written before this time.
+	PrescribedBefore *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=prescribed_before,json=prescribedBefore,proto3" json:"prescribed_before,omitempty"`
+	// Sort field optionally followed by "asc" or "desc": id, medication or
+	// prescribed_at. Defaults to "prescribed_at desc".
+	OrderBy string `protobuf:"bytes,6,opt,name=order_by,json=orderBy,proto3" json:"order_by,omitempty"`
+	// Maximum number of prescriptions to return. Defaults to 50 and is capped at 500.
+	PageSize int32 `protobuf:"varint,7,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// next_page_token from a previous response to the same request.
+	PageToken string `protobuf:"bytes,8,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	// Also list deleted prescriptions, and list them for a deleted patient.
+	IncludeDeleted bool `protobuf:"varint,9,opt,name=include_deleted,json=includeDeleted,proto3" json:"include_deleted,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ListPrescriptionsForPatientRequest) Reset() {
+	*x = ListPrescriptionsForPatientRequest{}
+	mi := &file_server_serverpb_api_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPrescriptionsForPatientRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPrescriptionsForPatientRequest) ProtoMessage() {}
+
+func (x *ListPrescriptionsForPatientRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_server_serverpb_api_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPrescriptionsForPatientRequest.ProtoReflect.Descriptor instead.
+func (*ListPrescriptionsForPatientRequest) Descriptor() ([]byte, []int) {
+	return file_server_serverpb_api_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *ListPrescriptionsForPatientRequest) GetPatientId() uint64 {
+	if x != nil {
+		return x.PatientId
+	}
+	return 0
+}
+
+func (x *ListPrescriptionsForPatientRequest) GetMedication() string {
+	if x != nil {
+		return x.Medication
+	}
+	return ""
+}
+
+func (x *ListPrescriptionsForPatientRequest) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *ListPrescriptionsForPatientRequest) GetPrescribedAfter() *timestamppb.Timestamp {
+	if x != nil {
+		return x.PrescribedAfter
+	}
+	return nil
+}
+
+func (x *ListPrescriptionsForPatientRequest) GetPrescribedBefore() *timestamppb.Timestamp {
+	if x != nil {
+		return x.PrescribedBefore
+	}
+	return nil
+}
+
+func (x *ListPrescriptionsForPatientRequest) GetOrderBy() string {
+	if x != nil {
+		return x.OrderBy
+	}
+	return ""
+}
+
+func (x *ListPrescriptionsForPatientRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListPrescriptionsForPatientRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+func (x *ListPrescriptionsForPatientRequest) GetIncludeDeleted() bool {
+	if x != nil {
+		return x.IncludeDeleted
+	}
+	return false
+}
+
+type ListPrescriptionsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Case-insensitive substring of the medication name.
+	Medication string `protobuf:"bytes,1,opt,name=medication,proto3" json:"medication,omitempty"`
+	// Only prescriptions with this status.
+	Status string `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	// Only prescriptions written at or after this time.
+	PrescribedAfter *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=prescribed_after,json=prescribedAfter,proto3" json:"prescribed_after,omitempty"`
+	// Only prescriptions written before this time.
+	PrescribedBefore *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=prescribed_before,json=prescribedBefore,proto3" json:"prescribed_before,omitempty"`
+	// Sort field optionally followed by "asc" or "desc": id, medication or
+	// prescribed_at. Defaults to "prescribed_at desc".
+	OrderBy string `protobuf:"bytes,5,opt,name=order_by,json=orderBy,proto3" json:"order_by,omitempty"`
+	// Maximum number of prescriptions to return. Defaults to 50 and is capped at 500.
+	PageSize int32 `protobuf:"varint,6,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// next_page_token from a previous response to the same request.
+	PageToken string `protobuf:"bytes,7,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	// Also list deleted prescriptions.
+	IncludeDeleted bool `protobuf:"varint,8,opt,name=include_deleted,json=includeDeleted,proto3" json:"include_deleted,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ListPrescriptionsRequest) Reset() {
+	*x = ListPrescriptionsRequest{}
+	mi := &file_server_serverpb_api_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPrescriptionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPrescriptionsRequest) ProtoMessage() {}
+
+func (x *ListPrescriptionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_server_serverpb_api_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPrescriptionsRequest.ProtoReflect.Descriptor instead.
+func (*ListPrescriptionsRequest) Descriptor() ([]byte, []int) {
+	return file_server_serverpb_api_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *ListPrescriptionsRequest) GetMedication() string {
+	if x != nil {
+		return x.Medication
+	}
+	return ""
+}
+
+func (x *ListPrescriptionsRequest) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *ListPrescriptionsRequest) GetPrescribedAfter() *timestamppb.Timestamp {
+	if x != nil {
+		return x.PrescribedAfter
+	}
+	return nil
+}
+
+func (x *ListPrescriptionsRequest) GetPrescribedBefore() *timestamppb.Timestamp {
+	if x != nil {
+		return x.PrescribedBefore
+	}
+	return nil
+}
+
+func (x *ListPrescriptionsRequest) GetOrderBy() string {
+	if x != nil {
+		return x.OrderBy
+	}
+	return ""
+}
+
+func (x *ListPrescriptionsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListPrescriptionsRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+func (x *ListPrescriptionsRequest) GetIncludeDeleted() bool {
+	if x != nil {
+		return x.IncludeDeleted
+	}
+	return false
+}
+
+type ListPrescriptionsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Prescriptions []*Prescription        `protobuf:"bytes,1,rep,name=prescriptions,proto3" json:"prescriptions,omitempty"`
+	// Token for the next page, empty on the last page.
+	NextPageToken string `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	// Number of prescriptions matching the request across all pages.
+	Total         int32 `protobuf:"varint,3,opt,name=total,proto3" json:"total,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPrescriptionsResponse) Reset() {
+	*x = ListPrescriptionsResponse{}
+	mi := &file_server_serverpb_api_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPrescriptionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPrescriptionsResponse) ProtoMessage() {}
+
+func (x *ListPrescriptionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_server_serverpb_api_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPrescriptionsResponse.ProtoReflect.Descriptor instead.
+func (*ListPrescriptionsResponse) Descriptor() ([]byte, []int) {
+	return file_server_serverpb_api_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *ListPrescriptionsResponse) GetPrescriptions() []*Prescription {
+	if x != nil {
+		return x.Prescriptions
+	}
+	return nil
+}
+
+func (x *ListPrescriptionsResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+func (x *ListPrescriptionsResponse) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+type UpdatePrescriptionRequest struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	Prescription *Prescription          `protobuf:"bytes,1,opt,name=prescription,proto3" json:"prescription,omitempty"`
+	// Fields of prescription to update. An empty mask or "*" replaces every field.
+	UpdateMask    *fieldmaskpb.FieldMask `protobuf:"bytes,2,opt,name=update_mask,json=updateMask,proto3" json:"update_mask,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdatePrescriptionRequest) Reset() {
+	*x = UpdatePrescriptionRequest{}
+	mi := &file_server_serverpb_api_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdatePrescriptionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdatePrescriptionRequest) ProtoMessage() {}
+
+func (x *UpdatePrescriptionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_server_serverpb_api_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdatePrescriptionRequest.ProtoReflect.Descriptor instead.
+func (*UpdatePrescriptionRequest) Descriptor() ([]byte, []int) {
+	return file_server_serverpb_api_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *UpdatePrescriptionRequest) GetPrescription() *Prescription {
+	if x != nil {
+		return x.Prescription
+	}
+	return nil
+}
+
+func (x *UpdatePrescriptionRequest) GetUpdateMask() *fieldmaskpb.FieldMask {
+	if x != nil {
+		return x.UpdateMask
+	}
+	return nil
+}
+
+type UpdatePrescriptionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Prescription  *Prescription          `protobuf:"bytes,1,opt,name=prescription,proto3" json:"prescription,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdatePrescriptionResponse) Reset() {
+	*x = UpdatePrescriptionResponse{}
+	mi := &file_server_serverpb_api_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdatePrescriptionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdatePrescriptionResponse) ProtoMessage() {}
+
+func (x *UpdatePrescriptionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_server_serverpb_api_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdatePrescriptionResponse.ProtoReflect.Descriptor instead.
+func (*UpdatePrescriptionResponse) Descriptor() ([]byte, []int) {
+	return file_server_serverpb_api_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *UpdatePrescriptionResponse) GetPrescription() *Prescription {
+	if x != nil {
+		return x.Prescription
+	}
+	return nil
+}
+
+type DeletePrescriptionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeletePrescriptionRequest) Reset() {
+	*x = DeletePrescriptionRequest{}
+	mi := &file_server_serverpb_api_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeletePrescriptionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeletePrescriptionRequest) ProtoMessage() {}
+
+func (x *DeletePrescriptionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_server_serverpb_api_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeletePrescriptionRequest.ProtoReflect.Descriptor instead.
+func (*DeletePrescriptionRequest) Descriptor() ([]byte, []int) {
+	return file_server_serverpb_api_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *DeletePrescriptionRequest) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type DeletePrescriptionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeletePrescriptionResponse) Reset() {
+	*x = DeletePrescriptionResponse{}
+	mi := &file_server_serverpb_api_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeletePrescriptionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeletePrescriptionResponse) ProtoMessage() {}
+
+func (x *DeletePrescriptionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_server_serverpb_api_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeletePrescriptionResponse.ProtoReflect.Descriptor instead.
+func (*DeletePrescriptionResponse) Descriptor() ([]byte, []int) {
+	return file_server_serverpb_api_proto_rawDescGZIP(), []int{26}
+}
+
+// AuditEvent records one call to the Api service.
+type AuditEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Time  *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=time,proto3" json:"time,omitempty"`
+	// Identity of the caller.
+	Actor string `protobuf:"bytes,3,opt,name=actor,proto3" json:"actor,omitempty"`
+	// Full gRPC method name, e.g. /serverpb.Api/GetPatient.
+	Method string `protobuf:"bytes,4,opt,name=method,proto3" json:"method,omitempty"`
+	// Records touched by the call, e.g. "patients/1" or "prescriptions/7".
+	Resources []string `protobuf:"bytes,5,rep,name=resources,proto3" json:"resources,omitempty"`
+	// Patients the call concerns.
+	PatientIds []uint64 `protobuf:"varint,6,rep,packed,name=patient_ids,json=patientIds,proto3" json:"patient_ids,omitempty"`
+	// gRPC status code name of the result, e.g. OK or PermissionDenied.
+	Outcome string `protobuf:"bytes,7,opt,name=outcome,proto3" json:"outcome,omitempty"`
+	// Error message for failed calls.
+	Message string `protobuf:"bytes,8,opt,name=message,proto3" json:"message,omitempty"`
+	// For writes, each changed field mapped to {"before": ..., "after": ...}.
+	Changes       *structpb.Struct `protobuf:"bytes,9,opt,name=changes,proto3" json:"changes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AuditEvent) Reset() {
+	*x = AuditEvent{}
+	mi := &file_server_serverpb_api_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AuditEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AuditEvent) ProtoMessage() {}
+
+func (x *AuditEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_server_serverpb_api_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AuditEvent.ProtoReflect.Descriptor instead.
+func (*AuditEvent) Descriptor() ([]byte, []int) {
+	return file_server_serverpb_api_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *AuditEvent) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *AuditEvent) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *AuditEvent) GetActor() string {
+	if x != nil {
+		return x.Actor
+	}
+	return ""
+}
+
+func (x *AuditEvent) GetMethod() string {
+	if x != nil {
+		return x.Method
+	}
+	return ""
+}
+
+func (x *AuditEvent) GetResources() []string {
+	if x != nil {
+		return x.Resources
+	}
+	return nil
+}
+
+func (x *AuditEvent) GetPatientIds() []uint64 {
+	if x != nil {
+		return x.PatientIds
+	}
+	return nil
+}
+
+func (x *AuditEvent) GetOutcome() string {
+	if x != nil {
+		return x.Outcome
+	}
+	return ""
+}
+
+func (x *AuditEvent) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *AuditEvent) GetChanges() *structpb.Struct {
+	if x != nil {
+		return x.Changes
+	}
+	return nil
+}
+
+type ListAuditEventsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Only events concerning this patient. Events that concern other patients as
+	// well, such as listings, are narrowed to what concerns this one.
+	PatientId uint64 `protobuf:"varint,1,opt,name=patient_id,json=patientId,proto3" json:"patient_id,omitempty"`
+	// Only events by this actor.
+	Actor string `protobuf:"bytes,2,opt,name=actor,proto3" json:"actor,omitempty"`
+	// Only events at or after this time.
+	StartTime *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"`
+	// Only events before this time.
+	EndTime *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=end_time,json=endTime,proto3" json:"end_time,omitempty"`
+	// Maximum number of events to return. Defaults to 50 and is capped at 500.
+	PageSize int32 `protobuf:"varint,5,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// next_page_token from a previous response to the same request.
+	PageToken     string `protobuf:"bytes,6,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAuditEventsRequest) Reset() {
+	*x = ListAuditEventsRequest{}
+	mi := &file_server_serverpb_api_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAuditEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAuditEventsRequest) ProtoMessage() {}
+
+func (x *ListAuditEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_server_serverpb_api_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAuditEventsRequest.ProtoReflect.Descriptor instead.
+func (*ListAuditEventsRequest) Descriptor() ([]byte, []int) {
+	return file_server_serverpb_api_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *ListAuditEventsRequest) GetPatientId() uint64 {
+	if x != nil {
+		return x.PatientId
+	}
+	return 0
+}
+
+func (x *ListAuditEventsRequest) GetActor() string {
+	if x != nil {
+		return x.Actor
+	}
+	return ""
+}
+
+func (x *ListAuditEventsRequest) GetStartTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartTime
+	}
+	return nil
+}
+
+func (x *ListAuditEventsRequest) GetEndTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.EndTime
+	}
+	return nil
+}
+
+func (x *ListAuditEventsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListAuditEventsRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+type ListAuditEventsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Matching events, newest first.
+	Events []*AuditEvent `protobuf:"bytes,1,rep,name=events,proto3" json:"events,omitempty"`
+	// Token for the next page, empty on the last page.
+	NextPageToken string `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAuditEventsResponse) Reset() {
+	*x = ListAuditEventsResponse{}
+	mi := &file_server_serverpb_api_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAuditEventsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAuditEventsResponse) ProtoMessage() {}
+
+func (x *ListAuditEventsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_server_serverpb_api_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAuditEventsResponse.ProtoReflect.Descriptor instead.
+func (*ListAuditEventsResponse) Descriptor() ([]byte, []int) {
+	return file_server_serverpb_api_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *ListAuditEventsResponse) GetEvents() []*AuditEvent {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+func (x *ListAuditEventsResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+// CareTeamMember grants a caller access to a patient under care-team-scoped
+// roles such as clinician.
+type CareTeamMember struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	PatientId uint64                 `protobuf:"varint,1,opt,name=patient_id,json=patientId,proto3" json:"patient_id,omitempty"`
+	// Subject of the member's bearer tokens.
+	Member        string                 `protobuf:"bytes,2,opt,name=member,proto3" json:"member,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CareTeamMember) Reset() {
+	*x = CareTeamMember{}
+	mi := &file_server_serverpb_api_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CareTeamMember) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CareTeamMember) ProtoMessage() {}
+
+func (x *CareTeamMember) ProtoReflect() protoreflect.Message {
+	mi := &file_server_serverpb_api_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CareTeamMember.ProtoReflect.Descriptor instead.
+func (*CareTeamMember) Descriptor() ([]byte, []int) {
+	return file_server_serverpb_api_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *CareTeamMember) GetPatientId() uint64 {
+	if x != nil {
+		return x.PatientId
+	}
+	return 0
+}
+
+func (x *CareTeamMember) GetMember() string {
+	if x != nil {
+		return x.Member
+	}
+	return ""
+}
+
+func (x *CareTeamMember) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+type AddCareTeamMemberRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PatientId     uint64                 `protobuf:"varint,1,opt,name=patient_id,json=patientId,proto3" json:"patient_id,omitempty"`
+	Member        string                 `protobuf:"bytes,2,opt,name=member,proto3" json:"member,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddCareTeamMemberRequest) Reset() {
+	*x = AddCareTeamMemberRequest{}
+	mi := &file_server_serverpb_api_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddCareTeamMemberRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddCareTeamMemberRequest) ProtoMessage() {}
+
+func (x *AddCareTeamMemberRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_server_serverpb_api_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddCareTeamMemberRequest.ProtoReflect.Descriptor instead.
+func (*AddCareTeamMemberRequest) Descriptor() ([]byte, []int) {
+	return file_server_serverpb_api_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *AddCareTeamMemberRequest) GetPatientId() uint64 {
+	if x != nil {
+		return x.PatientId
+	}
+	return 0
+}
+
+func (x *AddCareTeamMemberRequest) GetMember() string {
+	if x != nil {
+		return x.Member
+	}
+	return ""
+}
+
+type AddCareTeamMemberResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Member        *CareTeamMember        `protobuf:"bytes,1,opt,name=member,proto3" json:"member,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddCareTeamMemberResponse) Reset() {
+	*x = AddCareTeamMemberResponse{}
+	mi := &file_server_serverpb_api_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddCareTeamMemberResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddCareTeamMemberResponse) ProtoMessage() {}
+
+func (x *AddCareTeamMemberResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_server_serverpb_api_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddCareTeamMemberResponse.ProtoReflect.Descriptor instead.
+func (*AddCareTeamMemberResponse) Descriptor() ([]byte, []int) {
+	return file_server_serverpb_api_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *AddCareTeamMemberResponse) GetMember() *CareTeamMember {
+	if x != nil {
+		return x.Member
+	}
+	return nil
+}
+
+type RemoveCareTeamMemberRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PatientId     uint64                 `protobuf:"varint,1,opt,name=patient_id,json=patientId,proto3" json:"patient_id,omitempty"`
+	Member        string                 `protobuf:"bytes,2,opt,name=member,proto3" json:"member,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemoveCareTeamMemberRequest) Reset() {
+	*x = RemoveCareTeamMemberRequest{}
+	mi := &file_server_serverpb_api_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemoveCareTeamMemberRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoveCareTeamMemberRequest) ProtoMessage() {}
+
+func (x *RemoveCareTeamMemberRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_server_serverpb_api_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoveCareTeamMemberRequest.ProtoReflect.Descriptor instead.
+func (*RemoveCareTeamMemberRequest) Descriptor() ([]byte, []int) {
+	return file_server_serverpb_api_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *RemoveCareTeamMemberRequest) GetPatientId() uint64 {
+	if x != nil {
+		return x.PatientId
+	}
+	return 0
+}
+
+func (x *RemoveCareTeamMemberRequest) GetMember() string {
+	if x != nil {
+		return x.Member
+	}
+	return ""
+}
+
+type RemoveCareTeamMemberResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemoveCareTeamMemberResponse) Reset() {
+	*x = RemoveCareTeamMemberResponse{}
+	mi := &file_server_serverpb_api_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemoveCareTeamMemberResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoveCareTeamMemberResponse) ProtoMessage() {}
+
+func (x *RemoveCareTeamMemberResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_server_serverpb_api_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoveCareTeamMemberResponse.ProtoReflect.Descriptor instead.
+func (*RemoveCareTeamMemberResponse) Descriptor() ([]byte, []int) {
+	return file_server_serverpb_api_proto_rawDescGZIP(), []int{34}
+}
+
+type ListCareTeamRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PatientId     uint64                 `protobuf:"varint,1,opt,name=patient_id,json=patientId,proto3" json:"patient_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListCareTeamRequest) Reset() {
+	*x = ListCareTeamRequest{}
+	mi := &file_server_serverpb_api_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListCareTeamRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCareTeamRequest) ProtoMessage() {}
+
+func (x *ListCareTeamRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_server_serverpb_api_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCareTeamRequest.ProtoReflect.Descriptor instead.
+func (*ListCareTeamRequest) Descriptor() ([]byte, []int) {
+	return file_server_serverpb_api_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *ListCareTeamRequest) GetPatientId() uint64 {
+	if x != nil {
+		return x.PatientId
+	}
+	return 0
+}
+
+type ListCareTeamResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Members       []*CareTeamMember      `protobuf:"bytes,1,rep,name=members,proto3" json:"members,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListCareTeamResponse) Reset() {
+	*x = ListCareTeamResponse{}
+	mi := &file_server_serverpb_api_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListCareTeamResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCareTeamResponse) ProtoMessage() {}
+
+func (x *ListCareTeamResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_server_serverpb_api_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCareTeamResponse.ProtoReflect.Descriptor instead.
+func (*ListCareTeamResponse) Descriptor() ([]byte, []int) {
+	return file_server_serverpb_api_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *ListCareTeamResponse) GetMembers() []*CareTeamMember {
+	if x != nil {
+		return x.Members
+	}
+	return nil
+}
+
+var File_server_serverpb_api_proto protoreflect.FileDescriptor
+
+const file_server_serverpb_api_proto_rawDesc = "" +
+	"\n" +
+	"\x19server/serverpb/api.proto\x12\bserverpb\x1a\x1cgoogle/api/annotations.proto\x1a google/protobuf/field_mask.proto\x1a\x1cgoogle/protobuf/struct.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xa2\x03\n" +
+	"\aPatient\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x1d\n" +
+	"\n" +
+	"first_name\x18\x02 \x01(\tR\tfirstName\x12\x1b\n" +
+	"\tlast_name\x18\x03 \x01(\tR\blastName\x12\x16\n" +
+	"\x06gender\x18\x04 \x01(\tR\x06gender\x12\x14\n" +
+	"\x05email\x18\x05 \x01(\tR\x05email\x12\x14\n" +
+	"\x05phone\x18\x06 \x01(\tR\x05phone\x12\x18\n" +
+	"\aaddress\x18\a \x01(\tR\aaddress\x12<\n" +
+	"\rprescriptions\x18\b \x03(\v2\x16.serverpb.PrescriptionR\rprescriptions\x129\n" +
+	"\n" +
+	"created_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x129\n" +
+	"\n" +
+	"deleted_at\x18\v \x01(\v2\x1a.google.protobuf.TimestampR\tdeletedAt\"\xcf\x03\n" +
+	"\fPrescription\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x1e\n" +
+	"\n" +
+	"medication\x18\x02 \x01(\tR\n" +
+	"medication\x12\x16\n" +
+	"\x06dosage\x18\x03 \x01(\tR\x06dosage\x12\x1c\n" +
+	"\tfrequency\x18\x04 \x01(\tR\tfrequency\x12\x1a\n" +
+	"\bquantity\x18\x05 \x01(\x05R\bquantity\x12\x14\n" +
+	"\x05notes\x18\x06 \x01(\tR\x05notes\x12\x16\n" +
+	"\x06status\x18\a \x01(\tR\x06status\x12?\n" +
+	"\rprescribed_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\fprescribedAt\x129\n" +
+	"\n" +
+	"created_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x129\n" +
+	"\n" +
+	"deleted_at\x18\v \x01(\v2\x1a.google.protobuf.TimestampR\tdeletedAt\x12\x1d\n" +
+	"\n" +
+	"patient_id\x18\f \x01(\x04R\tpatientId\"C\n" +
+	"\x14CreatePatientRequest\x12+\n" +
+	"\apatient\x18\x01 \x01(\v2\x11.serverpb.PatientR\apatient\"D\n" +
+	"\x15CreatePatientResponse\x12+\n" +
+	"\apatient\x18\x01 \x01(\v2\x11.serverpb.PatientR\apatient\"L\n" +
+	"\x11GetPatientRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12'\n" +
+	"\x0finclude_deleted\x18\x02 \x01(\bR\x0eincludeDeleted\"A\n" +
+	"\x12GetPatientResponse\x12+\n" +
+	"\apatient\x18\x01 \x01(\v2\x11.serverpb.PatientR\apatient\"\xd7\x01\n" +
+	"\x13ListPatientsRequest\x12\x18\n" +
+	"\x05limit\x18\x01 \x01(\x05B\x02\x18\x01R\x05limit\x12\x1a\n" +
+	"\x06offset\x18\x02 \x01(\x05B\x02\x18\x01R\x06offset\x12\x1b\n" +
+	"\tpage_size\x18\x03 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x04 \x01(\tR\tpageToken\x12%\n" +
+	"\x0eestimate_total\x18\x05 \x01(\bR\restimateTotal\x12'\n" +
+	"\x0finclude_deleted\x18\x06 \x01(\bR\x0eincludeDeleted\"\xac\x01\n" +
+	"\x14ListPatientsResponse\x12-\n" +
+	"\bpatients\x18\x01 \x03(\v2\x11.serverpb.PatientR\bpatients\x12\x14\n" +
+	"\x05total\x18\x02 \x01(\x05R\x05total\x12&\n" +
+	"\x0fnext_page_token\x18\x03 \x01(\tR\rnextPageToken\x12'\n" +
+	"\x0ftotal_estimated\x18\x04 \x01(\bR\x0etotalEstimated\"\xaf\x01\n" +
+	"\x15SearchPatientsRequest\x12\x16\n" +
+	"\x06filter\x18\x01 \x01(\tR\x06filter\x12\x19\n" +
+	"\border_by\x18\x02 \x01(\tR\aorderBy\x12\x1b\n" +
+	"\tpage_size\x18\x03 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x04 \x01(\tR\tpageToken\x12'\n" +
+	"\x0finclude_deleted\x18\x05 \x01(\bR\x0eincludeDeleted\"\x85\x01\n" +
+	"\x16SearchPatientsResponse\x12-\n" +
+	"\bpatients\x18\x01 \x03(\v2\x11.serverpb.PatientR\bpatients\x12\x14\n" +
+	"\x05total\x18\x02 \x01(\x05R\x05total\x12&\n" +
+	"\x0fnext_page_token\x18\x03 \x01(\tR\rnextPageToken\"\x80\x01\n" +
+	"\x14UpdatePatientRequest\x12+\n" +
+	"\apatient\x18\x01 \x01(\v2\x11.serverpb.PatientR\apatient\x12;\n" +
+	"\vupdate_mask\x18\x02 \x01(\v2\x1a.google.protobuf.FieldMaskR\n" +
+	"updateMask\"D\n" +
+	"\x15UpdatePatientResponse\x12+\n" +
+	"\apatient\x18\x01 \x01(\v2\x11.serverpb.PatientR\apatient\"&\n" +
+	"\x14DeletePatientRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\"\x17\n" +
+	"\x15DeletePatientResponse\"(\n" +
+	"\x16UndeletePatientRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\"F\n" +
+	"\x17UndeletePatientResponse\x12+\n" +
+	"\apatient\x18\x01 \x01(\v2\x11.serverpb.PatientR\apatient\"v\n" +
+	"\x19CreatePrescriptionRequest\x12\x1d\n" +
+	"\n" +
+	"patient_id\x18\x01 \x01(\x04R\tpatientId\x12:\n" +
+	"\fprescription\x18\x02 \x01(\v2\x16.serverpb.PrescriptionR\fprescription\"X\n" +
+	"\x1aCreatePrescriptionResponse\x12:\n" +
+	"\fprescription\x18\x01 \x01(\v2\x16.serverpb.PrescriptionR\fprescription\"Q\n" +
+	"\x16GetPrescriptionRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12'\n" +
+	"\x0finclude_deleted\x18\x02 \x01(\bR\x0eincludeDeleted\"U\n" +
+	"\x17GetPrescriptionResponse\x12:\n" +
+	"\fprescription\x18\x01 \x01(\v2\x16.serverpb.PrescriptionR\fprescription\"\x8b\x03\n" +
 	"\"ListPrescriptionsForPatientRequest\x12\x1d\n" +
 	"\n" +
-	"patient_id\x18\x01 \x01(\x04R\tpatientId\"Y\n" +
+	"patient_id\x18\x01 \x01(\x04R\tpatientId\x12\x1e\n" +
+	"\n" +
+	"medication\x18\x02 \x01(\tR\n" +
+	"medication\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\x12E\n" +
+	"\x10prescribed_after\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\x0fprescribedAfter\x12G\n" +
+	"\x11prescribed_before\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\x10prescribedBefore\x12\x19\n" +
+	"\border_by\x18\x06 \x01(\tR\aorderBy\x12\x1b\n" +
+	"\tpage_size\x18\a \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\b \x01(\tR\tpageToken\x12'\n" +
+	"\x0finclude_deleted\x18\t \x01(\bR\x0eincludeDeleted\"\xe2\x02\n" +
+	"\x18ListPrescriptionsRequest\x12\x1e\n" +
+	"\n" +
+	"medication\x18\x01 \x01(\tR\n" +
+	"medication\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\x12E\n" +
+	"\x10prescribed_after\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x0fprescribedAfter\x12G\n" +
+	"\x11prescribed_before\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\x10prescribedBefore\x12\x19\n" +
+	"\border_by\x18\x05 \x01(\tR\aorderBy\x12\x1b\n" +
+	"\tpage_size\x18\x06 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\a \x01(\tR\tpageToken\x12'\n" +
+	"\x0finclude_deleted\x18\b \x01(\bR\x0eincludeDeleted\"\x97\x01\n" +
 	"\x19ListPrescriptionsResponse\x12<\n" +
-	"\rprescriptions\x18\x01 \x03(\v2\x16.serverpb.PrescriptionR\rprescriptions2\xf6\x05\n" +
+	"\rprescriptions\x18\x01 \x03(\v2\x16.serverpb.PrescriptionR\rprescriptions\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\x12\x14\n" +
+	"\x05total\x18\x03 \x01(\x05R\x05total\"\x94\x01\n" +
+	"\x19UpdatePrescriptionRequest\x12:\n" +
+	"\fprescription\x18\x01 \x01(\v2\x16.serverpb.PrescriptionR\fprescription\x12;\n" +
+	"\vupdate_mask\x18\x02 \x01(\v2\x1a.google.protobuf.FieldMaskR\n" +
+	"updateMask\"X\n" +
+	"\x1aUpdatePrescriptionResponse\x12:\n" +
+	"\fprescription\x18\x01 \x01(\v2\x16.serverpb.PrescriptionR\fprescription\"+\n" +
+	"\x19DeletePrescriptionRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\"\x1c\n" +
+	"\x1aDeletePrescriptionResponse\"\xa0\x02\n" +
+	"\n" +
+	"AuditEvent\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12.\n" +
+	"\x04time\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12\x14\n" +
+	"\x05actor\x18\x03 \x01(\tR\x05actor\x12\x16\n" +
+	"\x06method\x18\x04 \x01(\tR\x06method\x12\x1c\n" +
+	"\tresources\x18\x05 \x03(\tR\tresources\x12\x1f\n" +
+	"\vpatient_ids\x18\x06 \x03(\x04R\n" +
+	"patientIds\x12\x18\n" +
+	"\aoutcome\x18\a \x01(\tR\aoutcome\x12\x18\n" +
+	"\amessage\x18\b \x01(\tR\amessage\x121\n" +
+	"\achanges\x18\t \x01(\v2\x17.google.protobuf.StructR\achanges\"\xfb\x01\n" +
+	"\x16ListAuditEventsRequest\x12\x1d\n" +
+	"\n" +
+	"patient_id\x18\x01 \x01(\x04R\tpatientId\x12\x14\n" +
+	"\x05actor\x18\x02 \x01(\tR\x05actor\x129\n" +
+	"\n" +
+	"start_time\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\tstartTime\x125\n" +
+	"\bend_time\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\aendTime\x12\x1b\n" +
+	"\tpage_size\x18\x05 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x06 \x01(\tR\tpageToken\"o\n" +
+	"\x17ListAuditEventsResponse\x12,\n" +
+	"\x06events\x18\x01 \x03(\v2\x14.serverpb.AuditEventR\x06events\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\"\x82\x01\n" +
+	"\x0eCareTeamMember\x12\x1d\n" +
+	"\n" +
+	"patient_id\x18\x01 \x01(\x04R\tpatientId\x12\x16\n" +
+	"\x06member\x18\x02 \x01(\tR\x06member\x129\n" +
+	"\n" +
+	"created_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\"Q\n" +
+	"\x18AddCareTeamMemberRequest\x12\x1d\n" +
+	"\n" +
+	"patient_id\x18\x01 \x01(\x04R\tpatientId\x12\x16\n" +
+	"\x06member\x18\x02 \x01(\tR\x06member\"M\n" +
+	"\x19AddCareTeamMemberResponse\x120\n" +
+	"\x06member\x18\x01 \x01(\v2\x18.serverpb.CareTeamMemberR\x06member\"T\n" +
+	"\x1bRemoveCareTeamMemberRequest\x12\x1d\n" +
+	"\n" +
+	"patient_id\x18\x01 \x01(\x04R\tpatientId\x12\x16\n" +
+	"\x06member\x18\x02 \x01(\tR\x06member\"\x1e\n" +
+	"\x1cRemoveCareTeamMemberResponse\"4\n" +
+	"\x13ListCareTeamRequest\x12\x1d\n" +
+	"\n" +
+	"patient_id\x18\x01 \x01(\x04R\tpatientId\"J\n" +
+	"\x14ListCareTeamResponse\x122\n" +
+	"\amembers\x18\x01 \x03(\v2\x18.serverpb.CareTeamMemberR\amembers2\x80\x11\n" +
 	"\x03Api\x12i\n" +
 	"\rCreatePatient\x12\x1e.serverpb.CreatePatientRequest\x1a\x1f.serverpb.CreatePatientResponse\"\x17\x82\xd3\xe4\x93\x02\x11:\x01*\"\f/v1/patients\x12b\n" +
 	"\n" +
 	"GetPatient\x12\x1b.serverpb.GetPatientRequest\x1a\x1c.serverpb.GetPatientResponse\"\x19\x82\xd3\xe4\x93\x02\x13\x12\x11/v1/patients/{id}\x12c\n" +
-	"\fListPatients\x12\x1d.serverpb.ListPatientsRequest\x1a\x1e.serverpb.ListPatientsResponse\"\x14\x82\xd3\xe4\x93\x02\x0e\x12\f/v1/patients\x12\x9e\x01\n" +
+	"\fListPatients\x12\x1d.serverpb.ListPatientsRequest\x1a\x1e.serverpb.ListPatientsResponse\"\x14\x82\xd3\xe4\x93\x02\x0e\x12\f/v1/patients\x12p\n" +
+	"\x0eSearchPatients\x12\x1f.serverpb.SearchPatientsRequest\x1a .serverpb.SearchPatientsResponse\"\x1b\x82\xd3\xe4\x93\x02\x15\x12\x13/v1/patients:search\x12|\n" +
+	"\rUpdatePatient\x12\x1e.serverpb.UpdatePatientRequest\x1a\x1f.serverpb.UpdatePatientResponse\"*\x82\xd3\xe4\x93\x02$:\apatient2\x19/v1/patients/{patient.id}\x12k\n" +
+	"\rDeletePatient\x12\x1e.serverpb.DeletePatientRequest\x1a\x1f.serverpb.DeletePatientResponse\"\x19\x82\xd3\xe4\x93\x02\x13*\x11/v1/patients/{id}\x12}\n" +
+	"\x0fUndeletePatient\x12 .serverpb.UndeletePatientRequest\x1a!.serverpb.UndeletePatientResponse\"%\x82\xd3\xe4\x93\x02\x1f:\x01*\"\x1a/v1/patients/{id}:undelete\x12\x9e\x01\n" +
 	"\x12CreatePrescription\x12#.serverpb.CreatePrescriptionRequest\x1a$.serverpb.CreatePrescriptionResponse\"=\x82\xd3\xe4\x93\x027:\fprescription\"'/v1/patients/{patient_id}/prescriptions\x12v\n" +
 	"\x0fGetPrescription\x12 .serverpb.GetPrescriptionRequest\x1a!.serverpb.GetPrescriptionResponse\"\x1e\x82\xd3\xe4\x93\x02\x18\x12\x16/v1/prescriptions/{id}\x12\xa1\x01\n" +
-	"\x1bListPrescriptionsForPatient\x12,.serverpb.ListPrescriptionsForPatientRequest\x1a#.serverpb.ListPrescriptionsResponse\"/\x82\xd3\xe4\x93\x02)\x12'/v1/patients/{patient_id}/prescriptionsB=Z;github.com/hcliff-zhang/playground/server/serverpb;serverpbb\x06proto3"
+	"\x1bListPrescriptionsForPatient\x12,.serverpb.ListPrescriptionsForPatientRequest\x1a#.serverpb.ListPrescriptionsResponse\"/\x82\xd3\xe4\x93\x02)\x12'/v1/patients/{patient_id}/prescriptions\x12w\n" +
+	"\x11ListPrescriptions\x12\".serverpb.ListPrescriptionsRequest\x1a#.serverpb.ListPrescriptionsResponse\"\x19\x82\xd3\xe4\x93\x02\x13\x12\x11/v1/prescriptions\x12\x9a\x01\n" +
+	"\x12UpdatePrescription\x12#.serverpb.UpdatePrescriptionRequest\x1a$.serverpb.UpdatePrescriptionResponse\"9\x82\xd3\xe4\x93\x023:\fprescription2#/v1/prescriptions/{prescription.id}\x12\x7f\n" +
+	"\x12DeletePrescription\x12#.serverpb.DeletePrescriptionRequest\x1a$.serverpb.DeletePrescriptionResponse\"\x1e\x82\xd3\xe4\x93\x02\x18*\x16/v1/prescriptions/{id}\x12o\n" +
+	"\x0fListAuditEvents\x12 .serverpb.ListAuditEventsRequest\x1a!.serverpb.ListAuditEventsResponse\"\x17\x82\xd3\xe4\x93\x02\x11\x12\x0f/v1/auditEvents\x12\x8b\x01\n" +
+	"\x11AddCareTeamMember\x12\".serverpb.AddCareTeamMemberRequest\x1a#.serverpb.AddCareTeamMemberResponse\"-\x82\xd3\xe4\x93\x02':\x01*\"\"/v1/patients/{patient_id}/careTeam\x12\x9a\x01\n" +
+	"\x14RemoveCareTeamMember\x12%.serverpb.RemoveCareTeamMemberRequest\x1a&.serverpb.RemoveCareTeamMemberResponse\"3\x82\xd3\xe4\x93\x02-*+/v1/patients/{patient_id}/careTeam/{member}\x12y\n" +
+	"\fListCareTeam\x12\x1d.serverpb.ListCareTeamRequest\x1a\x1e.serverpb.ListCareTeamResponse\"*\x82\xd3\xe4\x93\x02$\x12\"/v1/patients/{patient_id}/careTeamB=Z;github.com/hcliff-zhang/playground/server/serverpb;serverpbb\x06proto3"
 
 var (
 	file_server_serverpb_api_proto_rawDescOnce sync.Once
@@ -836,7 +2533,7 @@ func file_server_serverpb_api_proto_rawDescGZIP() []byte {
 	return file_server_serverpb_api_proto_rawDescData
 }
 
-var file_server_serverpb_api_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
+var file_server_serverpb_api_proto_msgTypes = make([]protoimpl.MessageInfo, 37)
 var file_server_serverpb_api_proto_goTypes = []any{
 	(*Patient)(nil),                            // 0: serverpb.Patient
 	(*Prescription)(nil),                       // 1: serverpb.Prescription
@@ -846,40 +2543,115 @@ var file_server_serverpb_api_proto_goTypes = []any{
 	(*GetPatientResponse)(nil),                 // 5: serverpb.GetPatientResponse
 	(*ListPatientsRequest)(nil),                // 6: serverpb.ListPatientsRequest
 	(*ListPatientsResponse)(nil),               // 7: serverpb.ListPatientsResponse
-	(*CreatePrescriptionRequest)(nil),          // 8: serverpb.CreatePrescriptionRequest
-	(*CreatePrescriptionResponse)(nil),         // 9: serverpb.CreatePrescriptionResponse
-	(*GetPrescriptionRequest)(nil),             // 10: serverpb.GetPrescriptionRequest
-	(*GetPrescriptionResponse)(nil),            // 11: serverpb.GetPrescriptionResponse
-	(*ListPrescriptionsForPatientRequest)(nil), // 12: serverpb.ListPrescriptionsForPatientRequest
-	(*ListPrescriptionsResponse)(nil),          // 13: serverpb.ListPrescriptionsResponse
+	(*SearchPatientsRequest)(nil),              // 8: serverpb.SearchPatientsRequest
+	(*SearchPatientsResponse)(nil),             // 9: serverpb.SearchPatientsResponse
+	(*UpdatePatientRequest)(nil),               // 10: serverpb.UpdatePatientRequest
+	(*UpdatePatientResponse)(nil),              // 11: serverpb.UpdatePatientResponse
+	(*DeletePatientRequest)(nil),               // 12: serverpb.DeletePatientRequest
+	(*DeletePatientResponse)(nil),              // 13: serverpb.DeletePatientResponse
+	(*UndeletePatientRequest)(nil),             // 14: serverpb.UndeletePatientRequest
+	(*UndeletePatientResponse)(nil),            // 15: serverpb.UndeletePatientResponse
+	(*CreatePrescriptionRequest)(nil),          // 16: serverpb.CreatePrescriptionRequest
+	(*CreatePrescriptionResponse)(nil),         // 17: serverpb.CreatePrescriptionResponse
+	(*GetPrescriptionRequest)(nil),             // 18: serverpb.GetPrescriptionRequest
+	(*GetPrescriptionResponse)(nil),            // 19: serverpb.GetPrescriptionResponse
+	(*ListPrescriptionsForPatientRequest)(nil), // 20: serverpb.ListPrescriptionsForPatientRequest
+	(*ListPrescriptionsRequest)(nil),           // 21: serverpb.ListPrescriptionsRequest
+	(*ListPrescriptionsResponse)(nil),          // 22: serverpb.ListPrescriptionsResponse
+	(*UpdatePrescriptionRequest)(nil),          // 23: serverpb.UpdatePrescriptionRequest
+	(*UpdatePrescriptionResponse)(nil),         // 24: serverpb.UpdatePrescriptionResponse
+	(*DeletePrescriptionRequest)(nil),          // 25: serverpb.DeletePrescriptionRequest
+	(*DeletePrescriptionResponse)(nil),         // 26: serverpb.DeletePrescriptionResponse
+	(*AuditEvent)(nil),                         // 27: serverpb.AuditEvent
+	(*ListAuditEventsRequest)(nil),             // 28: serverpb.ListAuditEventsRequest
+	(*ListAuditEventsResponse)(nil),            // 29: serverpb.ListAuditEventsResponse
+	(*CareTeamMember)(nil),                     // 30: serverpb.CareTeamMember
+	(*AddCareTeamMemberRequest)(nil),           // 31: serverpb.AddCareTeamMemberRequest
+	(*AddCareTeamMemberResponse)(nil),          // 32: serverpb.AddCareTeamMemberResponse
+	(*RemoveCareTeamMemberRequest)(nil),        // 33: serverpb.RemoveCareTeamMemberRequest
+	(*RemoveCareTeamMemberResponse)(nil),       // 34: serverpb.RemoveCareTeamMemberResponse
+	(*ListCareTeamRequest)(nil),                // 35: serverpb.ListCareTeamRequest
+	(*ListCareTeamResponse)(nil),               // 36: serverpb.ListCareTeamResponse
+	(*timestamppb.Timestamp)(nil),              // 37: google.protobuf.Timestamp
+	(*fieldmaskpb.FieldMask)(nil),              // 38: google.protobuf.FieldMask
+	(*structpb.Struct)(nil),                    // 39: google.protobuf.Struct
 }
 var file_server_serverpb_api_proto_depIdxs = []int32{
 	1,  // 0: serverpb.Patient.prescriptions:type_name -> serverpb.Prescription
-	0,  // 1: serverpb.CreatePatientRequest.patient:type_name -> serverpb.Patient
-	0,  // 2: serverpb.CreatePatientResponse.patient:type_name -> serverpb.Patient
-	0,  // 3: serverpb.GetPatientResponse.patient:type_name -> serverpb.Patient
-	0,  // 4: serverpb.ListPatientsResponse.patients:type_name -> serverpb.Patient
-	1,  // 5: serverpb.CreatePrescriptionRequest.prescription:type_name -> serverpb.Prescription
-	1,  // 6: serverpb.CreatePrescriptionResponse.prescription:type_name -> serverpb.Prescription
-	1,  // 7: serverpb.GetPrescriptionResponse.prescription:type_name -> serverpb.Prescription
-	1,  // 8: serverpb.ListPrescriptionsResponse.prescriptions:type_name -> serverpb.Prescription
-	2,  // 9: serverpb.Api.CreatePatient:input_type -> serverpb.CreatePatientRequest
-	4,  // 10: serverpb.Api.GetPatient:input_type -> serverpb.GetPatientRequest
-	6,  // 11: serverpb.Api.ListPatients:input_type -> serverpb.ListPatientsRequest
-	8,  // 12: serverpb.Api.CreatePrescription:input_type -> serverpb.CreatePrescriptionRequest
-	10, // 13: serverpb.Api.GetPrescription:input_type -> serverpb.GetPrescriptionRequest
-	12, // 14: serverpb.Api.ListPrescriptionsForPatient:input_type -> serverpb.ListPrescriptionsForPatientRequest
-	3,  // 15: serverpb.Api.CreatePatient:output_type -> serverpb.CreatePatientResponse
-	5,  // 16: serverpb.Api.GetPatient:output_type -> serverpb.GetPatientResponse
-	7,  // 17: serverpb.Api.ListPatients:output_type -> serverpb.ListPatientsResponse
-	9,  // 18: serverpb.Api.CreatePrescription:output_type -> serverpb.CreatePrescriptionResponse
-	11, // 19: serverpb.Api.GetPrescription:output_type -> serverpb.GetPrescriptionResponse
-	13, // 20: serverpb.Api.ListPrescriptionsForPatient:output_type -> serverpb.ListPrescriptionsResponse
-	15, // [15:21] is the sub-list for method output_type
-	9,  // [9:15] is the sub-list for method input_type
-	9,  // [9:9] is the sub-list for extension type_name
-	9,  // [9:9] is the sub-list for extension extendee
-	0,  // [0:9] is the sub-list for field type_name
+	37, // 1: serverpb.Patient.created_at:type_name -> google.protobuf.Timestamp
+	37, // 2: serverpb.Patient.updated_at:type_name -> google.protobuf.Timestamp
+	37, // 3: serverpb.Patient.deleted_at:type_name -> google.protobuf.Timestamp
+	37, // 4: serverpb.Prescription.prescribed_at:type_name -> google.protobuf.Timestamp
+	37, // 5: serverpb.Prescription.created_at:type_name -> google.protobuf.Timestamp
+	37, // 6: serverpb.Prescription.updated_at:type_name -> google.protobuf.Timestamp
+	37, // 7: serverpb.Prescription.deleted_at:type_name -> google.protobuf.Timestamp
+	0,  // 8: serverpb.CreatePatientRequest.patient:type_name -> serverpb.Patient
+	0,  // 9: serverpb.CreatePatientResponse.patient:type_name -> serverpb.Patient
+	0,  // 10: serverpb.GetPatientResponse.patient:type_name -> serverpb.Patient
+	0,  // 11: serverpb.ListPatientsResponse.patients:type_name -> serverpb.Patient
+	0,  // 12: serverpb.SearchPatientsResponse.patients:type_name -> serverpb.Patient
+	0,  // 13: serverpb.UpdatePatientRequest.patient:type_name -> serverpb.Patient
+	38, // 14: serverpb.UpdatePatientRequest.update_mask:type_name -> google.protobuf.FieldMask
+	0,  // 15: serverpb.UpdatePatientResponse.patient:type_name -> serverpb.Patient
+	0,  // 16: serverpb.UndeletePatientResponse.patient:type_name -> serverpb.Patient
+	1,  // 17: serverpb.CreatePrescriptionRequest.prescription:type_name -> serverpb.Prescription
+	1,  // 18: serverpb.CreatePrescriptionResponse.prescription:type_name -> serverpb.Prescription
+	1,  // 19: serverpb.GetPrescriptionResponse.prescription:type_name -> serverpb.Prescription
+	37, // 20: serverpb.ListPrescriptionsForPatientRequest.prescribed_after:type_name -> google.protobuf.Timestamp
+	37, // 21: serverpb.ListPrescriptionsForPatientRequest.prescribed_before:type_name -> google.protobuf.Timestamp
+	37, // 22: serverpb.ListPrescriptionsRequest.prescribed_after:type_name -> google.protobuf.Timestamp
+	37, // 23: serverpb.ListPrescriptionsRequest.prescribed_before:type_name -> google.protobuf.Timestamp
+	1,  // 24: serverpb.ListPrescriptionsResponse.prescriptions:type_name -> serverpb.Prescription
+	1,  // 25: serverpb.UpdatePrescriptionRequest.prescription:type_name -> serverpb.Prescription
+	38, // 26: serverpb.UpdatePrescriptionRequest.update_mask:type_name -> google.protobuf.FieldMask
+	1,  // 27: serverpb.UpdatePrescriptionResponse.prescription:type_name -> serverpb.Prescription
+	37, // 28: serverpb.AuditEvent.time:type_name -> google.protobuf.Timestamp
+	39, // 29: serverpb.AuditEvent.changes:type_name -> google.protobuf.Struct
+	37, // 30: serverpb.ListAuditEventsRequest.start_time:type_name -> google.protobuf.Timestamp
+	37, // 31: serverpb.ListAuditEventsRequest.end_time:type_name -> google.protobuf.Timestamp
+	27, // 32: serverpb.ListAuditEventsResponse.events:type_name -> serverpb.AuditEvent
+	37, // 33: serverpb.CareTeamMember.created_at:type_name -> google.protobuf.Timestamp
+	30, // 34: serverpb.AddCareTeamMemberResponse.member:type_name -> serverpb.CareTeamMember
+	30, // 35: serverpb.ListCareTeamResponse.members:type_name -> serverpb.CareTeamMember
+	2,  // 36: serverpb.Api.CreatePatient:input_type -> serverpb.CreatePatientRequest
+	4,  // 37: serverpb.Api.GetPatient:input_type -> serverpb.GetPatientRequest
+	6,  // 38: serverpb.Api.ListPatients:input_type -> serverpb.ListPatientsRequest
+	8,  // 39: serverpb.Api.SearchPatients:input_type -> serverpb.SearchPatientsRequest
+	10, // 40: serverpb.Api.UpdatePatient:input_type -> serverpb.UpdatePatientRequest
+	12, // 41: serverpb.Api.DeletePatient:input_type -> serverpb.DeletePatientRequest
+	14, // 42: serverpb.Api.UndeletePatient:input_type -> serverpb.UndeletePatientRequest
+	16, // 43: serverpb.Api.CreatePrescription:input_type -> serverpb.CreatePrescriptionRequest
+	18, // 44: serverpb.Api.GetPrescription:input_type -> serverpb.GetPrescriptionRequest
+	20, // 45: serverpb.Api.ListPrescriptionsForPatient:input_type -> serverpb.ListPrescriptionsForPatientRequest
+	21, // 46: serverpb.Api.ListPrescriptions:input_type -> serverpb.ListPrescriptionsRequest
+	23, // 47: serverpb.Api.UpdatePrescription:input_type -> serverpb.UpdatePrescriptionRequest
+	25, // 48: serverpb.Api.DeletePrescription:input_type -> serverpb.DeletePrescriptionRequest
+	28, // 49: serverpb.Api.ListAuditEvents:input_type -> serverpb.ListAuditEventsRequest
+	31, // 50: serverpb.Api.AddCareTeamMember:input_type -> serverpb.AddCareTeamMemberRequest
+	33, // 51: serverpb.Api.RemoveCareTeamMember:input_type -> serverpb.RemoveCareTeamMemberRequest
+	35, // 52: serverpb.Api.ListCareTeam:input_type -> serverpb.ListCareTeamRequest
+	3,  // 53: serverpb.Api.CreatePatient:output_type -> serverpb.CreatePatientResponse
+	5,  // 54: serverpb.Api.GetPatient:output_type -> serverpb.GetPatientResponse
+	7,  // 55: serverpb.Api.ListPatients:output_type -> serverpb.ListPatientsResponse
+	9,  // 56: serverpb.Api.SearchPatients:output_type -> serverpb.SearchPatientsResponse
+	11, // 57: serverpb.Api.UpdatePatient:output_type -> serverpb.UpdatePatientResponse
+	13, // 58: serverpb.Api.DeletePatient:output_type -> serverpb.DeletePatientResponse
+	15, // 59: serverpb.Api.UndeletePatient:output_type -> serverpb.UndeletePatientResponse
+	17, // 60: serverpb.Api.CreatePrescription:output_type -> serverpb.CreatePrescriptionResponse
+	19, // 61: serverpb.Api.GetPrescription:output_type -> serverpb.GetPrescriptionResponse
+	22, // 62: serverpb.Api.ListPrescriptionsForPatient:output_type -> serverpb.ListPrescriptionsResponse
+	22, // 63: serverpb.Api.ListPrescriptions:output_type -> serverpb.ListPrescriptionsResponse
+	24, // 64: serverpb.Api.UpdatePrescription:output_type -> serverpb.UpdatePrescriptionResponse
+	26, // 65: serverpb.Api.DeletePrescription:output_type -> serverpb.DeletePrescriptionResponse
+	29, // 66: serverpb.Api.ListAuditEvents:output_type -> serverpb.ListAuditEventsResponse
+	32, // 67: serverpb.Api.AddCareTeamMember:output_type -> serverpb.AddCareTeamMemberResponse
+	34, // 68: serverpb.Api.RemoveCareTeamMember:output_type -> serverpb.RemoveCareTeamMemberResponse
+	36, // 69: serverpb.Api.ListCareTeam:output_type -> serverpb.ListCareTeamResponse
+	53, // [53:70] is the sub-list for method output_type
+	36, // [36:53] is the sub-list for method input_type
+	36, // [36:36] is the sub-list for extension type_name
+	36, // [36:36] is the sub-list for extension extendee
+	0,  // [0:36] is the sub-list for field type_name
 }
 
 func init() { file_server_serverpb_api_proto_init() }
@@ -893,7 +2665,7 @@ func file_server_serverpb_api_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_server_serverpb_api_proto_rawDesc), len(file_server_serverpb_api_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   14,
+			NumMessages:   37,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -22,9 +22,20 @@ const (
 	Api_CreatePatient_FullMethodName               = "/serverpb.Api/CreatePatient"
 	Api_GetPatient_FullMethodName                  = "/serverpb.Api/GetPatient"
 	Api_ListPatients_FullMethodName                = "/serverpb.Api/ListPatients"
+	Api_SearchPatients_FullMethodName              = "/serverpb.Api/SearchPatients"
+	Api_UpdatePatient_FullMethodName               = "/serverpb.Api/UpdatePatient"
+	Api_DeletePatient_FullMethodName               = "/serverpb.Api/DeletePatient"
+	Api_UndeletePatient_FullMethodName             = "/serverpb.Api/UndeletePatient"
 	Api_CreatePrescription_FullMethodName          = "/serverpb.Api/CreatePrescription"
 	Api_GetPrescription_FullMethodName             = "/serverpb.Api/GetPrescription"
 	Api_ListPrescriptionsForPatient_FullMethodName = "/serverpb.Api/ListPrescriptionsForPatient"
+	Api_ListPrescriptions_FullMethodName           = "/serverpb.Api/ListPrescriptions"
+	Api_UpdatePrescription_FullMethodName          = "/serverpb.Api/UpdatePrescription"
+	Api_DeletePrescription_FullMethodName          = "/serverpb.Api/DeletePrescription"
+	Api_ListAuditEvents_FullMethodName             = "/serverpb.Api/ListAuditEvents"
+	Api_AddCareTeamMember_FullMethodName           = "/serverpb.Api/AddCareTeamMember"
+	Api_RemoveCareTeamMember_FullMethodName        = "/serverpb.Api/RemoveCareTeamMember"
+	Api_ListCareTeam_FullMethodName                = "/serverpb.Api/ListCareTeam"
 )
 
 // ApiClient is the client API for Api service.
@@ -36,9 +47,20 @@ type ApiClient interface {
 	CreatePatient(ctx context.Context, in *CreatePatientRequest, opts ...grpc.CallOption) (*CreatePatientResponse, error)
 	GetPatient(ctx context.Context, in *GetPatientRequest, opts ...grpc.CallOption) (*GetPatientResponse, error)
 	ListPatients(ctx context.Context, in *ListPatientsRequest, opts ...grpc.CallOption) (*ListPatientsResponse, error)
+	SearchPatients(ctx context.Context, in *SearchPatientsRequest, opts ...grpc.CallOption) (*SearchPatientsResponse, error)
+	UpdatePatient(ctx context.Context, in *UpdatePatientRequest, opts ...grpc.CallOption) (*UpdatePatientResponse, error)
+	DeletePatient(ctx context.Context, in *DeletePatientRequest, opts ...grpc.CallOption) (*DeletePatientResponse, error)
+	UndeletePatient(ctx context.Context, in *UndeletePatientRequest, opts ...grpc.CallOption) (*UndeletePatientResponse, error)
 	CreatePrescription(ctx context.Context, in *CreatePrescriptionRequest, opts ...grpc.CallOption) (*CreatePrescriptionResponse, error)
 	GetPrescription(ctx context.Context, in *GetPrescriptionRequest, opts ...grpc.CallOption) (*GetPrescriptionResponse, error)
 	ListPrescriptionsForPatient(ctx context.Context, in *ListPrescriptionsForPatientRequest, opts ...grpc.CallOption) (*ListPrescriptionsResponse, error)
+	ListPrescriptions(ctx context.Context, in *ListPrescriptionsRequest, opts ...grpc.CallOption) (*ListPrescriptionsResponse, error)
+	UpdatePrescription(ctx context.Context, in *UpdatePrescriptionRequest, opts ...grpc.CallOption) (*UpdatePrescriptionResponse, error)
+	DeletePrescription(ctx context.Context, in *DeletePrescriptionRequest, opts ...grpc.CallOption) (*DeletePrescriptionResponse, error)
+	ListAuditEvents(ctx context.Context, in *ListAuditEventsRequest, opts ...grpc.CallOption) (*ListAuditEventsResponse, error)
+	AddCareTeamMember(ctx context.Context, in *AddCareTeamMemberRequest, opts ...grpc.CallOption) (*AddCareTeamMemberResponse, error)
+	RemoveCareTeamMember(ctx context.Context, in *RemoveCareTeamMemberRequest, opts ...grpc.CallOption) (*RemoveCareTeamMemberResponse, error)
+	ListCareTeam(ctx context.Context, in *ListCareTeamRequest, opts ...grpc.CallOption) (*ListCareTeamResponse, error)
 }
 
 type apiClient struct {
@@ -79,6 +101,46 @@ func (c *apiClient) ListPatients(ctx context.Context, in *ListPatientsRequest, o
 	return out, nil
 }
 
+func (c *apiClient) SearchPatients(ctx context.Context, in *SearchPatientsRequest, opts ...grpc.CallOption) (*SearchPatientsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SearchPatientsResponse)
+	err := c.cc.Invoke(ctx, Api_SearchPatients_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *apiClient) UpdatePatient(ctx context.Context, in *UpdatePatientRequest, opts ...grpc.CallOption) (*UpdatePatientResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UpdatePatientResponse)
+	err := c.cc.Invoke(ctx, Api_UpdatePatient_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *apiClient) DeletePatient(ctx context.Context, in *DeletePatientRequest, opts ...grpc.CallOption) (*DeletePatientResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeletePatientResponse)
+	err := c.cc.Invoke(ctx, Api_DeletePatient_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *apiClient) UndeletePatient(ctx context.Context, in *UndeletePatientRequest, opts ...grpc.CallOption) (*UndeletePatientResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UndeletePatientResponse)
+	err := c.cc.Invoke(ctx, Api_UndeletePatient_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *apiClient) CreatePrescription(ctx context.Context, in *CreatePrescriptionRequest, opts ...grpc.CallOption) (*CreatePrescriptionResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(CreatePrescriptionResponse)
@@ -109,6 +171,76 @@ func (c *apiClient) ListPrescriptionsForPatient(ctx context.Context, in *ListPre
 	return out, nil
 }
 
+func (c *apiClient) ListPrescriptions(ctx context.Context, in *ListPrescriptionsRequest, opts ...grpc.CallOption) (*ListPrescriptionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListPrescriptionsResponse)
+	err := c.cc.Invoke(ctx, Api_ListPrescriptions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *apiClient) UpdatePrescription(ctx context.Context, in *UpdatePrescriptionRequest, opts ...grpc.CallOption) (*UpdatePrescriptionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UpdatePrescriptionResponse)
+	err := c.cc.Invoke(ctx, Api_UpdatePrescription_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *apiClient) DeletePrescription(ctx context.Context, in *DeletePrescriptionRequest, opts ...grpc.CallOption) (*DeletePrescriptionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeletePrescriptionResponse)
+	err := c.cc.Invoke(ctx, Api_DeletePrescription_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *apiClient) ListAuditEvents(ctx context.Context, in *ListAuditEventsRequest, opts ...grpc.CallOption) (*ListAuditEventsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListAuditEventsResponse)
+	err := c.cc.Invoke(ctx, Api_ListAuditEvents_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *apiClient) AddCareTeamMember(ctx context.Context, in *AddCareTeamMemberRequest, opts ...grpc.CallOption) (*AddCareTeamMemberResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AddCareTeamMemberResponse)
+	err := c.cc.Invoke(ctx, Api_AddCareTeamMember_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *apiClient) RemoveCareTeamMember(ctx context.Context, in *RemoveCareTeamMemberRequest, opts ...grpc.CallOption) (*RemoveCareTeamMemberResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RemoveCareTeamMemberResponse)
+	err := c.cc.Invoke(ctx, Api_RemoveCareTeamMember_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *apiClient) ListCareTeam(ctx context.Context, in *ListCareTeamRequest, opts ...grpc.CallOption) (*ListCareTeamResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListCareTeamResponse)
+	err := c.cc.Invoke(ctx, Api_ListCareTeam_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ApiServer is the server API for Api service.
 // All implementations must embed UnimplementedApiServer
 // for forward compatibility.
@@ -118,9 +250,20 @@ type ApiServer interface {
 	CreatePatient(context.Context, *CreatePatientRequest) (*CreatePatientResponse, error)
 	GetPatient(context.Context, *GetPatientRequest) (*GetPatientResponse, error)
 	ListPatients(context.Context, *ListPatientsRequest) (*ListPatientsResponse, error)
+	SearchPatients(context.Context, *SearchPatientsRequest) (*SearchPatientsResponse, error)
+	UpdatePatient(context.Context, *UpdatePatientRequest) (*UpdatePatientResponse, error)
+	DeletePatient(context.Context, *DeletePatientRequest) (*DeletePatientResponse, error)
+	UndeletePatient(context.Context, *UndeletePatientRequest) (*UndeletePatientResponse, error)
 	CreatePrescription(context.Context, *CreatePrescriptionRequest) (*CreatePrescriptionResponse, error)
 	GetPrescription(context.Context, *GetPrescriptionRequest) (*GetPrescriptionResponse, error)
 	ListPrescriptionsForPatient(context.Context, *ListPrescriptionsForPatientRequest) (*ListPrescriptionsResponse, error)
+	ListPrescriptions(context.Context, *ListPrescriptionsRequest) (*ListPrescriptionsResponse, error)
+	UpdatePrescription(context.Context, *UpdatePrescriptionRequest) (*UpdatePrescriptionResponse, error)
+	DeletePrescription(context.Context, *DeletePrescriptionRequest) (*DeletePrescriptionResponse, error)
+	ListAuditEvents(context.Context, *ListAuditEventsRequest) (*ListAuditEventsResponse, error)
+	AddCareTeamMember(context.Context, *AddCareTeamMemberRequest) (*AddCareTeamMemberResponse, error)
+	RemoveCareTeamMember(context.Context, *RemoveCareTeamMemberRequest) (*RemoveCareTeamMemberResponse, error)
+	ListCareTeam(context.Context, *ListCareTeamRequest) (*ListCareTeamResponse, error)
 	mustEmbedUnimplementedApiServer()
 }
 
@@ -140,6 +283,18 @@ func (UnimplementedApiServer) GetPatient(context.Context, *GetPatientRequest) (*
 func (UnimplementedApiServer) ListPatients(context.Context, *ListPatientsRequest) (*ListPatientsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListPatients not implemented")
 }
+func (UnimplementedApiServer) SearchPatients(context.Context, *SearchPatientsRequest) (*SearchPatientsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SearchPatients not implemented")
+}
+func (UnimplementedApiServer) UpdatePatient(context.Context, *UpdatePatientRequest) (*UpdatePatientResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdatePatient not implemented")
+}
+func (UnimplementedApiServer) DeletePatient(context.Context, *DeletePatientRequest) (*DeletePatientResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeletePatient not implemented")
+}
+func (UnimplementedApiServer) UndeletePatient(context.Context, *UndeletePatientRequest) (*UndeletePatientResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UndeletePatient not implemented")
+}
 func (UnimplementedApiServer) CreatePrescription(context.Context, *CreatePrescriptionRequest) (*CreatePrescriptionResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CreatePrescription not implemented")
 }
@@ -149,6 +304,27 @@ func (UnimplementedApiServer) GetPrescription(context.Context, *GetPrescriptionR
 func (UnimplementedApiServer) ListPrescriptionsForPatient(context.Context, *ListPrescriptionsForPatientRequest) (*ListPrescriptionsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListPrescriptionsForPatient not implemented")
 }
+func (UnimplementedApiServer) ListPrescriptions(context.Context, *ListPrescriptionsRequest) (*ListPrescriptionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListPrescriptions not implemented")
+}
+func (UnimplementedApiServer) UpdatePrescription(context.Context, *UpdatePrescriptionRequest) (*UpdatePrescriptionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdatePrescription not implemented")
+}
+func (UnimplementedApiServer) DeletePrescription(context.Context, *DeletePrescriptionRequest) (*DeletePrescriptionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeletePrescription not implemented")
+}
+func (UnimplementedApiServer) ListAuditEvents(context.Context, *ListAuditEventsRequest) (*ListAuditEventsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAuditEvents not implemented")
+}
+func (UnimplementedApiServer) AddCareTeamMember(context.Context, *AddCareTeamMemberRequest) (*AddCareTeamMemberResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddCareTeamMember not implemented")
+}
+func (UnimplementedApiServer) RemoveCareTeamMember(context.Context, *RemoveCareTeamMemberRequest) (*RemoveCareTeamMemberResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveCareTeamMember not implemented")
+}
+func (UnimplementedApiServer) ListCareTeam(context.Context, *ListCareTeamRequest) (*ListCareTeamResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListCareTeam not implemented")
+}
 func (UnimplementedApiServer) mustEmbedUnimplementedApiServer() {}
 func (UnimplementedApiServer) testEmbeddedByValue()             {}
 
@@ -224,6 +400,78 @@ func _Api_ListPatients_Handler(srv interface{}, ctx context.Context, dec func(in
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Api_SearchPatients_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchPatientsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ApiServer).SearchPatients(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Api_SearchPatients_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ApiServer).SearchPatients(ctx, req.(*SearchPatientsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Api_UpdatePatient_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdatePatientRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ApiServer).UpdatePatient(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Api_UpdatePatient_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ApiServer).UpdatePatient(ctx, req.(*UpdatePatientRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Api_DeletePatient_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeletePatientRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ApiServer).DeletePatient(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Api_DeletePatient_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ApiServer).DeletePatient(ctx, req.(*DeletePatientRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Api_UndeletePatient_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UndeletePatientRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ApiServer).UndeletePatient(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Api_UndeletePatient_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ApiServer).UndeletePatient(ctx, req.(*UndeletePatientRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Api_CreatePrescription_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(CreatePrescriptionRequest)
 	if err := dec(in); err != nil {
@@ -278,6 +526,132 @@ func _Api_ListPrescriptionsForPatient_Handler(srv interface{}, ctx context.Conte
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Api_ListPrescriptions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListPrescriptionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ApiServer).ListPrescriptions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Api_ListPrescriptions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ApiServer).ListPrescriptions(ctx, req.(*ListPrescriptionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Api_UpdatePrescription_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdatePrescriptionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ApiServer).UpdatePrescription(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Api_UpdatePrescription_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ApiServer).UpdatePrescription(ctx, req.(*UpdatePrescriptionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Api_DeletePrescription_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeletePrescriptionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ApiServer).DeletePrescription(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Api_DeletePrescription_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ApiServer).DeletePrescription(ctx, req.(*DeletePrescriptionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Api_ListAuditEvents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAuditEventsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ApiServer).ListAuditEvents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Api_ListAuditEvents_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ApiServer).ListAuditEvents(ctx, req.(*ListAuditEventsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Api_AddCareTeamMember_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddCareTeamMemberRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ApiServer).AddCareTeamMember(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Api_AddCareTeamMember_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ApiServer).AddCareTeamMember(ctx, req.(*AddCareTeamMemberRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Api_RemoveCareTeamMember_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveCareTeamMemberRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ApiServer).RemoveCareTeamMember(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Api_RemoveCareTeamMember_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ApiServer).RemoveCareTeamMember(ctx, req.(*RemoveCareTeamMemberRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Api_ListCareTeam_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListCareTeamRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ApiServer).ListCareTeam(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Api_ListCareTeam_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ApiServer).ListCareTeam(ctx, req.(*ListCareTeamRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Api_ServiceDesc is the grpc.ServiceDesc for Api service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -297,6 +671,22 @@ var Api_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ListPatients",
 			Handler:    _Api_ListPatients_Handler,
 		},
+		{
+			MethodName: "SearchPatients",
+			Handler:    _Api_SearchPatients_Handler,
+		},
+		{
+			MethodName: "UpdatePatient",
+			Handler:    _Api_UpdatePatient_Handler,
+		},
+		{
+			MethodName: "DeletePatient",
+			Handler:    _Api_DeletePatient_Handler,
+		},
+		{
+			MethodName: "UndeletePatient",
+			Handler:    _Api_UndeletePatient_Handler,
+		},
 		{
 			MethodName: "CreatePrescription",
 			Handler:    _Api_CreatePrescription_Handler,
@@ -309,6 +699,34 @@ var Api_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ListPrescriptionsForPatient",
 			Handler:    _Api_ListPrescriptionsForPatient_Handler,
 		},
+		{
+			MethodName: "ListPrescriptions",
+			Handler:    _Api_ListPrescriptions_Handler,
+		},
+		{
+			MethodName: "UpdatePrescription",
+			Handler:    _Api_UpdatePrescription_Handler,
+		},
+		{
+			MethodName: "DeletePrescription",
+			Handler:    _Api_DeletePrescription_Handler,
+		},
+		{
+			MethodName: "ListAuditEvents",
+			Handler:    _Api_ListAuditEvents_Handler,
+		},
+		{
+			MethodName: "AddCareTeamMember",
+			Handler:    _Api_AddCareTeamMember_Handler,
+		},
+		{
+			MethodName: "RemoveCareTeamMember",
+			Handler:    _Api_RemoveCareTeamMember_Handler,
+		},
+		{
+			MethodName: "ListCareTeam",
+			Handler:    _Api_ListCareTeam_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "server/serverpb/api.proto",